@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"plandex/types"
+	"strconv"
 	"strings"
 
 	"github.com/plandex/plandex/shared"
@@ -232,7 +233,7 @@ func (a *Api) ListArchivedPlans(projectIds []string) ([]*shared.Plan, *shared.Ap
 	return plans, nil
 }
 
-func (a *Api) ListPlansRunning(projectIds []string, includeRecent bool) (*shared.ListPlansRunningResponse, *shared.ApiError) {
+func (a *Api) ListPlansRunning(projectIds []string, includeRecent, includeShared bool) (*shared.ListPlansRunningResponse, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/ps?", getApiHost())
 	parts := []string{}
 	for _, projectId := range projectIds {
@@ -242,6 +243,9 @@ func (a *Api) ListPlansRunning(projectIds []string, includeRecent bool) (*shared
 	if includeRecent {
 		serverUrl += "&recent=true"
 	}
+	if includeShared {
+		serverUrl += "&shared=true"
+	}
 
 	resp, err := authenticatedFastClient.Get(serverUrl)
 	if err != nil {
@@ -254,7 +258,7 @@ func (a *Api) ListPlansRunning(projectIds []string, includeRecent bool) (*shared
 		apiErr := handleApiError(resp, errorBody)
 		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
 		if tokenRefreshed {
-			return a.ListPlansRunning(projectIds, includeRecent)
+			return a.ListPlansRunning(projectIds, includeRecent, includeShared)
 		}
 		return nil, apiErr
 	}
@@ -447,6 +451,7 @@ func (a *Api) TellPlan(planId, branch string, req shared.TellPlanRequest, onStre
 	var client *http.Client
 	if req.ConnectStream {
 		client = authenticatedStreamingClient
+		setStreamProtocolHeader(request)
 	} else {
 		client = authenticatedFastClient
 	}
@@ -470,7 +475,7 @@ func (a *Api) TellPlan(planId, branch string, req shared.TellPlanRequest, onStre
 
 	if req.ConnectStream {
 		log.Println("Connecting stream")
-		connectPlanRespStream(resp.Body, onStream)
+		connectPlanRespStream(resp, planId, branch, false, onStream)
 	} else {
 		// log.Println("Background exec - not connecting stream")
 		resp.Body.Close()
@@ -498,6 +503,7 @@ func (a *Api) BuildPlan(planId, branch string, req shared.BuildPlanRequest, onSt
 	var client *http.Client
 	if req.ConnectStream {
 		client = authenticatedStreamingClient
+		setStreamProtocolHeader(request)
 	} else {
 		client = authenticatedFastClient
 	}
@@ -523,7 +529,7 @@ func (a *Api) BuildPlan(planId, branch string, req shared.BuildPlanRequest, onSt
 
 	if req.ConnectStream {
 		log.Println("Connecting stream")
-		connectPlanRespStream(resp.Body, onStream)
+		connectPlanRespStream(resp, planId, branch, false, onStream)
 	} else {
 		// log.Println("Background exec - not connecting stream")
 		resp.Body.Close()
@@ -567,35 +573,83 @@ func (a *Api) RespondMissingFile(planId, branch string, req shared.RespondMissin
 
 }
 
-func (a *Api) ConnectPlan(planId, branch string, onStream types.OnStreamPlan) *shared.ApiError {
+func (a *Api) ConnectPlan(planId, branch string, watch bool, onStream types.OnStreamPlan) *shared.ApiError {
+	resp, apiErr := connectPlanStream(planId, branch, watch, 0)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	connectPlanRespStream(resp, planId, branch, watch, onStream)
+
+	return nil
+}
+
+// lastSeq > 0 asks the server to replay messages after lastSeq
+func connectPlanStream(planId, branch string, watch bool, lastSeq int) (*http.Response, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/connect", getApiHost(), planId, branch)
+	if watch {
+		serverUrl += "?watch=true"
+	}
 
 	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	setStreamProtocolHeader(req)
+
+	if lastSeq > 0 {
+		req.Header.Set("Last-Event-ID", strconv.Itoa(lastSeq))
 	}
 
 	resp, err := authenticatedStreamingClient.Do(req)
 	if err != nil {
-		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+		return nil, &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
 
 	if resp.StatusCode >= 400 {
 		errorBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		apiErr := handleApiError(resp, errorBody)
 
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 
 		if didRefresh {
-			return a.ConnectPlan(planId, branch, onStream)
+			return connectPlanStream(planId, branch, watch, lastSeq)
 		}
 
-		return apiErr
+		return nil, apiErr
 	}
 
-	connectPlanRespStream(resp.Body, onStream)
+	return resp, nil
+}
 
-	return nil
+func (a *Api) GetStreamLog(streamId string) (*shared.StreamLog, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/streams/%s/log", getApiHost(), streamId)
+
+	resp, err := authenticatedSlowClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetStreamLog(streamId)
+		}
+		return nil, apiErr
+	}
+
+	var streamLog shared.StreamLog
+	err = json.NewDecoder(resp.Body).Decode(&streamLog)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &streamLog, nil
 }
 
 func (a *Api) StopPlan(planId, branch string) *shared.ApiError {
@@ -653,15 +707,21 @@ func (a *Api) GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanSta
 	return &state, nil
 }
 
-func (a *Api) ApplyPlan(planId, branch string) *shared.ApiError {
+func (a *Api) ApplyPlan(planId, branch string, req shared.ApplyPlanRequest) *shared.ApiError {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/apply", getApiHost(), planId, branch)
 
-	req, err := http.NewRequest(http.MethodPatch, serverUrl, nil)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := authenticatedFastClient.Do(req)
+	resp, err := authenticatedFastClient.Do(httpReq)
 	if err != nil {
 		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
 	}
@@ -673,7 +733,41 @@ func (a *Api) ApplyPlan(planId, branch string) *shared.ApiError {
 
 		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
 		if didRefresh {
-			return a.ApplyPlan(planId, branch)
+			return a.ApplyPlan(planId, branch, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) UnapplyPlan(planId, branch string, req shared.UnapplyPlanRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/unapply", getApiHost(), planId, branch)
+
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(httpReq)
+	if err != nil {
+		return &shared.ApiError{Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+
+		didRefresh, apiErr := refreshTokenIfNeeded(apiErr)
+		if didRefresh {
+			return a.UnapplyPlan(planId, branch, req)
 		}
 		return apiErr
 	}
@@ -884,6 +978,76 @@ func (a *Api) DeleteContext(planId, branch string, req shared.DeleteContextReque
 	return &deleteContextResponse, nil
 }
 
+func (a *Api) SetContextPriority(planId, branch string, req shared.SetContextPriorityRequest) (*shared.SetContextPriorityResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context/priority", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPatch, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.SetContextPriority(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var setContextPriorityResponse shared.SetContextPriorityResponse
+	err = json.NewDecoder(resp.Body).Decode(&setContextPriorityResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &setContextPriorityResponse, nil
+}
+
+func (a *Api) GetMissingContextBlobs(planId, branch string, req shared.MissingContextBlobsRequest) (*shared.MissingContextBlobsResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context_blobs/missing", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetMissingContextBlobs(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.MissingContextBlobsResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
 func (a *Api) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
 	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context", getApiHost(), planId, branch)
 
@@ -1008,6 +1172,45 @@ func (a *Api) RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*
 	return &rewindPlanResponse, nil
 }
 
+func (a *Api) RetryPlan(planId, branch string, req shared.RetryPlanRequest) (*shared.RetryPlanResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/retry", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPost, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.RetryPlan(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var retryPlanResponse shared.RetryPlanResponse
+	err = json.NewDecoder(resp.Body).Decode(&retryPlanResponse)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &retryPlanResponse, nil
+}
+
 func (a *Api) SignIn(req shared.SignInRequest, customHost string) (*shared.SessionResponse, *shared.ApiError) {
 	host := customHost
 	if host == "" {
@@ -1365,6 +1568,262 @@ func (a *Api) DeleteInvite(inviteId string) *shared.ApiError {
 	return nil
 }
 
+func (a *Api) ListPromptTemplates() ([]*shared.PromptTemplate, *shared.ApiError) {
+	serverUrl := getApiHost() + "/prompt_templates"
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListPromptTemplates()
+		}
+		return nil, apiErr
+	}
+
+	var templates []*shared.PromptTemplate
+	err = json.NewDecoder(resp.Body).Decode(&templates)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return templates, nil
+}
+
+func (a *Api) CreatePromptTemplate(req shared.CreatePromptTemplateRequest) (*shared.PromptTemplate, *shared.ApiError) {
+	serverUrl := getApiHost() + "/prompt_templates"
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.CreatePromptTemplate(req)
+		}
+		return nil, apiErr
+	}
+
+	var template shared.PromptTemplate
+	err = json.NewDecoder(resp.Body).Decode(&template)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &template, nil
+}
+
+func (a *Api) UpdatePromptTemplate(templateId string, req shared.UpdatePromptTemplateRequest) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/prompt_templates/%s", getApiHost(), templateId)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	request, err := http.NewRequest(http.MethodPut, serverUrl, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := authenticatedFastClient.Do(request)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.UpdatePromptTemplate(templateId, req)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) DeletePromptTemplate(templateId string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/prompt_templates/%s", getApiHost(), templateId)
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeletePromptTemplate(templateId)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
+func (a *Api) SaveContextSet(planId, branch string, req shared.SaveContextSetRequest) (*shared.SaveContextSetResponse, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/plans/%s/%s/context_sets", getApiHost(), planId, branch)
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error marshalling request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Post(serverUrl, "application/json", bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.SaveContextSet(planId, branch, req)
+		}
+		return nil, apiErr
+	}
+
+	var res shared.SaveContextSetResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &res, nil
+}
+
+func (a *Api) ListContextSets(projectId string) ([]*shared.ContextSet, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/projects/%s/context_sets", getApiHost(), projectId)
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListContextSets(projectId)
+		}
+		return nil, apiErr
+	}
+
+	var sets []*shared.ContextSet
+	err = json.NewDecoder(resp.Body).Decode(&sets)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return sets, nil
+}
+
+func (a *Api) GetContextSet(projectId, name string) (*shared.ContextSet, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/projects/%s/context_sets/%s", getApiHost(), projectId, name)
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.GetContextSet(projectId, name)
+		}
+		return nil, apiErr
+	}
+
+	var set shared.ContextSet
+	err = json.NewDecoder(resp.Body).Decode(&set)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return &set, nil
+}
+
+func (a *Api) ListContextSetVersions(projectId, name string) ([]*shared.ContextSetVersion, *shared.ApiError) {
+	serverUrl := fmt.Sprintf("%s/projects/%s/context_sets/%s/versions", getApiHost(), projectId, name)
+	resp, err := authenticatedFastClient.Get(serverUrl)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.ListContextSetVersions(projectId, name)
+		}
+		return nil, apiErr
+	}
+
+	var versions []*shared.ContextSetVersion
+	err = json.NewDecoder(resp.Body).Decode(&versions)
+	if err != nil {
+		return nil, &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error decoding response: %v", err)}
+	}
+
+	return versions, nil
+}
+
+func (a *Api) DeleteContextSet(projectId, name string) *shared.ApiError {
+	serverUrl := fmt.Sprintf("%s/projects/%s/context_sets/%s", getApiHost(), projectId, name)
+	req, err := http.NewRequest(http.MethodDelete, serverUrl, nil)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error creating request: %v", err)}
+	}
+
+	resp, err := authenticatedFastClient.Do(req)
+	if err != nil {
+		return &shared.ApiError{Type: shared.ApiErrorTypeOther, Msg: fmt.Sprintf("error sending request: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		errorBody, _ := io.ReadAll(resp.Body)
+		apiErr := handleApiError(resp, errorBody)
+		tokenRefreshed, apiErr := refreshTokenIfNeeded(apiErr)
+		if tokenRefreshed {
+			return a.DeleteContextSet(projectId, name)
+		}
+		return apiErr
+	}
+
+	return nil
+}
+
 func (a *Api) CreateEmailVerification(email, customHost, userId string) (*shared.CreateEmailVerificationResponse, *shared.ApiError) {
 	host := customHost
 	if host == "" {
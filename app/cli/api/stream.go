@@ -4,46 +4,201 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
 	"plandex/types"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/plandex/plandex/shared"
 )
 
-func connectPlanRespStream(body io.ReadCloser, onStream types.OnStreamPlan) {
+func setStreamProtocolHeader(req *http.Request) {
+	req.Header.Set(shared.STREAM_PROTOCOL_HEADER, strconv.Itoa(shared.STREAM_PROTOCOL_VERSION))
+	req.Header.Set("Accept", "text/event-stream")
+}
+
+const maxStreamReconnectAttempts = 3
+
+func connectPlanRespStream(resp *http.Response, planId, branch string, watch bool, onStream types.OnStreamPlan) {
+	version, _ := strconv.Atoi(resp.Header.Get(shared.STREAM_PROTOCOL_HEADER))
+
+	if version >= shared.STREAM_PROTOCOL_VERSION {
+		go readSSEStream(resp.Body, planId, branch, watch, onStream)
+	} else {
+		// server doesn't support SSE -- fall back to legacy framing
+		go readLegacyStream(resp.Body, onStream)
+	}
+}
+
+// returns true if the stream is done
+func handleStreamMessage(body io.ReadCloser, s string, seq int, onStream types.OnStreamPlan) bool {
+	var msg shared.StreamMessage
+	err := json.Unmarshal([]byte(s), &msg)
+	if err != nil {
+		log.Println("Error unmarshalling message:", err)
+		onStream(types.OnStreamPlanParams{Msg: nil, Err: err})
+		body.Close()
+		return true
+	}
+
+	if seq > 0 {
+		msg.Seq = seq
+	}
+
+	// log.Println("Received message:", msg)
+
+	onStream(types.OnStreamPlanParams{Msg: &msg, Err: nil})
+
+	if msg.Type == shared.StreamMessageFinished || msg.Type == shared.StreamMessageError || msg.Type == shared.StreamMessageAborted {
+		body.Close()
+		return true
+	}
+
+	return false
+}
+
+// reads SSE events until the stream finishes. If the connection drops, reconnects with the last received seq so the server can replay anything that was missed.
+func readSSEStream(body io.ReadCloser, planId, branch string, watch bool, onStream types.OnStreamPlan) {
+	lastSeq := 0
+
+	for attempt := 0; ; attempt++ {
+		done, err := readSSEEvents(body, &lastSeq, onStream)
+		if done {
+			return
+		}
+
+		// the dropped connection is replaced below, or given up on
+		body.Close()
+
+		if attempt >= maxStreamReconnectAttempts {
+			log.Println("Error reading stream:", err)
+			onStream(types.OnStreamPlanParams{Msg: nil, Err: err})
+			return
+		}
+
+		log.Printf("Stream connection lost: %v -- reconnecting from seq %d\n", err, lastSeq)
+		time.Sleep(time.Duration(1<<attempt) * time.Second)
+
+		resp, apiErr := connectPlanStream(planId, branch, watch, lastSeq)
+		if apiErr != nil {
+			log.Println("Error reconnecting to stream:", apiErr.Msg)
+			onStream(types.OnStreamPlanParams{Msg: nil, Err: fmt.Errorf("%s", apiErr.Msg)})
+			return
+		}
+
+		body = resp.Body
+	}
+}
+
+// returns true if the stream is done, otherwise the error that interrupted the connection
+func readSSEEvents(body io.ReadCloser, lastSeq *int, onStream types.OnStreamPlan) (bool, error) {
 	reader := bufio.NewReader(body)
 
+	var mu sync.Mutex
+	lastReceivedAt := time.Now()
+	done := make(chan struct{})
+	defer close(done)
+
+	// close the connection if the server stops sending heartbeats
 	go func() {
+		ticker := time.NewTicker(shared.STREAM_HEARTBEAT_INTERVAL)
+		defer ticker.Stop()
 		for {
-			s, err := readUntilSeparator(reader, shared.STREAM_MESSAGE_SEPARATOR)
-			if err != nil {
-				log.Println("Error reading line:", err)
-				onStream(types.OnStreamPlanParams{Msg: nil, Err: err})
-				body.Close()
+			select {
+			case <-done:
 				return
+			case <-ticker.C:
+				mu.Lock()
+				elapsed := time.Since(lastReceivedAt)
+				mu.Unlock()
+				if elapsed > shared.STREAM_HEARTBEAT_TIMEOUT {
+					log.Println("Stream heartbeat timeout -- closing connection")
+					body.Close()
+					return
+				}
 			}
+		}
+	}()
 
-			var msg shared.StreamMessage
-			err = json.Unmarshal([]byte(s), &msg)
-			if err != nil {
-				log.Println("Error unmarshalling message:", err)
-				onStream(types.OnStreamPlanParams{Msg: nil, Err: err})
-				body.Close()
-				return
+	var event string
+	var data []string
+	seq := 0
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			body.Close()
+			return false, err
+		}
+
+		mu.Lock()
+		lastReceivedAt = time.Now()
+		mu.Unlock()
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			// blank line dispatches the event
+			if len(data) > 0 && event != string(shared.StreamMessageHeartbeat) {
+				if seq > 0 {
+					*lastSeq = seq
+				}
+				if handleStreamMessage(body, strings.Join(data, "\n"), seq, onStream) {
+					return true, nil
+				}
 			}
+			event = ""
+			data = nil
+			seq = 0
+			continue
+		}
 
-			// log.Println("Received message:", msg)
+		if strings.HasPrefix(line, ":") {
+			// comment
+			continue
+		}
 
-			onStream(types.OnStreamPlanParams{Msg: &msg, Err: nil})
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
 
-			if msg.Type == shared.StreamMessageFinished || msg.Type == shared.StreamMessageError || msg.Type == shared.StreamMessageAborted {
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			seq, err = strconv.Atoi(value)
+			if err != nil {
+				log.Println("Error parsing stream event id:", err)
+				onStream(types.OnStreamPlanParams{Msg: nil, Err: fmt.Errorf("invalid stream event id: %s", value)})
 				body.Close()
-				return
+				return true, nil
 			}
+		}
+	}
+}
+
+func readLegacyStream(body io.ReadCloser, onStream types.OnStreamPlan) {
+	reader := bufio.NewReader(body)
 
+	for {
+		s, err := readUntilSeparator(reader, shared.STREAM_MESSAGE_SEPARATOR)
+		if err != nil {
+			log.Println("Error reading line:", err)
+			onStream(types.OnStreamPlanParams{Msg: nil, Err: err})
+			body.Close()
+			return
 		}
-	}()
+
+		if handleStreamMessage(body, s, 0, onStream) {
+			return
+		}
+	}
 }
 
 func readUntilSeparator(reader *bufio.Reader, separator string) (string, error) {
@@ -25,6 +25,7 @@ type changesUIModel struct {
 	width                    int
 	height                   int
 	shouldApplyAll           bool
+	applyFilePath            string
 	shouldRejectAll          bool
 	didCopy                  bool
 	isRejectingFile          bool
@@ -48,6 +49,7 @@ type keymap = struct {
 	switchView,
 	reject,
 	copy,
+	applyFile,
 	applyAll,
 	yes,
 	no,
@@ -132,6 +134,11 @@ func initialModel(currentPlan *shared.CurrentPlanState) *changesUIModel {
 				bubbleKey.WithHelp("c", "copy change"),
 			),
 
+			applyFile: bubbleKey.NewBinding(
+				bubbleKey.WithKeys("a"),
+				bubbleKey.WithHelp("a", "apply file"),
+			),
+
 			applyAll: bubbleKey.NewBinding(
 				bubbleKey.WithKeys("ctrl+a"),
 				bubbleKey.WithHelp("ctrl+a", "apply all changes"),
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"plandex/lib"
 	"plandex/term"
+	"plandex/types"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/plandex/plandex/shared"
@@ -38,7 +39,9 @@ func StartChangesUI(currentPlan *shared.CurrentPlanState) error {
 	}
 
 	if mod.shouldApplyAll {
-		lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, false)
+		lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, &types.ApplyPlanParams{})
+	} else if mod.applyFilePath != "" {
+		lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, &types.ApplyPlanParams{FilePaths: []string{mod.applyFilePath}})
 	}
 
 	if mod.rejectFileErr != nil {
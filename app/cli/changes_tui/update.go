@@ -142,6 +142,10 @@ func (m changesUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case bubbleKey.Matches(msg, m.keymap.no):
 			m.isConfirmingRejectFile = false
 
+		case bubbleKey.Matches(msg, m.keymap.applyFile):
+			m.applyFilePath = m.selectionInfo.currentPath
+			return m, tea.Quit
+
 		case bubbleKey.Matches(msg, m.keymap.applyAll):
 			m.shouldApplyAll = true
 			return m, tea.Quit
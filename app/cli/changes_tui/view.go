@@ -145,7 +145,7 @@ func (m changesUIModel) renderHelp() string {
 		help += "(↑/↓) select change • "
 	}
 
-	help += "(ctrl+a) apply all changes • (q)uit"
+	help += "(a)pply file • (ctrl+a) apply all changes • (q)uit"
 	style := lipgloss.NewStyle().Width(m.width).Inherit(topBorderStyle).Foreground(lipgloss.Color(helpTextColor))
 	return style.Render(help)
 }
@@ -4,23 +4,34 @@ import (
 	"fmt"
 	"plandex/auth"
 	"plandex/lib"
+	"plandex/term"
+	"plandex/types"
 
 	"github.com/spf13/cobra"
 )
 
 var autoConfirm bool
+var applySelect bool
+var applyGitBranch string
 
 func init() {
 	applyCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "Automatically confirm unless plan is outdated")
+	applyCmd.Flags().BoolVarP(&applySelect, "select", "s", false, "Select which files to apply")
+	applyCmd.Flags().StringVarP(&applyGitBranch, "branch", "b", "", "Apply and commit the changes on a git branch, then switch back")
 
 	RootCmd.AddCommand(applyCmd)
 }
 
 var applyCmd = &cobra.Command{
-	Use:     "apply [name-or-index]",
+	Use:     "apply [files...]",
 	Aliases: []string{"ap"},
 	Short:   "Apply a plan to the project",
-	Run:     apply,
+	Long: `Apply a plan's pending changes to the project.
+
+Pass file paths to apply only the changes to those files, or --select to choose them from a list. Changes to other files stay pending in the plan.
+
+Pass --branch with a git branch name to apply the changes on that branch instead of the current one. The branch is created from the current commit if it doesn't exist. Uncommitted changes to tracked files, and untracked files the plan writes to, are stashed, the changes are committed on the branch with a message built from the plan's commit messages, and then the original branch and your uncommitted changes are restored.`,
+	Run: apply,
 }
 
 func apply(cmd *cobra.Command, args []string) {
@@ -32,5 +43,22 @@ func apply(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, autoConfirm)
+	if applySelect && len(args) > 0 {
+		term.OutputErrorAndExit("--select can't be combined with file paths")
+	}
+
+	filePaths, err := lib.ApplyFilePathsFromArgs(args)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	if applySelect {
+		filePaths = lib.MustSelectApplyFilePaths(lib.CurrentPlanId, lib.CurrentBranch)
+	}
+
+	lib.MustApplyPlan(lib.CurrentPlanId, lib.CurrentBranch, &types.ApplyPlanParams{
+		AutoConfirm: autoConfirm,
+		FilePaths:   filePaths,
+		GitBranch:   applyGitBranch,
+	})
 }
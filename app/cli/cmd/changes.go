@@ -40,7 +40,7 @@ func changes(cmd *cobra.Command, args []string) {
 	// log.Println(spew.Sdump(currentPlanState))
 
 	for currentPlanState.HasPendingBuilds() {
-		plansRunningRes, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, false)
+		plansRunningRes, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, false, false)
 
 		if apiErr != nil {
 			term.StopSpinner()
@@ -10,21 +10,25 @@ import (
 	streamtui "plandex/stream_tui"
 	"plandex/term"
 
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
 var connectCmd = &cobra.Command{
 	Use:     "connect [stream-id-or-plan] [branch]",
 	Aliases: []string{"conn"},
-	Short:   "Connect to an active stream",
+	Short:   "Connect to an active stream or replay a finished one",
 	// Long:  ``,
 	Args: cobra.MaximumNArgs(2),
 	Run:  connect,
 }
 
+var connectWatch bool
+
 func init() {
 	RootCmd.AddCommand(connectCmd)
 
+	connectCmd.Flags().BoolVarP(&connectWatch, "watch", "w", false, "Follow the stream read-only (works for plans shared with your org)")
 }
 
 func connect(cmd *cobra.Command, args []string) {
@@ -36,14 +40,26 @@ func connect(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	planId, branch, shouldContinue := lib.SelectActiveStream(args)
+	if len(args) == 1 {
+		term.StartSpinner("")
+		streamLog, apiErr := api.Client.GetStreamLog(args[0])
+		term.StopSpinner()
+
+		// finished streams are replayed from the server's stream log -- otherwise fall through to active streams
+		if apiErr == nil && streamLog.FinishedAt != nil {
+			replayStream(streamLog)
+			return
+		}
+	}
+
+	planId, branch, shouldContinue := lib.SelectActiveStream(args, connectWatch)
 
 	if !shouldContinue {
 		return
 	}
 
 	term.StartSpinner("")
-	apiErr := api.Client.ConnectPlan(planId, branch, stream.OnStreamPlan)
+	apiErr := api.Client.ConnectPlan(planId, branch, connectWatch, stream.OnStreamPlan)
 	term.StopSpinner()
 
 	if apiErr != nil {
@@ -51,7 +67,12 @@ func connect(cmd *cobra.Command, args []string) {
 	}
 
 	go func() {
-		err := streamtui.StartStreamUI("", false)
+		var err error
+		if connectWatch {
+			err = streamtui.StartStreamWatchUI()
+		} else {
+			err = streamtui.StartStreamUI("", false)
+		}
 
 		if err != nil {
 			term.OutputErrorAndExit("Error starting stream UI", err)
@@ -66,3 +87,19 @@ func connect(cmd *cobra.Command, args []string) {
 	// Wait for the stream to finish
 	select {}
 }
+
+func replayStream(streamLog *shared.StreamLog) {
+	if len(streamLog.Entries) == 0 {
+		fmt.Println("🤷‍♂️ No stream log for this stream")
+		return
+	}
+
+	err := streamtui.StartStreamReplayUI(streamLog)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error starting stream UI: %v", err)
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "log", "changes")
+}
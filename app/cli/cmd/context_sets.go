@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var contextSetCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage named context sets",
+	Long: `Manage named context sets for the current project.
+
+A context set is a snapshot of a plan's context, saved on the server so it can be loaded into any plan in the project with 'plandex load --set <name>'. Sets store references rather than contents, so files, trees, urls, git refs, and commands are re-loaded from their sources. Notes and piped data are stored as-is. Saving over an existing set adds a new version, and 'plandex context history <name>' shows what changed.`,
+}
+
+var contextSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save the current plan's context as a named set",
+	Args:  cobra.ExactArgs(1),
+	Run:   saveContextSet,
+}
+
+var contextSetsCmd = &cobra.Command{
+	Use:   "sets",
+	Short: "List context sets for the current project",
+	Args:  cobra.NoArgs,
+	Run:   listContextSets,
+}
+
+var contextHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the versions of a context set",
+	Args:  cobra.ExactArgs(1),
+	Run:   contextSetHistory,
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a context set",
+	Args:    cobra.ExactArgs(1),
+	Run:     deleteContextSet,
+}
+
+func init() {
+	RootCmd.AddCommand(contextSetCmd)
+	contextSetCmd.AddCommand(contextSaveCmd)
+	contextSetCmd.AddCommand(contextSetsCmd)
+	contextSetCmd.AddCommand(contextHistoryCmd)
+	contextSetCmd.AddCommand(contextDeleteCmd)
+}
+
+func saveContextSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	name := args[0]
+	if !shared.IsValidContextSetName(name) {
+		term.OutputErrorAndExit("Invalid name '%s'. Use letters, numbers, '.', '-', and '_'.", name)
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.SaveContextSet(lib.CurrentPlanId, lib.CurrentBranch, shared.SaveContextSetRequest{Name: name})
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error saving context set: %v", apiErr.Msg)
+	}
+
+	if res.Changed {
+		fmt.Println("✅ " + res.Msg)
+	} else {
+		fmt.Println("🤷‍♂️ " + res.Msg)
+	}
+	fmt.Println()
+	term.PrintCmds("", "load --set", "context sets", "context history")
+}
+
+func listContextSets(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("")
+	sets, apiErr := api.Client.ListContextSets(lib.CurrentProjectId)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing context sets: %v", apiErr.Msg)
+	}
+
+	if len(sets) == 0 {
+		fmt.Println("🤷‍♂️ No context sets")
+		fmt.Println()
+		term.PrintCmds("", "context save")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Version", "Updated"})
+
+	for _, set := range sets {
+		table.Append([]string{
+			color.New(color.Bold, term.ColorHiCyan).Sprint(set.Name),
+			"v" + strconv.Itoa(set.Version),
+			set.UpdatedAt.Local().Format("Mon Jan 2, 2006 | 3:04pm"),
+		})
+	}
+
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "load --set", "context save", "context history", "context delete")
+}
+
+func contextSetHistory(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	term.StartSpinner("")
+	versions, apiErr := api.Client.ListContextSetVersions(lib.CurrentProjectId, args[0])
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting context set history: %v", apiErr.Msg)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(true)
+	table.SetHeader([]string{"Version", "Saved", "Pieces", "Changes"})
+
+	for _, version := range versions {
+		table.Append([]string{
+			"v" + strconv.Itoa(version.Version),
+			version.CreatedAt.Local().Format("Mon Jan 2, 2006 | 3:04pm"),
+			strconv.Itoa(version.NumRefs),
+			version.Summary,
+		})
+	}
+
+	table.Render()
+}
+
+func deleteContextSet(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	name := args[0]
+
+	term.StartSpinner("")
+	apiErr := api.Client.DeleteContextSet(lib.CurrentProjectId, name)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error deleting context set: %v", apiErr.Msg)
+	}
+
+	fmt.Printf("✅ Deleted context set %s\n", color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+}
@@ -6,7 +6,10 @@ import (
 	"plandex/lib"
 	"plandex/term"
 	"plandex/types"
+	"strconv"
+	"strings"
 
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
@@ -15,14 +18,54 @@ var (
 	namesOnly       bool
 	note            string
 	forceSkipIgnore bool
+	suggest         string
+	gitDiff         bool
+	gitDiffRef      string
+	gitCommit       string
+	gitChangedSince string
+	loadWatch       bool
+	execCommands    []string
+	loadPriority    string
+	loadPin         bool
+	loadSet         string
+	loadOutline     bool
+	loadRepoMap     bool
+	loadMapTokens   int
+	loadWithDeps    bool
+	loadDepsDepth   int
+	loadDepsTokens  int
+	loadCrawl       string
+	loadSamePrefix  bool
 )
 
 var contextLoadCmd = &cobra.Command{
-	Use:     "load [files-or-urls...]",
+	Use:     "load [files-or-urls-or-symbols...]",
 	Aliases: []string{"l", "add"},
 	Short:   "Load context from various inputs",
-	Long:    `Load context from a file path, a directory, a URL, a string, or piped data.`,
-	Run:     contextLoad,
+	Long: `Load context from a file path, a directory, a URL, a string, or piped data.
+
+Load a single function, method, or type with 'path:Symbol' or 'path#Symbol' (e.g. server.go:HandleRequest or server.go#Plan.Save), or a range of lines with 'path:start-end' (e.g. server.go:120-260).
+
+Load from the project's git repo with --git-diff (the working tree's diff against HEAD, or against another ref with --git-diff-ref <ref>), --commit <ref>, or --changed-since <ref> (every file changed since ref). 'plandex update' re-runs these, so if a branch or relative ref moves, the context follows it.
+
+Pass --exec with a command to load its output (e.g. --exec "go test ./..."). 'plandex update' re-runs the command to refresh the output. Commands time out after 2 minutes, and long output is truncated, keeping the end where failures usually are.
+
+Pass --outline to load only an outline of large files: declarations, signatures, and doc comments, without function bodies. Go files are parsed, and other files are outlined by indentation. If Plandex needs to change a file loaded as an outline, you'll be asked to load the full file first.
+
+Pass --with-deps to also load the local packages that loaded files import. Go imports are resolved with the module path in go.mod. You'll be asked whether to load them in full or as outlines. --deps-depth sets how many levels of imports to follow (1 by default), and --deps-tokens caps how many tokens they can add (20000 by default).
+
+URLs are converted to markdown that keeps headings, code blocks, and links, and cached so 'plandex update' only downloads pages that have changed. Pass --crawl depth=N to also load the pages a URL links to on the same site, following links N levels deep, and --same-prefix to only follow links under the URL's path, like the rest of a docs section. Crawls stop at 50 pages.
+
+Pass --map to load a repo map of a directory (the current directory by default): the exported functions, types, and methods in each source file, ranked by how often they're referenced and cut to fit a token budget (--map-tokens, 4000 by default). 'plandex update' rebuilds the map.
+
+Pass --priority low|normal|high to set which context is outlined or left out first if context doesn't fit in the planner's token budget, or --pin to never trim it. Change these later with 'plandex priority', 'plandex pin', and 'plandex unpin'.
+
+Pass --set with the name of a context set saved with 'plandex context save' to load it, re-reading its files, urls, and other sources.
+
+Pass --watch to keep watching loaded context after loading and update it as files change, like 'plandex watch'.
+
+Pass --suggest with a description of your task to rank project files by relevance and choose which ones to load.`,
+	Run: contextLoad,
 }
 
 func init() {
@@ -30,6 +73,24 @@ func init() {
 	contextLoadCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Search directories recursively")
 	contextLoadCmd.Flags().BoolVar(&namesOnly, "tree", false, "Load directory tree with file names only")
 	contextLoadCmd.Flags().BoolVarP(&forceSkipIgnore, "force", "f", false, "Load files even when ignored by .gitignore or .plandexignore")
+	contextLoadCmd.Flags().StringVarP(&suggest, "suggest", "s", "", "Suggest relevant files to load for a task")
+	contextLoadCmd.Flags().BoolVar(&gitDiff, "git-diff", false, "Load the diff of the working tree against HEAD")
+	contextLoadCmd.Flags().StringVar(&gitDiffRef, "git-diff-ref", "", "Load the diff of the working tree against a git ref")
+	contextLoadCmd.Flags().StringVar(&gitCommit, "commit", "", "Load a git commit")
+	contextLoadCmd.Flags().StringVar(&gitChangedSince, "changed-since", "", "Load every file changed since a git ref")
+	contextLoadCmd.Flags().StringArrayVarP(&execCommands, "exec", "e", nil, "Load the output of a command, re-run on update")
+	contextLoadCmd.Flags().BoolVar(&loadOutline, "outline", false, "Load only an outline of each file, without function bodies")
+	contextLoadCmd.Flags().BoolVar(&loadWithDeps, "with-deps", false, "Also load the local packages that files import")
+	contextLoadCmd.Flags().IntVar(&loadDepsDepth, "deps-depth", lib.DefaultDepsDepth, "Levels of imports to follow for --with-deps")
+	contextLoadCmd.Flags().IntVar(&loadDepsTokens, "deps-tokens", lib.DefaultDepsTokens, "Token budget for --with-deps")
+	contextLoadCmd.Flags().StringVar(&loadCrawl, "crawl", "", "Also load linked pages from URLs, following links to a depth (e.g. depth=2)")
+	contextLoadCmd.Flags().BoolVar(&loadSamePrefix, "same-prefix", false, "Only crawl links under the URL's path")
+	contextLoadCmd.Flags().BoolVar(&loadRepoMap, "map", false, "Load a map of the exported symbols in a directory")
+	contextLoadCmd.Flags().IntVar(&loadMapTokens, "map-tokens", lib.DefaultRepoMapTokens, "Token budget for --map")
+	contextLoadCmd.Flags().StringVar(&loadPriority, "priority", "normal", "Priority when trimming context to fit the token budget: low, normal, or high")
+	contextLoadCmd.Flags().BoolVar(&loadPin, "pin", false, "Never trim this context to fit the token budget")
+	contextLoadCmd.Flags().StringVar(&loadSet, "set", "", "Load a saved context set")
+	contextLoadCmd.Flags().BoolVarP(&loadWatch, "watch", "w", false, "Keep context in sync as files change after loading")
 	RootCmd.AddCommand(contextLoadCmd)
 }
 
@@ -42,12 +103,68 @@ func contextLoad(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	lib.MustLoadContext(args, &types.LoadContextParams{
+	priority, err := shared.ParseContextPriority(loadPriority)
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	var crawlDepth int
+	if loadCrawl != "" {
+		crawlDepth, err = strconv.Atoi(strings.TrimPrefix(loadCrawl, "depth="))
+		if err != nil || crawlDepth < 1 {
+			term.OutputErrorAndExit("Invalid --crawl value '%s', expected depth=N with N of at least 1", loadCrawl)
+		}
+	} else if loadSamePrefix {
+		term.OutputErrorAndExit("--same-prefix can only be used with --crawl")
+	}
+
+	params := &types.LoadContextParams{
 		Note:            note,
 		Recursive:       recursive,
 		NamesOnly:       namesOnly,
 		ForceSkipIgnore: forceSkipIgnore,
-	})
+		GitDiff:         gitDiffRef,
+		GitCommit:       gitCommit,
+		GitChangedSince: gitChangedSince,
+		ExecCommands:    execCommands,
+		Priority:        priority,
+		Pinned:          loadPin,
+		Outline:         loadOutline,
+		RepoMap:         loadRepoMap,
+		MapTokens:       loadMapTokens,
+		WithDeps:        loadWithDeps,
+		DepsDepth:       loadDepsDepth,
+		DepsTokens:      loadDepsTokens,
+		CrawlDepth:      crawlDepth,
+		SamePrefix:      loadSamePrefix,
+	}
+
+	if gitDiff && params.GitDiff == "" {
+		params.GitDiff = "HEAD"
+	}
+
+	if loadRepoMap && len(args) == 0 {
+		args = []string{"."}
+	}
+
+	hasInput := len(args) > 0 || note != "" || suggest != "" || params.GitDiff != "" || gitCommit != "" || gitChangedSince != "" || len(execCommands) > 0
+
+	if loadSet != "" {
+		if hasInput {
+			term.OutputErrorAndExit("--set can't be combined with other inputs")
+		}
+		lib.MustLoadContextSet(loadSet)
+	} else if suggest != "" {
+		lib.MustSuggestContext(suggest, params)
+	} else if hasInput || !loadWatch {
+		lib.MustLoadContext(args, params)
+	}
+
+	if loadWatch {
+		fmt.Println()
+		lib.MustWatchContext(lib.DefaultWatchDebounce)
+		return
+	}
 
 	fmt.Println()
 	term.PrintCmds("", "ls", "tell")
@@ -12,6 +12,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
 	"github.com/spf13/cobra"
 )
 
@@ -36,7 +37,7 @@ func listContext(cmd *cobra.Command, args []string) {
 
 	totalTokens := 0
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"#", "Name", "Type", "🪙", "Added", "Updated"})
+	table.SetHeader([]string{"#", "Name", "Type", "🪙", "Priority", "Last Reply", "Added", "Updated"})
 	table.SetAutoWrapText(false)
 
 	if len(contexts) == 0 {
@@ -51,11 +52,18 @@ func listContext(cmd *cobra.Command, args []string) {
 
 		t, icon := lib.GetContextLabelAndIcon(context.ContextType)
 
+		priority := context.Priority.String()
+		if context.Pinned {
+			priority += " 📌"
+		}
+
 		row := []string{
 			strconv.Itoa(i + 1),
 			" " + icon + " " + context.Name,
 			t,
 			strconv.Itoa(context.NumTokens), //+ " 🪙",
+			priority,
+			lastReplyStatusLabel(context.LastReplyStatus),
 			format.Time(context.CreatedAt),
 			format.Time(context.UpdatedAt),
 		}
@@ -78,6 +86,20 @@ func listContext(cmd *cobra.Command, args []string) {
 
 }
 
+// whether context was sent to the planner in full in the last reply, or trimmed to fit the token budget
+func lastReplyStatusLabel(status shared.ContextBudgetStatus) string {
+	switch status {
+	case shared.ContextBudgetIncluded:
+		return "✅ included"
+	case shared.ContextBudgetOutlined:
+		return "✂️  outlined"
+	case shared.ContextBudgetDropped:
+		return "🚫 left out"
+	}
+	// loaded since the last reply
+	return "-"
+}
+
 func init() {
 	RootCmd.AddCommand(contextCmd)
 
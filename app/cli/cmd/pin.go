@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var pinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "Pin context so it's never trimmed to fit the token budget",
+	Long:  `Pin context by index, name, or glob. When context doesn't fit in the planner's token budget, unpinned context is outlined or left out, lowest priority first. Pinned context is always sent in full.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pinned := true
+		setContextPriority(args, shared.SetContextPriorityRequest{Pinned: &pinned})
+	},
+}
+
+var unpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "Unpin context",
+	Long:  `Unpin context by index, name, or glob.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pinned := false
+		setContextPriority(args, shared.SetContextPriorityRequest{Pinned: &pinned})
+	},
+}
+
+var priorityCmd = &cobra.Command{
+	Use:   "priority <low|normal|high> [context...]",
+	Short: "Set the priority of context",
+	Long:  `Set the priority of context by index, name, or glob. When context doesn't fit in the planner's token budget, low priority context is outlined or left out first, then normal, then high.`,
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		priority, err := shared.ParseContextPriority(args[0])
+		if err != nil {
+			term.OutputErrorAndExit("%v", err)
+		}
+		setContextPriority(args[1:], shared.SetContextPriorityRequest{Priority: &priority})
+	},
+}
+
+func setContextPriority(args []string, req shared.SetContextPriorityRequest) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	term.StartSpinner("")
+	contexts, err := api.Client.ListContext(lib.CurrentPlanId, lib.CurrentBranch)
+
+	if err != nil {
+		term.OutputErrorAndExit("Error retrieving context: %v", err)
+	}
+
+	ids, matchErr := lib.MatchContextIds(contexts, args)
+
+	if matchErr != nil {
+		term.OutputErrorAndExit("Error matching context: %v", matchErr)
+	}
+
+	if len(ids) == 0 {
+		term.StopSpinner()
+		fmt.Println("🤷‍♂️ No matching context")
+		return
+	}
+
+	req.Ids = ids
+	res, err := api.Client.SetContextPriority(lib.CurrentPlanId, lib.CurrentBranch, req)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error updating context: %v", err)
+	}
+
+	fmt.Println("✅ " + res.Msg)
+	fmt.Println()
+	term.PrintCmds("", "ls")
+}
+
+func init() {
+	RootCmd.AddCommand(pinCmd)
+	RootCmd.AddCommand(unpinCmd)
+	RootCmd.AddCommand(priorityCmd)
+}
@@ -34,7 +34,7 @@ func ps(cmd *cobra.Command, args []string) {
 	}
 
 	term.StartSpinner("")
-	res, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, true)
+	res, apiErr := api.Client.ListPlansRunning([]string{lib.CurrentProjectId}, true, false)
 	term.StopSpinner()
 
 	if apiErr != nil {
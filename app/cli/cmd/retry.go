@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/plan_exec"
+	"plandex/term"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var retryName string
+var retryModel string
+var retryTemperature float64
+
+var retryCmd = &cobra.Command{
+	Use:   "retry",
+	Short: "Retry the last prompt on a new branch",
+	Long: `Retry the last prompt on a new branch.
+
+A new branch is forked from the current branch at the state just before the last prompt, then the prompt is sent again. The current branch is left as-is, so you can compare the two replies and choose one with 'plandex checkout'.
+
+Pass --model and/or --temperature to retry with different planner settings on the new branch.`,
+	Args: cobra.NoArgs,
+	Run:  retry,
+}
+
+func init() {
+	RootCmd.AddCommand(retryCmd)
+
+	retryCmd.Flags().StringVar(&retryName, "name", "", "Name for the new branch")
+	retryCmd.Flags().StringVarP(&retryModel, "model", "m", "", "Planner model to use on the new branch")
+	retryCmd.Flags().Float64VarP(&retryTemperature, "temperature", "t", -1, "Planner temperature to use on the new branch (0.0 to 2.0)")
+	retryCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
+	retryCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
+	retryCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+}
+
+func retry(cmd *cobra.Command, args []string) {
+	if os.Getenv("OPENAI_API_KEY") == "" {
+		term.OutputNoApiKeyMsgAndExit()
+	}
+
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	var selectedModel *shared.BaseModelConfig
+	if retryModel != "" {
+		for i, m := range shared.AvailableModels {
+			if shared.Compact(m.ModelName) == shared.Compact(retryModel) {
+				selectedModel = &shared.AvailableModels[i]
+				break
+			}
+		}
+
+		if selectedModel == nil {
+			term.OutputErrorAndExit("Model %s not found", retryModel)
+		}
+	}
+
+	if cmd.Flags().Changed("temperature") && (retryTemperature < 0 || retryTemperature > 2) {
+		term.OutputErrorAndExit("Invalid value for temperature: %v", retryTemperature)
+	}
+
+	term.StartSpinner("")
+	res, apiErr := api.Client.RetryPlan(lib.CurrentPlanId, lib.CurrentBranch, shared.RetryPlanRequest{Name: retryName})
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error retrying plan: %v", apiErr)
+	}
+
+	// the new branch isn't switched to until its settings are in place, so it's deleted rather than left behind if they can't be
+	onSettingsErr := func(msg string, args ...interface{}) {
+		term.StopSpinner()
+		if apiErr := api.Client.DeleteBranch(lib.CurrentPlanId, res.Branch); apiErr != nil {
+			term.OutputSimpleError("Failed to delete branch %s: %v", res.Branch, apiErr.Msg)
+		}
+		term.OutputErrorAndExit(msg, args...)
+	}
+
+	if selectedModel != nil || cmd.Flags().Changed("temperature") {
+		settings, apiErr := api.Client.GetSettings(lib.CurrentPlanId, res.Branch)
+		if apiErr != nil {
+			onSettingsErr("Error getting settings: %v", apiErr.Msg)
+		}
+
+		if settings.ModelSet == nil {
+			// copy so the shared default isn't modified
+			bytes, err := json.Marshal(shared.DefaultModelSet)
+			if err != nil {
+				onSettingsErr("Error marshalling model set: %v", err)
+			}
+			var modelSet shared.ModelSet
+			err = json.Unmarshal(bytes, &modelSet)
+			if err != nil {
+				onSettingsErr("Error unmarshalling model set: %v", err)
+			}
+			settings.ModelSet = &modelSet
+		}
+
+		if selectedModel != nil {
+			settings.ModelSet.Planner.BaseModelConfig = *selectedModel
+			settings.ModelSet.Planner.PlannerModelConfig = shared.PlannerModelConfigByName[selectedModel.ModelName]
+		}
+
+		if cmd.Flags().Changed("temperature") {
+			settings.ModelSet.Planner.Temperature = float32(retryTemperature)
+		}
+
+		_, apiErr = api.Client.UpdateSettings(lib.CurrentPlanId, res.Branch, shared.UpdateSettingsRequest{Settings: settings})
+		if apiErr != nil {
+			onSettingsErr("Error updating settings: %v", apiErr.Msg)
+		}
+	}
+
+	term.StopSpinner()
+
+	err := lib.WriteCurrentBranch(res.Branch)
+	if err != nil {
+		term.OutputErrorAndExit("Error setting current branch: %v", err)
+	}
+
+	fmt.Printf("🔁 Retrying on new branch %s (forked from %s at %s)\n",
+		color.New(color.Bold, term.ColorHiGreen).Sprint(res.Branch),
+		color.New(color.Bold, term.ColorHiCyan).Sprint(lib.CurrentBranch),
+		res.Sha,
+	)
+	fmt.Println()
+
+	plan_exec.TellPlan(plan_exec.ExecParams{
+		CurrentPlanId: lib.CurrentPlanId,
+		CurrentBranch: res.Branch,
+		CheckOutdatedContext: func(maybeContexts []*shared.Context) (bool, bool) {
+			return lib.MustCheckOutdatedContext(false, maybeContexts)
+		},
+	}, res.Prompt, tellBg, tellStop, tellNoBuild, false)
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"path/filepath"
 	"plandex/api"
 	"plandex/auth"
 	"plandex/lib"
@@ -37,36 +36,10 @@ func contextRm(cmd *cobra.Command, args []string) {
 		term.OutputErrorAndExit("Error retrieving context: %v", err)
 	}
 
-	deleteIds := map[string]bool{}
+	deleteIds, matchErr := lib.MatchContextIds(contexts, args)
 
-	for i, context := range contexts {
-		for _, id := range args {
-			if fmt.Sprintf("%d", i+1) == id || context.Name == id || context.FilePath == id || context.Url == id {
-				deleteIds[context.Id] = true
-				break
-			} else if context.FilePath != "" {
-				// Check if id is a glob pattern
-				matched, err := filepath.Match(id, context.FilePath)
-				if err != nil {
-					term.OutputErrorAndExit("Error matching glob pattern: %v", err)
-				}
-				if matched {
-					deleteIds[context.Id] = true
-					break
-				}
-
-				// Check if id is a parent directory
-				parentDir := context.FilePath
-				for parentDir != "." && parentDir != "/" && parentDir != "" {
-					if parentDir == id {
-						deleteIds[context.Id] = true
-						break
-					}
-					parentDir = filepath.Dir(parentDir) // Move up one directory
-				}
-
-			}
-		}
+	if matchErr != nil {
+		term.OutputErrorAndExit("Error matching context: %v", matchErr)
 	}
 
 	if len(deleteIds) > 0 {
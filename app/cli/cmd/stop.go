@@ -31,7 +31,7 @@ func stop(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	planId, branch, shouldContinue := lib.SelectActiveStream(args)
+	planId, branch, shouldContinue := lib.SelectActiveStream(args, false)
 
 	if !shouldContinue {
 		return
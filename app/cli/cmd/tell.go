@@ -22,6 +22,8 @@ var tellPromptFile string
 var tellBg bool
 var tellStop bool
 var tellNoBuild bool
+var tellTemplate string
+var tellVars []string
 
 // tellCmd represents the prompt command
 var tellCmd = &cobra.Command{
@@ -40,6 +42,8 @@ func init() {
 	tellCmd.Flags().BoolVarP(&tellStop, "stop", "s", false, "Stop after a single reply")
 	tellCmd.Flags().BoolVarP(&tellNoBuild, "no-build", "n", false, "Don't build files")
 	tellCmd.Flags().BoolVar(&tellBg, "bg", false, "Execute autonomously in the background")
+	tellCmd.Flags().StringVar(&tellTemplate, "template", "", "Prompt template to fill in and send")
+	tellCmd.Flags().StringArrayVar(&tellVars, "var", nil, "Template variable as key=value (can be repeated)")
 }
 
 func doTell(cmd *cobra.Command, args []string) {
@@ -57,7 +61,14 @@ func doTell(cmd *cobra.Command, args []string) {
 
 	var prompt string
 
-	if len(args) > 0 {
+	if tellTemplate != "" {
+		prompt = lib.MustExpandPromptTemplate(tellTemplate, tellVars)
+
+		// any prompt passed along with a template is added after it
+		if len(args) > 0 {
+			prompt += "\n\n" + args[0]
+		}
+	} else if len(args) > 0 {
 		prompt = args[0]
 	} else if tellPromptFile != "" {
 		bytes, err := os.ReadFile(tellPromptFile)
@@ -101,6 +112,17 @@ func getEditorInstructions(editor string) string {
 }
 
 func getEditorPrompt() string {
+	instructions := getEditorInstructions(os.Getenv("EDITOR"))
+
+	prompt := getEditorInput(instructions)
+	prompt = strings.TrimPrefix(prompt, strings.TrimSpace(instructions))
+	prompt = strings.TrimSpace(prompt)
+
+	return prompt
+}
+
+// getEditorInput opens the user's editor on a temp file containing initial and returns the saved content.
+func getEditorInput(initial string) string {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = os.Getenv("VISUAL")
@@ -114,9 +136,8 @@ func getEditorPrompt() string {
 		term.OutputErrorAndExit("Failed to create temporary file: %v", err)
 	}
 
-	instructions := getEditorInstructions(editor)
 	filename := tempFile.Name()
-	err = os.WriteFile(filename, []byte(instructions), 0644)
+	err = os.WriteFile(filename, []byte(initial), 0644)
 	if err != nil {
 		term.OutputErrorAndExit("Failed to write instructions to temporary file: %v", err)
 	}
@@ -135,16 +156,10 @@ func getEditorPrompt() string {
 		term.OutputErrorAndExit("Error reading temporary file: %v", err)
 	}
 
-	prompt := string(bytes)
-
 	err = os.Remove(tempFile.Name())
 	if err != nil {
 		term.OutputErrorAndExit("Error removing temporary file: %v", err)
 	}
 
-	prompt = strings.TrimPrefix(prompt, strings.TrimSpace(instructions))
-	prompt = strings.TrimSpace(prompt)
-
-	return prompt
-
+	return strings.TrimSpace(string(bytes))
 }
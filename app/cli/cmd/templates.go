@@ -0,0 +1,341 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/lib"
+	"plandex/term"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+	"github.com/spf13/cobra"
+)
+
+var templateOrg bool
+var templateDesc string
+var templateFile string
+
+var templatesCmd = &cobra.Command{
+	Use:     "templates",
+	Aliases: []string{"tpl"},
+	Short:   "List prompt templates",
+	Long: `List prompt templates for the current project and org.
+
+Templates are prompts with {{variables}} that can be filled in with 'plandex tell --template <name> --var key=value'. Project templates are stored in the project's .plandex directory; org templates are stored on the server and shared with everyone in the org. If a project and org template have the same name, the project template is used.`,
+	Args: cobra.NoArgs,
+	Run:  listTemplates,
+}
+
+var templatesCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a prompt template",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   createTemplate,
+}
+
+var templatesEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a prompt template",
+	Args:  cobra.ExactArgs(1),
+	Run:   editTemplate,
+}
+
+var templatesDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a prompt template",
+	Args:    cobra.ExactArgs(1),
+	Run:     deleteTemplate,
+}
+
+func init() {
+	RootCmd.AddCommand(templatesCmd)
+	templatesCmd.AddCommand(templatesCreateCmd)
+	templatesCmd.AddCommand(templatesEditCmd)
+	templatesCmd.AddCommand(templatesDeleteCmd)
+
+	templatesCreateCmd.Flags().BoolVar(&templateOrg, "org", false, "Share the template with your org")
+	templatesCreateCmd.Flags().StringVarP(&templateDesc, "description", "d", "", "Short description of the template")
+	templatesCreateCmd.Flags().StringVarP(&templateFile, "file", "f", "", "File containing the template body")
+
+	templatesEditCmd.Flags().BoolVar(&templateOrg, "org", false, "Edit the org template rather than the project template")
+	templatesEditCmd.Flags().StringVarP(&templateDesc, "description", "d", "", "New description for the template")
+	templatesEditCmd.Flags().StringVarP(&templateFile, "file", "f", "", "File containing the new template body")
+
+	templatesDeleteCmd.Flags().BoolVar(&templateOrg, "org", false, "Delete the org template rather than the project template")
+}
+
+func listTemplates(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MaybeResolveProject()
+
+	term.StartSpinner("")
+	projectTemplates, err := lib.LoadProjectPromptTemplates()
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error loading project templates: %v", err)
+	}
+
+	orgTemplates, apiErr := api.Client.ListPromptTemplates()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing org templates: %v", apiErr.Msg)
+	}
+
+	if len(projectTemplates) == 0 && len(orgTemplates) == 0 {
+		fmt.Println("🤷‍♂️ No prompt templates")
+		fmt.Println()
+		term.PrintCmds("", "templates create")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Name", "Scope", "Variables", "Description"})
+
+	for _, t := range projectTemplates {
+		table.Append([]string{t.Name, "project", strings.Join(shared.PromptTemplateVars(t.Body), ", "), t.Description})
+	}
+
+	for _, t := range orgTemplates {
+		table.Append([]string{t.Name, "org", strings.Join(shared.PromptTemplateVars(t.Body), ", "), t.Description})
+	}
+
+	table.Render()
+
+	fmt.Println()
+	term.PrintCmds("", "tell --template", "templates create", "templates edit", "templates delete")
+}
+
+func createTemplate(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	if !templateOrg {
+		lib.MustResolveProject()
+	}
+
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		var err error
+		name, err = term.GetUserStringInput("Template name:")
+		if err != nil {
+			term.OutputErrorAndExit("Error getting template name: %v", err)
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	if !shared.IsValidPromptTemplateName(name) {
+		term.OutputErrorAndExit("Invalid template name %q. Use letters, numbers, dashes and underscores.", name)
+	}
+
+	body := getTemplateBody("")
+	if body == "" {
+		fmt.Println("🤷‍♂️ Template is empty")
+		return
+	}
+
+	if templateOrg {
+		term.StartSpinner("")
+		_, apiErr := api.Client.CreatePromptTemplate(shared.CreatePromptTemplateRequest{
+			Name:        name,
+			Description: templateDesc,
+			Body:        body,
+		})
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error creating template: %v", apiErr.Msg)
+		}
+	} else {
+		templates, err := lib.LoadProjectPromptTemplates()
+		if err != nil {
+			term.OutputErrorAndExit("Error loading project templates: %v", err)
+		}
+
+		for _, t := range templates {
+			if t.Name == name {
+				term.OutputErrorAndExit("Template %s already exists. Use 'plandex templates edit %s' to change it.", name, name)
+			}
+		}
+
+		now := time.Now()
+		templates = append(templates, &shared.PromptTemplate{
+			Name:        name,
+			Description: templateDesc,
+			Body:        body,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+
+		err = lib.WriteProjectPromptTemplates(templates)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving template: %v", err)
+		}
+	}
+
+	fmt.Printf("✅ Created %s template %s\n", templateScope(templateOrg), color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+	printTemplateVars(body)
+}
+
+func editTemplate(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MaybeResolveProject()
+
+	name := args[0]
+
+	template, isOrg := mustFindTemplate(name)
+
+	body := getTemplateBody(template.Body)
+	if body == "" {
+		fmt.Println("🤷‍♂️ Template is empty, not saving")
+		return
+	}
+
+	desc := template.Description
+	if cmd.Flags().Changed("description") {
+		desc = templateDesc
+	}
+
+	if isOrg {
+		term.StartSpinner("")
+		apiErr := api.Client.UpdatePromptTemplate(template.Id, shared.UpdatePromptTemplateRequest{
+			Description: desc,
+			Body:        body,
+		})
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error updating template: %v", apiErr.Msg)
+		}
+	} else {
+		templates, err := lib.LoadProjectPromptTemplates()
+		if err != nil {
+			term.OutputErrorAndExit("Error loading project templates: %v", err)
+		}
+
+		for _, t := range templates {
+			if t.Name == name {
+				t.Description = desc
+				t.Body = body
+				t.UpdatedAt = time.Now()
+			}
+		}
+
+		err = lib.WriteProjectPromptTemplates(templates)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving template: %v", err)
+		}
+	}
+
+	fmt.Printf("✅ Updated %s template %s\n", templateScope(isOrg), color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+	printTemplateVars(body)
+}
+
+func deleteTemplate(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MaybeResolveProject()
+
+	name := args[0]
+
+	template, isOrg := mustFindTemplate(name)
+
+	if isOrg {
+		term.StartSpinner("")
+		apiErr := api.Client.DeletePromptTemplate(template.Id)
+		term.StopSpinner()
+
+		if apiErr != nil {
+			term.OutputErrorAndExit("Error deleting template: %v", apiErr.Msg)
+		}
+	} else {
+		templates, err := lib.LoadProjectPromptTemplates()
+		if err != nil {
+			term.OutputErrorAndExit("Error loading project templates: %v", err)
+		}
+
+		var remaining []*shared.PromptTemplate
+		for _, t := range templates {
+			if t.Name != name {
+				remaining = append(remaining, t)
+			}
+		}
+
+		err = lib.WriteProjectPromptTemplates(remaining)
+		if err != nil {
+			term.OutputErrorAndExit("Error saving templates: %v", err)
+		}
+	}
+
+	fmt.Printf("✅ Deleted %s template %s\n", templateScope(isOrg), color.New(color.Bold, term.ColorHiCyan).Sprint(name))
+}
+
+// mustFindTemplate finds a template by name, skipping project templates when --org is set
+func mustFindTemplate(name string) (*shared.PromptTemplate, bool) {
+	if !templateOrg {
+		term.StartSpinner("")
+		template, isOrg, err := lib.ResolvePromptTemplate(name)
+		term.StopSpinner()
+
+		if err != nil {
+			term.OutputErrorAndExit("Error loading templates: %v", err)
+		}
+
+		if template == nil {
+			term.OutputErrorAndExit("Template %s not found", name)
+		}
+
+		return template, isOrg
+	}
+
+	term.StartSpinner("")
+	orgTemplates, apiErr := api.Client.ListPromptTemplates()
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error listing org templates: %v", apiErr.Msg)
+	}
+
+	for _, t := range orgTemplates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+
+	term.OutputErrorAndExit("Org template %s not found", name)
+	return nil, false
+}
+
+func getTemplateBody(current string) string {
+	if templateFile != "" {
+		bytes, err := os.ReadFile(templateFile)
+		if err != nil {
+			term.OutputErrorAndExit("Error reading template file: %v", err)
+		}
+		return strings.TrimSpace(string(bytes))
+	}
+
+	return getEditorInput(current)
+}
+
+func templateScope(isOrg bool) string {
+	if isOrg {
+		return "org"
+	}
+	return "project"
+}
+
+func printTemplateVars(body string) {
+	vars := shared.PromptTemplateVars(body)
+	if len(vars) == 0 {
+		return
+	}
+
+	fmt.Println("Variables: " + strings.Join(vars, ", "))
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"plandex/auth"
+	"plandex/lib"
+
+	"github.com/spf13/cobra"
+)
+
+var unapplyAutoConfirm bool
+
+func init() {
+	unapplyCmd.Flags().BoolVarP(&unapplyAutoConfirm, "yes", "y", false, "Automatically confirm unless files were changed after the apply")
+
+	RootCmd.AddCommand(unapplyCmd)
+}
+
+var unapplyCmd = &cobra.Command{
+	Use:   "unapply",
+	Short: "Undo the last apply",
+	Long: `Undo the most recent apply.
+
+Files the apply updated are restored to how they were before it, and files it created are removed. The applied changes are marked pending again in the plan, so they can be reviewed and applied later. Snapshots are kept for the last 10 applies, so running unapply again undoes the one before.
+
+Commits made when applying aren't reverted. Applies made with --branch to a branch other than the current one aren't snapshotted, since they can be undone with git.`,
+	Args: cobra.NoArgs,
+	Run:  unapply,
+}
+
+func unapply(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	lib.MustUnapplyPlan(unapplyAutoConfirm)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"plandex/auth"
+	"plandex/lib"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var watchDebounce time.Duration
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Keep context in sync as files change",
+	Long: `Watch the files behind the current plan's context and update it on the server as they change, so the planner doesn't work from stale files while you edit alongside it.
+
+Changes are debounced so a burst of saves results in a single update. Directory trees and git contexts are refreshed when any file that isn't ignored by .gitignore or .plandexignore changes. Runs until interrupted with ctrl+c.`,
+	Args: cobra.NoArgs,
+	Run:  watch,
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", lib.DefaultWatchDebounce, "How long to wait after a change before updating")
+}
+
+func watch(cmd *cobra.Command, args []string) {
+	auth.MustResolveAuthWithOrg()
+	lib.MustResolveProject()
+
+	if lib.CurrentPlanId == "" {
+		fmt.Println("🤷‍♂️ No current plan")
+		return
+	}
+
+	lib.MustWatchContext(watchDebounce)
+}
@@ -0,0 +1,33 @@
+package deps
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Resolver finds the local files that a source file imports. Paths are returned relative to the current directory, like the paths passed to 'plandex load'.
+type Resolver interface {
+	Resolve(path string, src []byte) ([]string, error)
+}
+
+var resolversByExt = map[string]Resolver{}
+
+// Register adds a resolver for files with the given extension (e.g. ".go"), replacing any existing one
+func Register(ext string, resolver Resolver) {
+	resolversByExt[strings.ToLower(ext)] = resolver
+}
+
+func HasResolver(path string) bool {
+	_, ok := resolversByExt[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func Resolve(path string, src []byte) ([]string, error) {
+	resolver, ok := resolversByExt[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("dependencies aren't supported for %s files", filepath.Ext(path))
+	}
+
+	return resolver.Resolve(path, src)
+}
@@ -0,0 +1,125 @@
+package deps
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type goResolver struct {
+	mu sync.Mutex
+	// module root and path by directory, so go.mod is only looked up once per directory
+	modulesByDir map[string][2]string
+}
+
+func init() {
+	Register(".go", &goResolver{modulesByDir: map[string][2]string{}})
+}
+
+var goModuleRegex = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+// Resolve returns the non-test files of each package imported from the file's own module, skipping files excluded by build constraints
+func (r *goResolver) Resolve(path string, src []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing go file: %v", err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path: %v", err)
+	}
+
+	modRoot, modPath, err := r.findModule(filepath.Dir(absPath))
+	if err != nil {
+		return nil, err
+	}
+	if modRoot == "" {
+		return nil, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting working directory: %v", err)
+	}
+
+	var res []string
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if importPath != modPath && !strings.HasPrefix(importPath, modPath+"/") {
+			continue
+		}
+
+		dir := filepath.Join(modRoot, filepath.FromSlash(strings.TrimPrefix(importPath, modPath)))
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			// imports that don't resolve, like ones being written, are left for the compiler to report
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+				continue
+			}
+
+			if match, err := build.Default.MatchFile(dir, name); err != nil || !match {
+				continue
+			}
+
+			rel, err := filepath.Rel(cwd, filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("error getting relative path: %v", err)
+			}
+			res = append(res, rel)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res, nil
+}
+
+// findModule returns the root dir and module path of the go.mod that dir belongs to, or empty strings if it isn't in a module
+func (r *goResolver) findModule(dir string) (string, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if mod, ok := r.modulesByDir[dir]; ok {
+		return mod[0], mod[1], nil
+	}
+
+	var mod [2]string
+	for d := dir; ; d = filepath.Dir(d) {
+		bytes, err := os.ReadFile(filepath.Join(d, "go.mod"))
+		if err == nil {
+			match := goModuleRegex.FindSubmatch(bytes)
+			if match == nil {
+				return "", "", fmt.Errorf("no module path in %s", filepath.Join(d, "go.mod"))
+			}
+			mod = [2]string{d, string(match[1])}
+			break
+		} else if !os.IsNotExist(err) {
+			return "", "", fmt.Errorf("error reading go.mod: %v", err)
+		}
+
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	r.modulesByDir[dir] = mod
+	return mod[0], mod[1], nil
+}
@@ -0,0 +1,115 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTempModule writes files to a temp dir and runs the test from it, since resolved paths are relative to the working directory
+func useTempModule(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for path, content := range files {
+		dstPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dstPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+var testModuleFiles = map[string]string{
+	"go.mod": "module example.com/app\n\ngo 1.21\n",
+	"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/app/internal/store"
+	"example.com/application"
+	"github.com/pkg/errors"
+)
+`,
+	"internal/store/store.go":      "package store\n\nimport \"example.com/app/internal/db\"\n",
+	"internal/store/cache.go":      "package store\n",
+	"internal/store/store_test.go": "package store\n",
+	"internal/store/tagged.go":     "//go:build sometag\n\npackage store\n",
+	"internal/store/README.md":     "# store\n",
+	"internal/db/db.go":            "package db\n",
+	// where example.com/application would resolve if matching the module path didn't stop at a path separator
+	"lication/app.go": "package application\n",
+}
+
+func TestGoResolver(t *testing.T) {
+	useTempModule(t, testModuleFiles)
+
+	resolver := &goResolver{modulesByDir: map[string][2]string{}}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "main.go", want: []string{"internal/store/cache.go", "internal/store/store.go"}},
+		{path: "internal/store/store.go", want: []string{"internal/db/db.go"}},
+		{path: "internal/db/db.go", want: nil},
+	}
+
+	for _, tt := range tests {
+		src, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := resolver.Resolve(tt.path, src)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGoResolverOutsideModule(t *testing.T) {
+	useTempModule(t, map[string]string{
+		"main.go": "package main\n\nimport \"example.com/app/store\"\n",
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &goResolver{modulesByDir: map[string][2]string{}}
+
+	root, modPath, err := resolver.findModule(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != "" || modPath != "" {
+		// a go.mod above the temp dir would make this meaningless
+		t.Skipf("temp dir is inside the module %s", modPath)
+	}
+
+	got, err := resolver.Resolve("main.go", []byte(testModuleFiles["main.go"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got %v, want no dependencies outside a module", got)
+	}
+}
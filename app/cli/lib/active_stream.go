@@ -8,9 +8,10 @@ import (
 	"github.com/plandex/plandex/shared"
 )
 
-func SelectActiveStream(args []string) (string, string, bool) {
+// includeShared also lists other users' plans that are shared with the org (for watching)
+func SelectActiveStream(args []string, includeShared bool) (string, string, bool) {
 	term.StartSpinner("")
-	res, apiErr := api.Client.ListPlansRunning([]string{CurrentProjectId}, false)
+	res, apiErr := api.Client.ListPlansRunning([]string{CurrentProjectId}, false, includeShared)
 	term.StopSpinner()
 
 	if apiErr != nil {
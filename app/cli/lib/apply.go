@@ -6,11 +6,21 @@ import (
 	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
+	"plandex/merge"
 	"plandex/term"
+	"plandex/types"
+	"sort"
 	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
 )
 
-func MustApplyPlan(planId, branch string, autoConfirm bool) {
+// MustApplyPlan writes the plan's pending changes to the project. If params.FilePaths is non-empty, only the changes to those files are applied, and the rest stay pending in the plan. If params.GitBranch is set, the changes are written and committed on that git branch, and then the original branch and any uncommitted changes are restored.
+func MustApplyPlan(planId, branch string, params *types.ApplyPlanParams) {
+	autoConfirm := params.AutoConfirm
+	filePaths := params.FilePaths
+
 	term.StartSpinner("")
 
 	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
@@ -21,7 +31,7 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 	}
 
 	if currentPlanState.HasPendingBuilds() {
-		plansRunningRes, apiErr := api.Client.ListPlansRunning([]string{CurrentProjectId}, false)
+		plansRunningRes, apiErr := api.Client.ListPlansRunning([]string{CurrentProjectId}, false, false)
 
 		if apiErr != nil {
 			term.StopSpinner()
@@ -60,7 +70,37 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		}
 	}
 
-	anyOutdated, didUpdate := MustCheckOutdatedContext(true, nil)
+	currentPlanFiles := currentPlanState.CurrentPlanFiles
+	isRepo := fs.ProjectRootIsGitRepo()
+
+	if params.GitBranch != "" && !isRepo {
+		term.StopSpinner()
+		term.OutputErrorAndExit("--branch can only be used in a git repo")
+	}
+
+	toApply := currentPlanFiles.Files
+
+	if len(filePaths) > 0 {
+		selected := map[string]string{}
+		for _, path := range filePaths {
+			content, ok := toApply[path]
+			if !ok {
+				term.StopSpinner()
+				term.OutputErrorAndExit("%s has no pending changes", path)
+			}
+			selected[path] = content
+		}
+		toApply = selected
+	}
+
+	contexts, apiErr := api.Client.ListContext(planId, branch)
+
+	if apiErr != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Error getting context: %v", apiErr)
+	}
+
+	anyOutdated, didUpdate := MustCheckOutdatedContext(true, contextsToCheckBeforeApply(contexts, toApply))
 
 	if anyOutdated && !didUpdate {
 		term.StopSpinner()
@@ -68,11 +108,6 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		os.Exit(0)
 	}
 
-	currentPlanFiles := currentPlanState.CurrentPlanFiles
-	isRepo := fs.ProjectRootIsGitRepo()
-
-	toApply := currentPlanFiles.Files
-
 	if len(toApply) == 0 {
 		term.StopSpinner()
 		fmt.Println("🤷‍♂️ No changes to apply")
@@ -98,24 +133,46 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 		term.ResumeSpinner()
 	}
 
+	var gitBranch *applyGitBranch
+
 	onErr := func(errMsg string, errArgs ...interface{}) {
 		term.StopSpinner()
+		if gitBranch != nil {
+			if err := gitBranch.restore(); err != nil {
+				term.OutputSimpleError("Failed to restore your branch:", err.Error())
+			}
+		}
 		term.OutputErrorAndExit(errMsg, errArgs...)
 	}
 
+	if params.GitBranch != "" {
+		var err error
+		var applyPaths []string
+		for path := range toApply {
+			applyPaths = append(applyPaths, path)
+		}
+		sort.Strings(applyPaths)
+
+		gitBranch, err = checkoutApplyBranch(params.GitBranch, applyPaths)
+		if err != nil {
+			onErr("failed to check out branch %s: %v", params.GitBranch, err)
+		}
+	}
+
 	onGitErr := func(errMsg, unformattedErrMsg string) {
 		term.StopSpinner()
 		term.OutputSimpleError(errMsg, unformattedErrMsg)
 	}
 
-	apiErr = api.Client.ApplyPlan(planId, branch)
-
-	if apiErr != nil {
-		onErr("failed to set pending results applied: %s", apiErr.Msg)
-		return
-	}
-
 	var updatedFiles []string
+	var mergedFiles []string
+	var conflictedFiles []string
+	// conflicted files where the local version was kept
+	var keptPaths []string
+	contentsByPath := map[string]string{}
+	// what each file was before the apply, for the unapply snapshot. nil if it didn't exist
+	priorByPath := map[string]*string{}
+
 	for path, content := range toApply {
 		// Compute destination path
 		dstPath := filepath.Join(fs.ProjectRoot, path)
@@ -145,6 +202,45 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 				return
 			}
 
+			prior := string(bytes)
+			priorByPath[path] = &prior
+
+			context := currentPlanState.ContextsByPath[path]
+			shouldMerge := context != nil
+
+			if context != nil && context.IsPartialFile() {
+				// only part of the file is in context, so apply the changes to the file as it is now to keep any edits made outside that part
+				current := strings.ReplaceAll(string(bytes), "```", "\\`\\`\\`")
+				if updated, ok := currentPlanState.ApplyPendingToFile(path, current); ok {
+					content = strings.ReplaceAll(updated, "\\`\\`\\`", "```")
+					shouldMerge = false
+				}
+				// if the changes no longer apply to the file as it is now, the plan's version was built from the whole file as loaded, so it's merged below like a whole file rather than written over edits outside the loaded part
+			}
+
+			if shouldMerge {
+				// the file was edited since it was loaded, so merge those edits with the plan's changes, using the file as it was loaded as the common base. For partial contexts, the body here is the whole file.
+				base := strings.ReplaceAll(context.Body, "\\`\\`\\`", "```")
+				if context.Redacted {
+					// the file on disk has the real secrets, so they're put back in the base and the plan's version first, or every masked file would look edited and changes near a secret would conflict
+					base = restoreRedactedSecrets(base, string(bytes))
+					content = restoreRedactedSecrets(content, string(bytes))
+				}
+				// this also runs when the plan's version matches the base, since writing it would otherwise undo the local edits
+				if string(bytes) != base {
+					merged, numConflicts := merge.Merge(base, string(bytes), content, "local", "plandex")
+					content = merged
+					if numConflicts > 0 {
+						conflictedFiles = append(conflictedFiles, path)
+					} else if merged != string(bytes) {
+						mergedFiles = append(mergedFiles, path)
+					}
+				}
+			}
+
+			// secrets masked when the file was loaded are put back rather than overwritten with placeholders
+			content = restoreRedactedSecrets(content, string(bytes))
+
 			// Check if the file has changed
 			if string(bytes) == content {
 				// log.Println("File is unchanged, skipping")
@@ -154,30 +250,147 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 			}
 		} else {
 			updatedFiles = append(updatedFiles, path)
+		}
 
-			// Create the directory if it doesn't exist
-			err := os.MkdirAll(filepath.Dir(dstPath), 0755)
-			if err != nil {
-				onErr("failed to create directory %s:", filepath.Dir(dstPath))
-				return
+		contentsByPath[path] = content
+	}
+
+	if len(conflictedFiles) > 0 {
+		sort.Strings(conflictedFiles)
+		// there's no prompting while another branch is checked out, so conflicts are committed there with markers
+		contentsByPath, updatedFiles, keptPaths = mustResolveApplyConflicts(conflictedFiles, toApply, contentsByPath, updatedFiles, autoConfirm || gitBranch != nil)
+	}
+
+	if len(keptPaths) > 0 {
+		// the plan's changes to files where the local version was kept stay pending rather than being marked applied
+		kept := map[string]bool{}
+		for _, path := range keptPaths {
+			kept[path] = true
+		}
+
+		remaining := map[string]string{}
+		filePaths = nil
+		for path, content := range toApply {
+			if !kept[path] {
+				remaining[path] = content
+				filePaths = append(filePaths, path)
 			}
 		}
+		toApply = remaining
+		sort.Strings(filePaths)
+
+		if len(toApply) == 0 {
+			term.StopSpinner()
+			fmt.Println("🤷‍♂️ No changes applied, your local versions were kept and the plan's changes are still pending")
+			return
+		}
+	}
+
+	// applies committed to another branch are undone with git rather than unapply
+	var snapshot *applySnapshot
+	if gitBranch == nil || gitBranch.isCurrent {
+		snapshot = newApplySnapshot(planId, branch, currentPlanState, toApply, updatedFiles, contentsByPath, priorByPath)
+		err := writeApplySnapshot(snapshot)
+		if err != nil {
+			onErr("failed to save apply snapshot: %v", err)
+			return
+		}
+	}
+
+	applyReq, err := newApplyPlanRequest(currentPlanState, toApply, filePaths, contentsByPath, priorByPath)
+	if err != nil {
+		if snapshot != nil {
+			removeApplySnapshot(snapshot.Id)
+		}
+		onErr("failed to mask secrets in applied files: %v", err)
+		return
+	}
+
+	apiErr = api.Client.ApplyPlan(planId, branch, applyReq)
+
+	if apiErr != nil {
+		if snapshot != nil {
+			removeApplySnapshot(snapshot.Id)
+		}
+		onErr("failed to set pending results applied: %s", apiErr.Msg)
+		return
+	}
+
+	for _, path := range updatedFiles {
+		dstPath := filepath.Join(fs.ProjectRoot, path)
+
+		// Create the directory if it doesn't exist
+		err := os.MkdirAll(filepath.Dir(dstPath), 0755)
+		if err != nil {
+			onErr("failed to create directory %s:", filepath.Dir(dstPath))
+			return
+		}
 
 		// Write the file
-		err = os.WriteFile(dstPath, []byte(content), 0644)
+		err = os.WriteFile(dstPath, []byte(contentsByPath[path]), 0644)
 		if err != nil {
 			onErr("failed to write %s:", dstPath)
 			return
 		}
 	}
 
+	if gitBranch != nil {
+		if len(updatedFiles) > 0 {
+			err := GitAddAndCommitPaths(fs.ProjectRoot, currentPlanState.PendingChangesSummaryForApply(updatedFiles), updatedFiles, true)
+			if err != nil {
+				onErr("failed to commit changes to branch %s: %v", gitBranch.name, err)
+			}
+		}
+
+		err := gitBranch.restore()
+		if err != nil {
+			term.StopSpinner()
+			term.OutputErrorAndExit("%v", err)
+		}
+	}
+
 	term.StopSpinner()
 
+	var markedFiles []string
+	for _, path := range conflictedFiles {
+		if content, ok := contentsByPath[path]; ok && merge.HasConflictMarkers(content) {
+			markedFiles = append(markedFiles, path)
+		}
+	}
+
+	if gitBranch != nil && !gitBranch.isCurrent {
+		if len(updatedFiles) == 0 {
+			fmt.Printf("✅ Applied changes, but no files were updated on branch %s\n", gitBranch.name)
+			return
+		}
+
+		suffix := ""
+		if len(updatedFiles) > 1 {
+			suffix = "s"
+		}
+		fmt.Printf("✅ Applied changes to branch %s, %d file%s updated and committed\n", color.New(color.Bold).Sprint(gitBranch.name), len(updatedFiles), suffix)
+		fmt.Printf("↩️  Switched back to %s", gitBranch.origRef)
+		if gitBranch.stashed {
+			fmt.Print(" and restored your uncommitted changes")
+		}
+		fmt.Println()
+
+		if len(markedFiles) > 0 {
+			fmt.Println()
+			fmt.Println("⚠️  " + color.New(term.ColorHiYellow, color.Bold).Sprintf("These files were committed to %s with conflict markers to resolve:", gitBranch.name))
+			for _, path := range markedFiles {
+				fmt.Printf("  • %s\n", path)
+			}
+		}
+		return
+	}
+
 	if len(updatedFiles) == 0 {
 		fmt.Println("✅ Applied changes, but no files were updated")
 		return
 	} else {
-		if isRepo {
+		// committing unresolved conflict markers would be a mistake, so that's left until they're resolved
+		if isRepo && len(markedFiles) == 0 && gitBranch == nil {
 			fmt.Println("✏️  Plandex can commit these updates with an automatically generated message.")
 			fmt.Println()
 			fmt.Println("ℹ️  Only the files that Plandex is updating will be included the commit. Any other changes, staged or unstaged, will remain exactly as they are.")
@@ -191,7 +404,7 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 
 			if confirmed {
 				// Commit the changes
-				msg := currentPlanState.PendingChangesSummaryForApply()
+				msg := currentPlanState.PendingChangesSummaryForApply(updatedFiles)
 
 				// log.Println("Committing changes with message:")
 				// log.Println(msg)
@@ -210,6 +423,131 @@ func MustApplyPlan(planId, branch string, autoConfirm bool) {
 			suffix = "s"
 		}
 		fmt.Printf("✅ Applied changes, %d file%s updated\n", len(updatedFiles), suffix)
+
+		if len(mergedFiles) > 0 {
+			fmt.Println()
+			fmt.Println("🔀 Merged with your local edits:")
+			for _, path := range mergedFiles {
+				fmt.Printf("  • %s\n", path)
+			}
+		}
+
+		if len(markedFiles) > 0 {
+			fmt.Println()
+			fmt.Println("⚠️  " + color.New(term.ColorHiYellow, color.Bold).Sprint("Resolve the conflict markers in these files:"))
+			for _, path := range markedFiles {
+				fmt.Printf("  • %s\n", path)
+			}
+		}
+
+		if len(keptPaths) > 0 {
+			fmt.Println()
+			fmt.Println("📌 Kept your local version, the plan's changes are still pending:")
+			for _, path := range keptPaths {
+				fmt.Printf("  • %s\n", path)
+			}
+		}
+	}
+
+}
+
+// MustSelectApplyFilePaths lists the files with pending changes and returns the ones selected to apply
+func MustSelectApplyFilePaths(planId, branch string) []string {
+	term.StartSpinner("")
+	currentPlanState, apiErr := api.Client.GetCurrentPlanState(planId, branch)
+	term.StopSpinner()
+
+	if apiErr != nil {
+		term.OutputErrorAndExit("Error getting current plan state: %v", apiErr)
+	}
+
+	var paths []string
+	for path := range currentPlanState.CurrentPlanFiles.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if len(paths) == 0 {
+		fmt.Println("🤷‍♂️ No changes to apply")
+		os.Exit(0)
+	}
+
+	selected, err := term.SelectManyFromList("Select files to apply:", paths)
+
+	if err != nil {
+		term.OutputErrorAndExit("failed to get a response: %v", err)
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("🤷‍♂️ No files selected")
+		os.Exit(0)
+	}
+
+	return selected
+}
+
+// ApplyFilePathsFromArgs resolves file paths given on the command line to the project-relative paths the plan uses
+func ApplyFilePathsFromArgs(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", arg, err)
+		}
+
+		relPath, err := filepath.Rel(fs.ProjectRoot, absPath)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			return nil, fmt.Errorf("%s isn't in the project", arg)
+		}
+
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+	return paths, nil
+}
+
+// newApplyPlanRequest sends what each applied file was written as, so the server sets its context to match the file on disk rather than the plan's version, which doesn't have any local edits merged in
+func newApplyPlanRequest(currentPlanState *shared.CurrentPlanState, toApply map[string]string, filePaths []string, contentsByPath map[string]string, priorByPath map[string]*string) (shared.ApplyPlanRequest, error) {
+	req := shared.ApplyPlanRequest{
+		FilePaths:      filePaths,
+		ContentsByPath: map[string]string{},
+	}
+
+	for path := range toApply {
+		content, ok := contentsByPath[path]
+		if !ok {
+			// files that were already up to date aren't written
+			prior := priorByPath[path]
+			if prior == nil {
+				continue
+			}
+			content = *prior
+		}
+
+		context := currentPlanState.ContextsByPath[path]
+		if context != nil && context.Redacted {
+			var err error
+			content, err = RedactContextBody(content)
+			if err != nil {
+				return req, err
+			}
+		}
+
+		req.ContentsByPath[path] = content
 	}
 
+	return req, nil
+}
+
+// contextsToCheckBeforeApply leaves out file context for files the plan changes. Local edits to those are merged with the plan's changes when they're applied, so they don't need a context update and rebuild first.
+func contextsToCheckBeforeApply(contexts []*shared.Context, toApply map[string]string) []*shared.Context {
+	toCheck := []*shared.Context{}
+	for _, context := range contexts {
+		if context.ContextType == shared.ContextFileType || context.IsPartialFile() {
+			if _, ok := toApply[context.FilePath]; ok {
+				continue
+			}
+		}
+		toCheck = append(toCheck, context)
+	}
+	return toCheck
 }
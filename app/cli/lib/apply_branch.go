@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"fmt"
+)
+
+// applyGitBranch tracks what was changed to apply onto another git branch so it can be put back
+type applyGitBranch struct {
+	name    string
+	origRef string
+	// the plan is applied and committed on the branch that's already checked out, so nothing needs restoring
+	isCurrent bool
+	stashed   bool
+}
+
+// checkoutApplyBranch stashes any uncommitted changes and checks out the branch, creating it from the current commit if it doesn't exist yet. Only changes to tracked files and untracked files at applyPaths are stashed, so other untracked files, including .plandex, stay where they are.
+func checkoutApplyBranch(name string, applyPaths []string) (*applyGitBranch, error) {
+	err := GitValidateBranchName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	origRef, err := GitCurrentBranch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the current branch: %v", err)
+	}
+
+	b := &applyGitBranch{name: name, origRef: origRef}
+
+	if origRef == name {
+		b.isCurrent = true
+		return b, nil
+	}
+
+	// untracked files the apply writes to would otherwise be committed on the branch and removed when switching back
+	stashPaths, err := GitStashablePaths(applyPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stashPaths) > 0 {
+		err = GitStashCreate(fmt.Sprintf("plandex: before applying onto %s", name), stashPaths)
+		if err != nil {
+			return nil, err
+		}
+		b.stashed = true
+	}
+
+	err = GitCheckout(name, !GitBranchExists(name))
+	if err != nil {
+		// put the stash back so a failed checkout leaves the working tree as it was
+		if b.stashed {
+			popErr := GitStashPop(false)
+			if popErr != nil {
+				return nil, fmt.Errorf("%v | also failed to restore stashed changes: %v", err, popErr)
+			}
+		}
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// restore checks out the original branch and pops the stashed changes
+func (b *applyGitBranch) restore() error {
+	if b.isCurrent {
+		return nil
+	}
+
+	err := GitCheckout(b.origRef, false)
+	if err != nil {
+		msg := fmt.Sprintf("failed to switch back to %s: %v", b.origRef, err)
+		if b.stashed {
+			msg += " | your uncommitted changes are saved in the latest git stash"
+		}
+		return fmt.Errorf("%s", msg)
+	}
+
+	if b.stashed {
+		err = GitStashPop(false)
+		if err != nil {
+			return fmt.Errorf("failed to restore your uncommitted changes, which are saved in the latest git stash: %v", err)
+		}
+	}
+
+	return nil
+}
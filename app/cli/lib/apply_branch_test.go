@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"plandex/types"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func newBranchApplyPlanState() *shared.CurrentPlanState {
+	results := []*shared.PlanFileResult{
+		{Id: "result-a", Path: "a.go", Content: "package a // updated\n"},
+		{Id: "result-new", Path: "new.go", Content: "package main // from the plan\n"},
+	}
+
+	return &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           results,
+			FileResultsByPath: shared.PlanFileResultsByPath{"a.go": {results[0]}, "new.go": {results[1]}},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{
+			"a.go":   "package a // updated\n",
+			"new.go": "package main // from the plan\n",
+		}},
+		ContextsByPath: map[string]*shared.Context{},
+	}
+}
+
+// sets up uncommitted work that a branch apply has to carry around: a tracked edit, an untracked file the plan writes to, an unrelated untracked file, and .plandex, which isn't ignored
+func writeUncommittedWork(t *testing.T) {
+	t.Helper()
+	writeProjectFile(t, "c.go", "package c // local edit\n")
+	writeProjectFile(t, "new.go", "package main // local draft\n")
+	writeProjectFile(t, "notes.txt", "notes\n")
+	writeProjectFile(t, ".plandex/settings.json", "{}\n")
+}
+
+func TestGitStashablePaths(t *testing.T) {
+	useTempGitRepo(t, &fakeApplyClient{}, map[string]string{"a.go": "package a\n", "c.go": "package c\n"})
+	writeUncommittedWork(t)
+
+	paths, err := GitStashablePaths([]string{"a.go", "new.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"c.go", "new.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestApplyBranchRoundTrip(t *testing.T) {
+	client := &fakeApplyClient{state: newBranchApplyPlanState()}
+	useTempGitRepo(t, client, map[string]string{"a.go": "package a\n", "c.go": "package c\n"})
+	writeUncommittedWork(t)
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true, GitBranch: "plandex"})
+
+	if branch := strings.TrimSpace(gitTest(t, "rev-parse", "--abbrev-ref", "HEAD")); branch != "main" {
+		t.Errorf("checked out %s after applying, want main", branch)
+	}
+
+	// the plan's changes are committed on the branch
+	for path, want := range client.state.CurrentPlanFiles.Files {
+		if got := gitTest(t, "show", "plandex:"+path); got != want {
+			t.Errorf("%s on the branch = %q, want %q", path, got, want)
+		}
+	}
+
+	// and the working tree is back the way it was
+	for path, want := range map[string]string{
+		"a.go":                   "package a\n",
+		"c.go":                   "package c // local edit\n",
+		"new.go":                 "package main // local draft\n",
+		"notes.txt":              "notes\n",
+		".plandex/settings.json": "{}\n",
+	} {
+		got, ok := readProjectFile(t, path)
+		if !ok {
+			t.Errorf("%s is missing after applying", path)
+		} else if got != want {
+			t.Errorf("%s = %q after applying, want %q", path, got, want)
+		}
+	}
+
+	if stashes := gitTest(t, "stash", "list"); stashes != "" {
+		t.Errorf("stash wasn't popped: %s", stashes)
+	}
+}
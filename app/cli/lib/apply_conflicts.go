@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const (
+	applyConflictMarkersOpt = "Write the file with conflict markers to resolve"
+	applyConflictLocalOpt   = "Keep my local version"
+	applyConflictPlanOpt    = "Use the plan's version, discarding my local edits"
+)
+
+// mustResolveApplyConflicts asks what to do with each file where local edits conflict with the plan's changes: write it with conflict markers, keep the local version, or use the plan's version. With autoConfirm, files are written with conflict markers. It returns the contents and updated files left to write, and the files where the local version was kept, whose changes should stay pending in the plan.
+func mustResolveApplyConflicts(conflictedFiles []string, toApply, contentsByPath map[string]string, updatedFiles []string, autoConfirm bool) (map[string]string, []string, []string) {
+	if autoConfirm {
+		return contentsByPath, updatedFiles, nil
+	}
+
+	term.StopSpinner()
+
+	fmt.Println("⚔️  " + color.New(term.ColorHiYellow, color.Bold).Sprint("Your local edits conflict with the plan's changes"))
+	for _, path := range conflictedFiles {
+		fmt.Printf("  • %s\n", path)
+	}
+	fmt.Println()
+
+	skipPaths := map[string]bool{}
+	var keptPaths []string
+
+	for _, path := range conflictedFiles {
+		selected, err := term.SelectFromList(fmt.Sprintf("%s:", path), []string{applyConflictMarkersOpt, applyConflictLocalOpt, applyConflictPlanOpt})
+
+		if err != nil {
+			term.OutputErrorAndExit("failed to get a response: %v", err)
+		}
+
+		switch selected {
+		case applyConflictLocalOpt:
+			skipPaths[path] = true
+			keptPaths = append(keptPaths, path)
+			delete(contentsByPath, path)
+
+		case applyConflictPlanOpt:
+			bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, path))
+			if err != nil {
+				term.OutputErrorAndExit("failed to read %s: %v", path, err)
+			}
+
+			content := strings.ReplaceAll(toApply[path], "\\`\\`\\`", "```")
+			contentsByPath[path] = restoreRedactedSecrets(content, string(bytes))
+		}
+	}
+
+	fmt.Println()
+	term.ResumeSpinner()
+
+	if len(skipPaths) == 0 {
+		return contentsByPath, updatedFiles, nil
+	}
+
+	var res []string
+	for _, path := range updatedFiles {
+		if !skipPaths[path] {
+			res = append(res, path)
+		}
+	}
+
+	return contentsByPath, res, keptPaths
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// snapshots are kept for this many of the most recent applies
+const maxApplySnapshots = 10
+
+// applySnapshot records what an apply changed so that 'plandex unapply' can undo it, in the project and on the server
+type applySnapshot struct {
+	Id        string    `json:"id"`
+	PlanId    string    `json:"planId"`
+	Branch    string    `json:"branch"`
+	CreatedAt time.Time `json:"createdAt"`
+	ResultIds []string  `json:"resultIds"`
+	// the bodies file contexts had before the apply updated them, keyed by path
+	ContextBodiesByPath map[string]string `json:"contextBodiesByPath"`
+	// new files the apply loaded into context
+	RemoveContextPaths []string             `json:"removeContextPaths"`
+	Files              []*applySnapshotFile `json:"files"`
+}
+
+type applySnapshotFile struct {
+	Path string `json:"path"`
+	// files that didn't exist before the apply are removed by unapply
+	Existed bool   `json:"existed"`
+	Content string `json:"content,omitempty"`
+	// sha of what the apply wrote, to tell whether the file was changed afterward
+	AppliedSha string `json:"appliedSha"`
+}
+
+func applySnapshotsDir() string {
+	return filepath.Join(fs.PlandexDir, "applies")
+}
+
+func contentSha(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// writeApplySnapshot stores a snapshot and removes the oldest ones beyond maxApplySnapshots
+func writeApplySnapshot(snapshot *applySnapshot) error {
+	dir := applySnapshotsDir()
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating apply snapshots dir: %v", err)
+	}
+
+	// ids sort in the order snapshots were made
+	snapshot.Id = strconv.FormatInt(snapshot.CreatedAt.UnixNano(), 10)
+
+	bytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error marshalling apply snapshot: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, snapshot.Id+".json"), bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing apply snapshot: %v", err)
+	}
+
+	ids, err := listApplySnapshotIds()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > maxApplySnapshots {
+		err = removeApplySnapshot(ids[0])
+		if err != nil {
+			return err
+		}
+		ids = ids[1:]
+	}
+
+	return nil
+}
+
+func listApplySnapshotIds() ([]string, error) {
+	entries, err := os.ReadDir(applySnapshotsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading apply snapshots dir: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	return ids, nil
+}
+
+// getLatestApplySnapshot returns the snapshot of the most recent apply, or nil if there isn't one
+func getLatestApplySnapshot() (*applySnapshot, error) {
+	ids, err := listApplySnapshotIds()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	bytes, err := os.ReadFile(filepath.Join(applySnapshotsDir(), ids[len(ids)-1]+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading apply snapshot: %v", err)
+	}
+
+	var snapshot applySnapshot
+	err = json.Unmarshal(bytes, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling apply snapshot: %v", err)
+	}
+
+	return &snapshot, nil
+}
+
+func removeApplySnapshot(id string) error {
+	err := os.Remove(filepath.Join(applySnapshotsDir(), id+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing apply snapshot: %v", err)
+	}
+	return nil
+}
+
+func newApplySnapshot(planId, branch string, currentPlanState *shared.CurrentPlanState, toApply map[string]string, updatedFiles []string, contentsByPath map[string]string, priorByPath map[string]*string) *applySnapshot {
+	snapshot := &applySnapshot{
+		PlanId:              planId,
+		Branch:              branch,
+		CreatedAt:           time.Now(),
+		ContextBodiesByPath: map[string]string{},
+	}
+
+	for _, result := range currentPlanState.PlanResult.Results {
+		if _, ok := toApply[result.Path]; ok && result.IsPending() {
+			snapshot.ResultIds = append(snapshot.ResultIds, result.Id)
+		}
+	}
+
+	for path := range toApply {
+		context := currentPlanState.ContextsByPath[path]
+		if context == nil {
+			snapshot.RemoveContextPaths = append(snapshot.RemoveContextPaths, path)
+		} else if !context.IsPartialFile() {
+			snapshot.ContextBodiesByPath[path] = strings.ReplaceAll(context.Body, "\\`\\`\\`", "```")
+		}
+	}
+	sort.Strings(snapshot.RemoveContextPaths)
+
+	for _, path := range updatedFiles {
+		file := &applySnapshotFile{
+			Path:       path,
+			AppliedSha: contentSha(contentsByPath[path]),
+		}
+		if prior := priorByPath[path]; prior != nil {
+			file.Existed = true
+			file.Content = *prior
+		}
+		snapshot.Files = append(snapshot.Files, file)
+	}
+
+	return snapshot
+}
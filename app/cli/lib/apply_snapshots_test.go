@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteApplySnapshotPrunesOldest(t *testing.T) {
+	useTempProject(t, nil)
+
+	start := time.Now()
+	for i := 0; i < maxApplySnapshots+3; i++ {
+		snapshot := &applySnapshot{
+			PlanId:    "plan",
+			Branch:    "main",
+			CreatedAt: start.Add(time.Duration(i) * time.Second),
+			ResultIds: []string{strconv.Itoa(i)},
+		}
+		if err := writeApplySnapshot(snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := listApplySnapshotIds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != maxApplySnapshots {
+		t.Fatalf("got %d snapshots, want %d", len(ids), maxApplySnapshots)
+	}
+	if want := strconv.FormatInt(start.Add(3*time.Second).UnixNano(), 10); ids[0] != want {
+		t.Errorf("oldest kept snapshot = %s, want %s", ids[0], want)
+	}
+
+	latest, err := getLatestApplySnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == nil || latest.ResultIds[0] != strconv.Itoa(maxApplySnapshots+2) {
+		t.Errorf("latest snapshot = %+v, want the last one written", latest)
+	}
+
+	// unapplying the latest makes the one before it the latest
+	if err := removeApplySnapshot(latest.Id); err != nil {
+		t.Fatal(err)
+	}
+	latest, err = getLatestApplySnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == nil || latest.ResultIds[0] != strconv.Itoa(maxApplySnapshots+1) {
+		t.Errorf("latest snapshot after removal = %+v", latest)
+	}
+}
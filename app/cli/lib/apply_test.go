@@ -0,0 +1,303 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/merge"
+	"plandex/redact"
+	"plandex/types"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// fakeApplyClient serves a plan with pending changes and records what apply and unapply send
+type fakeApplyClient struct {
+	types.ApiClient
+	state      *shared.CurrentPlanState
+	applyReq   *shared.ApplyPlanRequest
+	numApplied int
+	unapplyReq *shared.UnapplyPlanRequest
+}
+
+func (c *fakeApplyClient) GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError) {
+	return c.state, nil
+}
+
+func (c *fakeApplyClient) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
+	return nil, nil
+}
+
+func (c *fakeApplyClient) ApplyPlan(planId, branch string, req shared.ApplyPlanRequest) *shared.ApiError {
+	c.numApplied++
+	c.applyReq = &req
+	return nil
+}
+
+func (c *fakeApplyClient) UnapplyPlan(planId, branch string, req shared.UnapplyPlanRequest) *shared.ApiError {
+	c.unapplyReq = &req
+	return nil
+}
+
+// useTempProject points the project root and .plandex dir at a temp dir, with client as the api client
+func useTempProject(t *testing.T, client types.ApiClient) string {
+	projectRoot, plandexDir, apiClient := fs.ProjectRoot, fs.PlandexDir, api.Client
+	t.Cleanup(func() {
+		fs.ProjectRoot, fs.PlandexDir, api.Client = projectRoot, plandexDir, apiClient
+	})
+
+	dir := t.TempDir()
+	fs.ProjectRoot = dir
+	fs.PlandexDir = filepath.Join(dir, ".plandex")
+	api.Client = client
+
+	return dir
+}
+
+func writeProjectFile(t *testing.T, path, content string) {
+	t.Helper()
+	dstPath := filepath.Join(fs.ProjectRoot, path)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dstPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readProjectFile(t *testing.T, path string) (string, bool) {
+	t.Helper()
+	bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, path))
+	if os.IsNotExist(err) {
+		return "", false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bytes), true
+}
+
+func TestApplyMergesWithRedactedBase(t *testing.T) {
+	// split up so the test file itself doesn't look like it holds a secret
+	secret := "AKIA" + "IOSFODNN7EXAMPLE"
+	original := "package main\n\nconst apiKey = \"" + secret + "\"\nconst timeout = 10\n\nfunc main() {}\n"
+
+	loaded, findings := redact.Redact(original, redact.BuiltInRules())
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings in the sample, want 1", len(findings))
+	}
+
+	// the plan's change is built against the masked body, on the line after the secret
+	planned := strings.Replace(loaded, "timeout = 10", "timeout = 30", 1)
+
+	tests := []struct {
+		name  string
+		local string
+		want  string
+	}{
+		{
+			name:  "no local edits",
+			local: original,
+			want:  strings.Replace(original, "timeout = 10", "timeout = 30", 1),
+		},
+		{
+			name:  "local edit elsewhere",
+			local: strings.Replace(original, "func main() {}", "func main() { run() }", 1),
+			want:  strings.Replace(strings.Replace(original, "timeout = 10", "timeout = 30", 1), "func main() {}", "func main() { run() }", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		result := &shared.PlanFileResult{Id: "result", Path: "main.go", Content: planned}
+		client := &fakeApplyClient{state: &shared.CurrentPlanState{
+			PlanResult: &shared.PlanResult{
+				Results:           []*shared.PlanFileResult{result},
+				FileResultsByPath: shared.PlanFileResultsByPath{"main.go": {result}},
+			},
+			CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{"main.go": planned}},
+			ContextsByPath: map[string]*shared.Context{
+				"main.go": {ContextType: shared.ContextFileType, FilePath: "main.go", Body: loaded, Redacted: true},
+			},
+		}}
+		useTempProject(t, client)
+		writeProjectFile(t, "main.go", tt.local)
+
+		MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true})
+
+		got, _ := readProjectFile(t, "main.go")
+		if merge.HasConflictMarkers(got) {
+			t.Errorf("%s: the change next to a masked secret conflicted:\n%s", tt.name, got)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+
+		// the context is set to the merged file, masked like it was when loaded, so it isn't outdated on the next apply
+		wantBody, _ := redact.Redact(tt.want, redact.BuiltInRules())
+		if client.applyReq == nil || client.applyReq.ContentsByPath["main.go"] != wantBody {
+			t.Errorf("%s: expected the merged file to be sent masked as main.go's context, got %+v", tt.name, client.applyReq)
+		}
+	}
+}
+
+func TestContextsToCheckBeforeApply(t *testing.T) {
+	contexts := []*shared.Context{
+		{Id: "file", ContextType: shared.ContextFileType, FilePath: "a.go"},
+		{Id: "symbol", ContextType: shared.ContextSymbolType, FilePath: "a.go", Symbol: "Run"},
+		{Id: "range", ContextType: shared.ContextFileType, FilePath: "a.go", StartLine: 1, EndLine: 3},
+		{Id: "other", ContextType: shared.ContextFileType, FilePath: "b.go"},
+		{Id: "otherSymbol", ContextType: shared.ContextSymbolType, FilePath: "b.go", Symbol: "Run"},
+		{Id: "url", ContextType: shared.ContextURLType, Url: "https://example.com"},
+	}
+
+	var ids []string
+	for _, context := range contextsToCheckBeforeApply(contexts, map[string]string{"a.go": "package a\n"}) {
+		ids = append(ids, context.Id)
+	}
+
+	want := []string{"other", "otherSymbol", "url"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
+
+func TestApplyKeepsLocalEditsWhenPlanMatchesBase(t *testing.T) {
+	loaded := "package main\n\nfunc main() {}\n"
+	local := "package main\n\nfunc main() { run() }\n"
+
+	// the plan's version of main.go is what was loaded, like when its changes to it were rejected or reverted
+	result := &shared.PlanFileResult{Id: "result", Path: "main.go", Content: loaded}
+	client := &fakeApplyClient{state: &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           []*shared.PlanFileResult{result},
+			FileResultsByPath: shared.PlanFileResultsByPath{"main.go": {result}},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{"main.go": loaded}},
+		ContextsByPath: map[string]*shared.Context{
+			"main.go": {ContextType: shared.ContextFileType, FilePath: "main.go", Body: loaded},
+		},
+	}}
+	useTempProject(t, client)
+	writeProjectFile(t, "main.go", local)
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true})
+
+	got, _ := readProjectFile(t, "main.go")
+	if got != local {
+		t.Errorf("local edits were overwritten, got:\n%s\nwant:\n%s", got, local)
+	}
+}
+
+func TestApplyMergesLineRangeWhenChangesNoLongerApply(t *testing.T) {
+	loaded := "one\ntwo\nthree\nfour\nfive\n"
+	planned := "one\nTWO\nthree\nfour\nfive\n"
+	// the loaded range was edited so the replacement no longer applies, and so was the rest of the file
+	local := "one\ntwo\n3\nfour\nFIVE\n"
+
+	result := &shared.PlanFileResult{
+		Id:           "result",
+		Path:         "main.txt",
+		Replacements: []*shared.Replacement{{Id: "replacement", Old: "two\nthree", New: "TWO\nthree"}},
+	}
+	client := &fakeApplyClient{state: &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           []*shared.PlanFileResult{result},
+			FileResultsByPath: shared.PlanFileResultsByPath{"main.txt": {result}},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{"main.txt": planned}},
+		// the server sends the whole file as loaded as the body of a partial context here
+		ContextsByPath: map[string]*shared.Context{
+			"main.txt": {ContextType: shared.ContextFileType, FilePath: "main.txt", StartLine: 1, EndLine: 3, Body: loaded},
+		},
+	}}
+	useTempProject(t, client)
+	writeProjectFile(t, "main.txt", local)
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true})
+
+	got, _ := readProjectFile(t, "main.txt")
+	if !strings.HasSuffix(got, "four\nFIVE\n") {
+		t.Errorf("the local edit outside the loaded range was overwritten:\n%s", got)
+	}
+	if !merge.HasConflictMarkers(got) {
+		t.Errorf("expected the conflicting edits in the loaded range to be marked:\n%s", got)
+	}
+}
+
+// useTempGitRepo makes a temp project that's a git repo with files committed on main
+func useTempGitRepo(t *testing.T, client types.ApiClient, files map[string]string) {
+	t.Helper()
+
+	dir := useTempProject(t, client)
+	initTestGitRepo(t, dir, files)
+}
+
+func TestApplyCommitMessageOnlyDescribesAppliedFiles(t *testing.T) {
+	results := []*shared.PlanFileResult{
+		{Id: "result-a", ConvoMessageId: "msg-a", Path: "a.go", Content: "package a // updated\n"},
+		{Id: "result-b", ConvoMessageId: "msg-b", Path: "b.go", Content: "package b // updated\n"},
+	}
+	client := &fakeApplyClient{state: &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           results,
+			FileResultsByPath: shared.PlanFileResultsByPath{"a.go": {results[0]}, "b.go": {results[1]}},
+		},
+		ConvoMessageDescriptions: []*shared.ConvoMessageDescription{
+			{ConvoMessageId: "msg-a", CommitMsg: "Update a"},
+			{ConvoMessageId: "msg-b", CommitMsg: "Update b"},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{
+			"a.go": "package a // updated\n",
+			"b.go": "package b // updated\n",
+		}},
+		ContextsByPath: map[string]*shared.Context{},
+	}}
+	useTempGitRepo(t, client, map[string]string{"a.go": "package a\n", "b.go": "package b\n"})
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true, FilePaths: []string{"a.go"}, GitBranch: "plandex"})
+
+	msg := gitTest(t, "log", "-1", "--format=%B", "plandex")
+	if !strings.Contains(msg, "Update a") {
+		t.Errorf("commit message doesn't describe the applied change:\n%s", msg)
+	}
+	if strings.Contains(msg, "Update b") {
+		t.Errorf("commit message describes a change that's still pending:\n%s", msg)
+	}
+}
+
+func TestApplySelectedFiles(t *testing.T) {
+	results := []*shared.PlanFileResult{
+		{Id: "result-a", Path: "a.go", Content: "package a // updated\n"},
+		{Id: "result-b", Path: "b.go", Content: "package b // updated\n"},
+	}
+	client := &fakeApplyClient{state: &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           results,
+			FileResultsByPath: shared.PlanFileResultsByPath{"a.go": {results[0]}, "b.go": {results[1]}},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{
+			"a.go": "package a // updated\n",
+			"b.go": "package b // updated\n",
+		}},
+		ContextsByPath: map[string]*shared.Context{},
+	}}
+	useTempProject(t, client)
+	writeProjectFile(t, "a.go", "package a\n")
+	writeProjectFile(t, "b.go", "package b\n")
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true, FilePaths: []string{"a.go"}})
+
+	if content, _ := readProjectFile(t, "a.go"); content != "package a // updated\n" {
+		t.Errorf("a.go wasn't updated: %q", content)
+	}
+	if content, _ := readProjectFile(t, "b.go"); content != "package b\n" {
+		t.Errorf("b.go was updated, but it wasn't selected: %q", content)
+	}
+	if client.applyReq == nil || strings.Join(client.applyReq.FilePaths, ",") != "a.go" {
+		t.Errorf("expected only a.go to be marked applied, got %+v", client.applyReq)
+	}
+}
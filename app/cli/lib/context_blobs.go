@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"log"
+	"plandex/api"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type contextBody struct {
+	body *string
+	hash *string
+}
+
+// omitStoredContextBodies sends content hashes to the server first and leaves out any bodies already in the branch's context, so unchanged files aren't uploaded again. If the check fails, bodies are sent in full.
+func omitStoredContextBodies(bodies []contextBody) {
+	var hashes []string
+	seen := map[string]bool{}
+
+	for _, b := range bodies {
+		if *b.body == "" {
+			continue
+		}
+		*b.hash = shared.GetContextBodyHash(*b.body)
+		if !seen[*b.hash] {
+			seen[*b.hash] = true
+			hashes = append(hashes, *b.hash)
+		}
+	}
+
+	if len(hashes) == 0 {
+		return
+	}
+
+	res, apiErr := api.Client.GetMissingContextBlobs(CurrentPlanId, CurrentBranch, shared.MissingContextBlobsRequest{Hashes: hashes})
+	if apiErr != nil {
+		log.Printf("Error checking for stored context bodies, sending in full: %v\n", apiErr.Msg)
+		return
+	}
+
+	missing := map[string]bool{}
+	for _, hash := range res.Missing {
+		missing[hash] = true
+	}
+
+	for _, b := range bodies {
+		if *b.hash != "" && !missing[*b.hash] {
+			*b.body = ""
+		}
+	}
+}
+
+func omitStoredLoadContextBodies(req shared.LoadContextRequest) {
+	var bodies []contextBody
+	for _, params := range req {
+		bodies = append(bodies,
+			contextBody{body: &params.Body, hash: &params.BodyHash},
+			contextBody{body: &params.FileBody, hash: &params.FileBodyHash},
+		)
+	}
+	omitStoredContextBodies(bodies)
+}
+
+func omitStoredUpdateContextBodies(req shared.UpdateContextRequest) {
+	var bodies []contextBody
+	for _, params := range req {
+		bodies = append(bodies,
+			contextBody{body: &params.Body, hash: &params.BodyHash},
+			contextBody{body: &params.FileBody, hash: &params.FileBodyHash},
+		)
+	}
+	omitStoredContextBodies(bodies)
+}
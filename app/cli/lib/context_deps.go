@@ -0,0 +1,254 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/deps"
+	"plandex/fs"
+	"plandex/term"
+	"plandex/types"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const (
+	DefaultDepsDepth  = 1
+	DefaultDepsTokens = 20000
+)
+
+const (
+	depsLoadFullOpt    = "full"
+	depsLoadOutlineOpt = "outline"
+)
+
+type depFile struct {
+	path          string
+	body          string
+	outline       string
+	fullTokens    int
+	outlineTokens int
+}
+
+// mustBuildDepsLoadContextRequest follows the local imports of the files being loaded, up to params.DepsDepth levels, and asks whether to load the files found in full, as outlines, or not at all. Files that are already in context or being loaded aren't offered again, and only as many files as fit in params.DepsTokens are loaded.
+func mustBuildDepsLoadContextRequest(req shared.LoadContextRequest, params *types.LoadContextParams, existsByComposite map[string]bool, ignoredPaths map[string]string) shared.LoadContextRequest {
+	onErr := onLoadContextErr
+
+	seen := map[string]bool{}
+	var queue []string
+
+	for _, context := range req {
+		if context.FilePath == "" || context.ContextType == shared.ContextImageType || context.ContextType == shared.ContextDirectoryTreeType || context.ContextType == shared.ContextRepoMapType {
+			continue
+		}
+		if !seen[context.FilePath] {
+			seen[context.FilePath] = true
+			queue = append(queue, context.FilePath)
+		}
+	}
+
+	var paths *fs.ProjectPaths
+	if !params.ForceSkipIgnore {
+		var err error
+		paths, err = fs.GetProjectPaths(fs.ProjectRoot)
+		if err != nil {
+			onErr(fmt.Errorf("failed to get project paths: %v", err))
+		}
+	}
+
+	depPaths, err := resolveDepPaths(queue, params.DepsDepth, func(depPath string) bool {
+		if existsByComposite[strings.Join([]string{string(shared.ContextFileType), depPath}, "|")] {
+			return false
+		}
+
+		if paths != nil && !paths.ActivePaths[depPath] {
+			if reason, ok := paths.IgnoredPaths[depPath]; ok {
+				ignoredPaths[depPath] = reason
+			}
+			return false
+		}
+
+		return true
+	})
+	if err != nil {
+		onErr(err)
+	}
+
+	if len(depPaths) == 0 {
+		return nil
+	}
+
+	files := make([]*depFile, 0, len(depPaths))
+	for _, path := range depPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			onErr(fmt.Errorf("failed to read the file %s: %v", path, err))
+		}
+
+		file := &depFile{path: path, body: string(content)}
+
+		file.fullTokens, err = shared.GetNumTokens(file.body)
+		if err != nil {
+			onErr(fmt.Errorf("failed to get the number of tokens in %s: %v", path, err))
+		}
+
+		if outline, ok := shared.GetOutline(path, file.body); ok {
+			file.outline = outline
+			file.outlineTokens, err = shared.GetNumTokens(outline)
+			if err != nil {
+				onErr(fmt.Errorf("failed to get the number of tokens in the outline of %s: %v", path, err))
+			}
+		} else {
+			file.outlineTokens = file.fullTokens
+		}
+
+		files = append(files, file)
+	}
+
+	fullFiles, fullTokens := fitDepsBudget(files, params.DepsTokens, func(f *depFile) int { return f.fullTokens })
+	outlineFiles, outlineTokens := fitDepsBudget(files, params.DepsTokens, func(f *depFile) int { return f.outlineTokens })
+
+	if len(outlineFiles) == 0 {
+		term.StopSpinner()
+		fmt.Printf("🤷‍♂️ Found %d local %s, but none fit in the %d 🪙 dependency budget\n", len(files), depsLabel(len(files)), params.DepsTokens)
+		term.ResumeSpinner()
+		return nil
+	}
+
+	fileOpt := func(mode string, n, numTokens int) string {
+		opt := fmt.Sprintf("Load %d %s %s | %d 🪙", n, depsLabel(n), mode, numTokens)
+		if n < len(files) {
+			opt += fmt.Sprintf(" | %d left out to fit the budget", len(files)-n)
+		}
+		return opt
+	}
+
+	var opts []string
+	modesByOpt := map[string]string{}
+	if len(fullFiles) > 0 {
+		opt := fileOpt("in full", len(fullFiles), fullTokens)
+		opts = append(opts, opt)
+		modesByOpt[opt] = depsLoadFullOpt
+	}
+	outlineOpt := fileOpt("as outlines", len(outlineFiles), outlineTokens)
+	opts = append(opts, outlineOpt)
+	modesByOpt[outlineOpt] = depsLoadOutlineOpt
+	skipOpt := "Skip dependencies"
+	opts = append(opts, skipOpt)
+
+	term.StopSpinner()
+
+	fmt.Printf("📦 Found %d local %s\n", len(files), depsLabel(len(files)))
+	for _, file := range files {
+		fmt.Printf("  • %s\n", file.path)
+	}
+	fmt.Println()
+
+	selected, err := term.SelectFromList("Load dependencies?", opts)
+	if err != nil {
+		onErr(fmt.Errorf("failed to get a response: %v", err))
+	}
+
+	term.ResumeSpinner()
+
+	var res shared.LoadContextRequest
+
+	switch modesByOpt[selected] {
+	case depsLoadFullOpt:
+		for _, file := range fullFiles {
+			res = append(res, &shared.LoadContextParams{
+				ContextType: shared.ContextFileType,
+				Name:        file.path,
+				FilePath:    file.path,
+				Body:        file.body,
+			})
+		}
+	case depsLoadOutlineOpt:
+		for _, file := range outlineFiles {
+			params := &shared.LoadContextParams{
+				ContextType: shared.ContextFileType,
+				Name:        file.path,
+				FilePath:    file.path,
+				Body:        file.body,
+			}
+			if file.outline != "" {
+				params.Body = file.outline
+				params.FileBody = file.body
+				params.Outline = true
+			}
+			res = append(res, params)
+		}
+	}
+
+	return res
+}
+
+// resolveDepPaths follows local imports from the files in queue, up to depth levels, and returns the files found in the order they were found. Files that include rejects aren't returned or followed.
+func resolveDepPaths(queue []string, depth int, include func(path string) bool) ([]string, error) {
+	seen := map[string]bool{}
+	for _, path := range queue {
+		seen[path] = true
+	}
+
+	var depPaths []string
+
+	for level := 1; level <= depth && len(queue) > 0; level++ {
+		var next []string
+
+		for _, path := range queue {
+			if !deps.HasResolver(path) {
+				continue
+			}
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the file %s: %v", path, err)
+			}
+
+			resolved, err := deps.Resolve(path, src)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve the dependencies of %s: %v", path, err)
+			}
+
+			for _, depPath := range resolved {
+				if seen[depPath] {
+					continue
+				}
+				seen[depPath] = true
+
+				if !include(depPath) {
+					continue
+				}
+
+				depPaths = append(depPaths, depPath)
+				next = append(next, depPath)
+			}
+		}
+
+		queue = next
+	}
+
+	return depPaths, nil
+}
+
+// fitDepsBudget takes files in the order they were found until the next one doesn't fit in budget, so closer dependencies are kept when the budget runs out
+func fitDepsBudget(files []*depFile, budget int, tokensFn func(*depFile) int) ([]*depFile, int) {
+	var res []*depFile
+	total := 0
+	for _, file := range files {
+		n := tokensFn(file)
+		if total+n > budget {
+			break
+		}
+		total += n
+		res = append(res, file)
+	}
+	return res, total
+}
+
+func depsLabel(n int) string {
+	if n == 1 {
+		return "dependency"
+	}
+	return "dependencies"
+}
@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveDepPaths(t *testing.T) {
+	dir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod":               "module example.com/app\n\ngo 1.21\n",
+		"main.go":              "package main\n\nimport \"example.com/app/store\"\n",
+		"store/store.go":       "package store\n\nimport \"example.com/app/db\"\n",
+		"store/store_test.go":  "package store\n\nimport \"example.com/app/testutil\"\n",
+		"db/db.go":             "package db\n\nimport \"example.com/app/store/cache\"\n",
+		"store/cache/cache.go": "package cache\n",
+		"testutil/testutil.go": "package testutil\n",
+	} {
+		dstPath := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(dstPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	includeAll := func(string) bool { return true }
+
+	tests := []struct {
+		name    string
+		depth   int
+		include func(string) bool
+		want    []string
+	}{
+		{name: "one level", depth: 1, include: includeAll, want: []string{"store/store.go"}},
+		{name: "two levels", depth: 2, include: includeAll, want: []string{"store/store.go", "db/db.go"}},
+		{name: "three levels", depth: 3, include: includeAll, want: []string{"store/store.go", "db/db.go", "store/cache/cache.go"}},
+		{
+			// db.go is already in context, so its imports aren't followed either
+			name:    "excluded file",
+			depth:   3,
+			include: func(path string) bool { return path != "db/db.go" },
+			want:    []string{"store/store.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := resolveDepPaths([]string{"main.go"}, tt.depth, tt.include)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFitDepsBudget(t *testing.T) {
+	files := []*depFile{
+		{path: "a.go", fullTokens: 40, outlineTokens: 10},
+		{path: "b.go", fullTokens: 50, outlineTokens: 20},
+		{path: "c.go", fullTokens: 5, outlineTokens: 5},
+	}
+
+	paths := func(files []*depFile) []string {
+		var res []string
+		for _, file := range files {
+			res = append(res, file.path)
+		}
+		return res
+	}
+
+	// c.go would fit on its own, but closer dependencies come first, so it's left out along with b.go
+	full, fullTokens := fitDepsBudget(files, 60, func(f *depFile) int { return f.fullTokens })
+	if !reflect.DeepEqual(paths(full), []string{"a.go"}) || fullTokens != 40 {
+		t.Errorf("full: got %v (%d 🪙), want [a.go] (40 🪙)", paths(full), fullTokens)
+	}
+
+	outlines, outlineTokens := fitDepsBudget(files, 60, func(f *depFile) int { return f.outlineTokens })
+	if !reflect.DeepEqual(paths(outlines), []string{"a.go", "b.go", "c.go"}) || outlineTokens != 35 {
+		t.Errorf("outlines: got %v (%d 🪙), want all files (35 🪙)", paths(outlines), outlineTokens)
+	}
+}
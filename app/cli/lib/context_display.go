@@ -21,6 +21,27 @@ func GetContextLabelAndIcon(contextType shared.ContextType) (string, string) {
 	case shared.ContextPipedDataType:
 		icon = "↔️ "
 		lbl = "piped"
+	case shared.ContextImageType:
+		icon = "🖼️ "
+		lbl = "image"
+	case shared.ContextSymbolType:
+		icon = "🔣"
+		lbl = "symbol"
+	case shared.ContextGitDiffType:
+		icon = "🔀"
+		lbl = "diff"
+	case shared.ContextGitCommitType:
+		icon = "🔖"
+		lbl = "commit"
+	case shared.ContextGitChangesType:
+		icon = "🌿"
+		lbl = "changes"
+	case shared.ContextExecType:
+		icon = "💻"
+		lbl = "command"
+	case shared.ContextRepoMapType:
+		icon = "🗺️ "
+		lbl = "map"
 	}
 
 	return lbl, icon
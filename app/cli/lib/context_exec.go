@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	execTimeout = 2 * time.Minute
+	// how long to wait for output after the command is killed, in case something it started still holds the pipe
+	execWaitDelay = 5 * time.Second
+	execMaxLines  = 1000
+	execMaxBytes  = 100 * 1024
+	// when output is truncated, keep this share of the lines from the start, and the rest from the end where failures usually are
+	execHeadShare = 0.25
+)
+
+// runExecContext runs a command in the current directory and returns its combined output along with how it exited. A non-zero exit isn't an error, since failing output (like failing tests) is usually why the command was loaded.
+func runExecContext(command string) (string, error) {
+	return runExecContextWithTimeout(command, execTimeout)
+}
+
+func runExecContextWithTimeout(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	// killing only the shell would leave anything it started running, so the whole process tree is killed on timeout
+	setExecProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killExecProcessGroup(cmd)
+	}
+	cmd.WaitDelay = execWaitDelay
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+
+	var status string
+	if ctx.Err() == context.DeadlineExceeded {
+		status = fmt.Sprintf("timed out after %s", timeout)
+	} else if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to run '%s': %v", command, err)
+		}
+		status = fmt.Sprintf("exit status %d", exitErr.ExitCode())
+	} else {
+		status = "exit status 0"
+	}
+
+	return fmt.Sprintf("$ %s\n%s\n[%s]", command, truncateExecOutput(out.String()), status), nil
+}
+
+func truncateExecOutput(output string) string {
+	output = strings.TrimRight(output, "\n")
+	lines := strings.Split(output, "\n")
+
+	if len(lines) > execMaxLines {
+		numHead := int(float64(execMaxLines) * execHeadShare)
+		numTail := execMaxLines - numHead
+
+		output = strings.Join(lines[:numHead], "\n") +
+			fmt.Sprintf("\n… %d lines truncated …\n", len(lines)-numHead-numTail) +
+			strings.Join(lines[len(lines)-numTail:], "\n")
+	}
+
+	// very long lines can still exceed the byte limit, so fall back to keeping the end
+	if len(output) > execMaxBytes {
+		output = "… truncated …\n" + output[len(output)-execMaxBytes:]
+	}
+
+	return output
+}
+
+func execContextName(command string) string {
+	return truncateName("$ "+command, 40)
+}
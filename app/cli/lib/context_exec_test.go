@@ -0,0 +1,42 @@
+//go:build !windows
+
+package lib
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunExecContextTimeoutKillsChildren(t *testing.T) {
+	start := time.Now()
+
+	// the background sleep inherits the output pipe, so the run only returns in time if it's killed along with the shell
+	res, err := runExecContextWithTimeout("sleep 30 & echo started; wait", 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > execWaitDelay {
+		t.Errorf("run took %s, expected it to return soon after the timeout", elapsed)
+	}
+
+	if !strings.Contains(res, "started") {
+		t.Errorf("expected output before the timeout to be kept, got %q", res)
+	}
+	if !strings.HasSuffix(res, "[timed out after 500ms]") {
+		t.Errorf("expected timeout status, got %q", res)
+	}
+}
+
+func TestRunExecContextExitStatus(t *testing.T) {
+	res, err := runExecContext("echo failing; exit 3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "$ echo failing; exit 3\nfailing\n[exit status 3]"
+	if res != expected {
+		t.Errorf("expected %q, got %q", expected, res)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package lib
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setExecProcessGroup starts the command in its own process group so everything it starts can be killed with it
+func setExecProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killExecProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,13 @@
+package lib
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+func setExecProcessGroup(cmd *exec.Cmd) {}
+
+// killExecProcessGroup kills the command along with everything it started
+func killExecProcessGroup(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// changed files bigger than this are listed without their content
+const maxGitChangedFileSize = 1024 * 1024
+
+func IsGitContextType(contextType shared.ContextType) bool {
+	return contextType == shared.ContextGitDiffType ||
+		contextType == shared.ContextGitCommitType ||
+		contextType == shared.ContextGitChangesType
+}
+
+// getGitContextBody runs the git command behind a git context. It's re-run by 'plandex update', so a context loaded from a branch or relative ref picks up changes when the ref moves. Changed files matching .plandexignore are left out unless forceSkipIgnore is set, the same as with 'plandex load'.
+func getGitContextBody(contextType shared.ContextType, ref string, forceSkipIgnore bool) (string, error) {
+	switch contextType {
+	case shared.ContextGitDiffType:
+		return GitDiff(ref)
+
+	case shared.ContextGitCommitType:
+		return GitShowCommit(ref)
+
+	case shared.ContextGitChangesType:
+		paths, err := GitChangedFilesSince(ref)
+		if err != nil {
+			return "", err
+		}
+
+		// untracked files are already filtered by .gitignore, and tracked files aren't ignored by git
+		var ignored *ignore.GitIgnore
+		if !forceSkipIgnore {
+			ignored, err = fs.GetPlandexIgnore(fs.ProjectRoot)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		var sb strings.Builder
+		for _, path := range paths {
+			if ignored != nil {
+				relPath, err := filepath.Rel(fs.ProjectRoot, filepath.Join(fs.Cwd, path))
+				if err == nil && ignored.MatchesPath(relPath) {
+					continue
+				}
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					fmt.Fprintf(&sb, "--- %s (deleted) ---\n\n", path)
+					continue
+				}
+				return "", fmt.Errorf("failed to read the file %s: %v", path, err)
+			}
+
+			if info.Size() > maxGitChangedFileSize {
+				fmt.Fprintf(&sb, "--- %s (too large to include) ---\n\n", path)
+				continue
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read the file %s: %v", path, err)
+			}
+
+			if shared.IsImagePath(path) || bytes.IndexByte(content, 0) != -1 {
+				fmt.Fprintf(&sb, "--- %s (binary) ---\n\n", path)
+				continue
+			}
+
+			fmt.Fprintf(&sb, "--- %s ---\n%s\n\n", path, content)
+		}
+
+		return sb.String(), nil
+	}
+
+	return "", fmt.Errorf("not a git context type: %s", contextType)
+}
+
+func gitContextName(contextType shared.ContextType, ref string) string {
+	switch contextType {
+	case shared.ContextGitDiffType:
+		return "diff " + ref
+	case shared.ContextGitCommitType:
+		summary, err := GitCommitSummary(ref)
+		if err == nil && summary != "" {
+			// show the subject, truncated like urls
+			return truncateName(summary, 40)
+		}
+		return ref
+	case shared.ContextGitChangesType:
+		return "changed since " + ref
+	}
+	return ref
+}
@@ -2,14 +2,19 @@ package lib
 
 import (
 	"bufio"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"plandex/api"
 	"plandex/fs"
+	"plandex/symbols"
 	"plandex/term"
 	"plandex/types"
 	"plandex/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -20,10 +25,7 @@ import (
 func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	term.StartSpinner("📥 Loading context...")
 
-	onErr := func(err error) {
-		term.StopSpinner()
-		term.OutputErrorAndExit("Failed to load context: %v", err)
-	}
+	onErr := onLoadContextErr
 
 	var loadContextReq shared.LoadContextRequest
 
@@ -52,44 +54,109 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		}
 	}
 
-	var inputUrls []string
-	var inputFilePaths []string
+	existsByComposite := mustGetExistingContextComposites()
+	alreadyLoadedByComposite := make(map[string]string)
+	ignoredPaths := make(map[string]string)
 
-	if len(resources) > 0 {
-		for _, resource := range resources {
-			// so far resources are either files or urls
-			if url.IsValidURL(resource) {
-				inputUrls = append(inputUrls, resource)
-			} else {
-				inputFilePaths = append(inputFilePaths, resource)
-			}
-		}
+	loadContextReq = append(loadContextReq, mustBuildLoadContextRequest(resources, params, existsByComposite, alreadyLoadedByComposite, ignoredPaths)...)
+
+	if params.WithDeps {
+		loadContextReq = append(loadContextReq, mustBuildDepsLoadContextRequest(loadContextReq, params, existsByComposite, ignoredPaths)...)
 	}
 
-	var contextMu sync.Mutex
+	for _, context := range loadContextReq {
+		context.Priority = params.Priority
+		context.Pinned = params.Pinned
+	}
 
-	errCh := make(chan error)
-	ignoredPaths := make(map[string]string)
+	mustSendLoadContextRequest(loadContextReq, alreadyLoadedByComposite, ignoredPaths)
+}
 
-	numRoutines := 0
+func onLoadContextErr(err error) {
+	term.StopSpinner()
+	term.OutputErrorAndExit("Failed to load context: %v", err)
+}
 
-	// filter out already loaded contexts
-	alreadyLoadedByComposite := make(map[string]string)
+// mustGetExistingContextComposites returns keys for the plan's current context so inputs that are already loaded can be skipped
+func mustGetExistingContextComposites() map[string]bool {
 	existingContexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
 	if apiErr != nil {
-		onErr(fmt.Errorf("failed to list contexts: %v", apiErr.Msg))
+		onLoadContextErr(fmt.Errorf("failed to list contexts: %v", apiErr.Msg))
 	}
 
 	existsByComposite := make(map[string]bool)
 	for _, context := range existingContexts {
 		switch context.ContextType {
-		case shared.ContextFileType, shared.ContextDirectoryTreeType:
+		case shared.ContextFileType, shared.ContextDirectoryTreeType, shared.ContextImageType, shared.ContextRepoMapType:
+			if context.StartLine > 0 {
+				// line ranges are keyed by name so they don't collide with the whole file
+				existsByComposite[strings.Join([]string{string(context.ContextType), context.Name}, "|")] = true
+				continue
+			}
 			existsByComposite[strings.Join([]string{string(context.ContextType), context.FilePath}, "|")] = true
 		case shared.ContextURLType:
 			existsByComposite[strings.Join([]string{string(context.ContextType), context.Url}, "|")] = true
+		case shared.ContextSymbolType:
+			existsByComposite[strings.Join([]string{string(context.ContextType), symbols.RefName(context.FilePath, context.Symbol)}, "|")] = true
+		case shared.ContextGitDiffType, shared.ContextGitCommitType, shared.ContextGitChangesType:
+			existsByComposite[strings.Join([]string{string(context.ContextType), context.GitRef}, "|")] = true
+		case shared.ContextExecType:
+			existsByComposite[strings.Join([]string{string(context.ContextType), context.Command}, "|")] = true
+		case shared.ContextNoteType, shared.ContextPipedDataType:
+			existsByComposite[strings.Join([]string{string(context.ContextType), context.Sha}, "|")] = true
 		}
 	}
 
+	return existsByComposite
+}
+
+// mustBuildLoadContextRequest reads files, trees, urls, symbols, line ranges, git refs, and commands into load params. Inputs that are already in context are recorded in alreadyLoadedByComposite and paths skipped due to ignore files in ignoredPaths.
+func mustBuildLoadContextRequest(resources []string, params *types.LoadContextParams, existsByComposite map[string]bool, alreadyLoadedByComposite, ignoredPaths map[string]string) shared.LoadContextRequest {
+	onErr := onLoadContextErr
+
+	var loadContextReq shared.LoadContextRequest
+
+	var gitRefsByType = map[shared.ContextType]string{}
+	if params.GitDiff != "" {
+		gitRefsByType[shared.ContextGitDiffType] = params.GitDiff
+	}
+	if params.GitCommit != "" {
+		gitRefsByType[shared.ContextGitCommitType] = params.GitCommit
+	}
+	if params.GitChangedSince != "" {
+		gitRefsByType[shared.ContextGitChangesType] = params.GitChangedSince
+	}
+
+	if len(gitRefsByType) > 0 && !fs.ProjectRootIsGitRepo() {
+		onErr(fmt.Errorf("git contexts can only be loaded in a git repository"))
+	}
+
+	var inputUrls []string
+	var inputFilePaths []string
+	var inputSymbolRefs [][2]string
+	var inputLineRanges []lineRangeRef
+
+	if len(resources) > 0 {
+		for _, resource := range resources {
+			// resources are urls, line ranges ('path:120-260'), symbols ('path:Symbol' or 'path#Symbol'), or files
+			if url.IsValidURL(resource) {
+				inputUrls = append(inputUrls, resource)
+			} else if ref, ok := parseLineRangeRef(resource); ok {
+				inputLineRanges = append(inputLineRanges, ref)
+			} else if path, name, ok := symbols.ParseRef(resource); ok {
+				inputSymbolRefs = append(inputSymbolRefs, [2]string{path, name})
+			} else {
+				inputFilePaths = append(inputFilePaths, resource)
+			}
+		}
+	}
+
+	var contextMu sync.Mutex
+
+	errCh := make(chan error)
+
+	numRoutines := 0
+
 	if len(inputFilePaths) > 0 {
 		baseDir := fs.GetBaseDirForFilePaths(inputFilePaths)
 
@@ -129,7 +196,52 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			inputFilePaths = filteredPaths
 		}
 
-		if params.NamesOnly {
+		if params.RepoMap {
+			for _, inputFilePath := range inputFilePaths {
+				composite := strings.Join([]string{string(shared.ContextRepoMapType), inputFilePath}, "|")
+				if existsByComposite[composite] {
+					alreadyLoadedByComposite[composite] = inputFilePath
+					continue
+				}
+
+				numRoutines++
+				go func(inputFilePath string) {
+					mapPaths := paths
+					if params.ForceSkipIgnore {
+						mapPaths = nil
+					}
+
+					body, err := buildRepoMap(inputFilePath, mapPaths, params.MapTokens)
+					if err != nil {
+						errCh <- fmt.Errorf("failed to build the repo map for %s: %v", inputFilePath, err)
+						return
+					}
+
+					contextMu.Lock()
+					defer contextMu.Unlock()
+
+					if body == "" {
+						term.StopSpinner()
+						fmt.Printf("🤷‍♂️ No exported functions, types, or methods found in %s\n", inputFilePath)
+						term.ResumeSpinner()
+						errCh <- nil
+						return
+					}
+
+					loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+						ContextType:     shared.ContextRepoMapType,
+						Name:            repoMapName(inputFilePath),
+						Body:            body,
+						FilePath:        inputFilePath,
+						MapTokens:       params.MapTokens,
+						ForceSkipIgnore: params.ForceSkipIgnore,
+					})
+
+					errCh <- nil
+				}(inputFilePath)
+			}
+
+		} else if params.NamesOnly {
 			for _, inputFilePath := range inputFilePaths {
 				composite := strings.Join([]string{string(shared.ContextDirectoryTreeType), inputFilePath}, "|")
 				if existsByComposite[composite] {
@@ -206,7 +318,12 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 			inputFilePaths = flattenedPaths
 
 			for _, path := range flattenedPaths {
-				composite := strings.Join([]string{string(shared.ContextFileType), path}, "|")
+				contextType := shared.ContextFileType
+				if shared.IsImagePath(path) {
+					contextType = shared.ContextImageType
+				}
+
+				composite := strings.Join([]string{string(contextType), path}, "|")
 
 				if existsByComposite[composite] {
 					alreadyLoadedByComposite[composite] = path
@@ -220,17 +337,34 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 						errCh <- fmt.Errorf("failed to read the file %s: %v", path, err)
 						return
 					}
-					body := string(fileContent)
 
-					contextMu.Lock()
-					defer contextMu.Unlock()
+					var body string
+					if contextType == shared.ContextImageType {
+						body = base64.StdEncoding.EncodeToString(fileContent)
+					} else {
+						body = string(fileContent)
+					}
 
-					loadContextReq = append(loadContextReq, &shared.LoadContextParams{
-						ContextType: shared.ContextFileType,
+					loadParams := &shared.LoadContextParams{
+						ContextType: contextType,
 						Name:        path,
 						Body:        body,
 						FilePath:    path,
-					})
+					}
+
+					// files that wouldn't get any smaller are loaded in full
+					if params.Outline && contextType == shared.ContextFileType {
+						if outline, ok := shared.GetOutline(path, body); ok {
+							loadParams.Body = outline
+							loadParams.FileBody = body
+							loadParams.Outline = true
+						}
+					}
+
+					contextMu.Lock()
+					defer contextMu.Unlock()
+
+					loadContextReq = append(loadContextReq, loadParams)
 
 					errCh <- nil
 				}(path)
@@ -238,9 +372,206 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		}
 	}
 
+	for contextType, ref := range gitRefsByType {
+		composite := strings.Join([]string{string(contextType), ref}, "|")
+		name := gitContextName(contextType, ref)
+
+		if existsByComposite[composite] {
+			alreadyLoadedByComposite[composite] = name
+			continue
+		}
+
+		_, err := GitResolveCommit(ref)
+		if err != nil {
+			onErr(err)
+		}
+
+		body, err := getGitContextBody(contextType, ref, params.ForceSkipIgnore)
+		if err != nil {
+			onErr(fmt.Errorf("failed to load %s: %v", name, err))
+		}
+
+		if strings.TrimSpace(body) == "" {
+			term.StopSpinner()
+			fmt.Printf("🤷‍♂️ No changes for %s\n", name)
+			term.ResumeSpinner()
+			continue
+		}
+
+		contextMu.Lock()
+		loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+			ContextType:     contextType,
+			Name:            name,
+			GitRef:          ref,
+			Body:            body,
+			ForceSkipIgnore: params.ForceSkipIgnore,
+		})
+		contextMu.Unlock()
+	}
+
+	for _, command := range params.ExecCommands {
+		composite := strings.Join([]string{string(shared.ContextExecType), command}, "|")
+		name := execContextName(command)
+
+		if existsByComposite[composite] {
+			alreadyLoadedByComposite[composite] = name
+			continue
+		}
+
+		numRoutines++
+		go func(command, name string) {
+			body, err := runExecContext(command)
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			contextMu.Lock()
+			defer contextMu.Unlock()
+
+			loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+				ContextType: shared.ContextExecType,
+				Name:        name,
+				Command:     command,
+				Body:        body,
+			})
+
+			errCh <- nil
+		}(command, name)
+	}
+
+	// line ranges are read up front since the name depends on the number of lines in the file
+	for _, ref := range inputLineRanges {
+		fileContent, err := os.ReadFile(ref.path)
+		if err != nil {
+			onErr(fmt.Errorf("failed to read the file %s: %v", ref.path, err))
+		}
+
+		numLines := strings.Count(string(fileContent), "\n") + 1
+		if ref.startLine > numLines {
+			onErr(fmt.Errorf("%s has %d lines, so line %d is out of range", ref.path, numLines, ref.startLine))
+		}
+		endLine := ref.endLine
+		if endLine > numLines {
+			endLine = numLines
+		}
+
+		name := lineRangeName(ref.path, ref.startLine, endLine)
+
+		composite := strings.Join([]string{string(shared.ContextFileType), name}, "|")
+		if existsByComposite[composite] {
+			alreadyLoadedByComposite[composite] = name
+			continue
+		}
+
+		body, _ := shared.GetLineRange(string(fileContent), ref.startLine, endLine)
+
+		contextMu.Lock()
+		loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+			ContextType: shared.ContextFileType,
+			Name:        name,
+			FilePath:    ref.path,
+			StartLine:   ref.startLine,
+			EndLine:     endLine,
+			Body:        body,
+			FileBody:    string(fileContent),
+		})
+		contextMu.Unlock()
+	}
+
+	for _, ref := range inputSymbolRefs {
+		path, name := ref[0], ref[1]
+		refName := symbols.RefName(path, name)
+
+		composite := strings.Join([]string{string(shared.ContextSymbolType), refName}, "|")
+		if existsByComposite[composite] {
+			alreadyLoadedByComposite[composite] = refName
+			continue
+		}
+
+		numRoutines++
+		go func(path, name, refName string) {
+			fileContent, err := os.ReadFile(path)
+			if err != nil {
+				errCh <- fmt.Errorf("failed to read the file %s: %v", path, err)
+				return
+			}
+
+			symbol, err := symbols.Extract(path, fileContent, name)
+			if err != nil {
+				errCh <- fmt.Errorf("failed to find symbol %s: %v", refName, err)
+				return
+			}
+			if symbol == nil {
+				errCh <- fmt.Errorf("symbol %s not found in %s", name, path)
+				return
+			}
+
+			contextMu.Lock()
+			defer contextMu.Unlock()
+
+			loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+				ContextType: shared.ContextSymbolType,
+				Name:        refName,
+				FilePath:    path,
+				Symbol:      name,
+				Body:        symbol.Body,
+				FileBody:    string(fileContent),
+			})
+
+			errCh <- nil
+		}(path, name, refName)
+	}
+
 	if len(inputUrls) > 0 {
 		for _, u := range inputUrls {
 			composite := strings.Join([]string{string(shared.ContextURLType), u}, "|")
+
+			if params.CrawlDepth > 0 {
+				numRoutines++
+				go func(u string) {
+					res, err := url.Crawl(u, url.CrawlParams{Depth: params.CrawlDepth, SamePrefix: params.SamePrefix})
+					if err != nil {
+						errCh <- fmt.Errorf("failed to crawl URL %s: %v", u, err)
+						return
+					}
+
+					contextMu.Lock()
+					defer contextMu.Unlock()
+
+					for _, page := range res.Pages {
+						composite := strings.Join([]string{string(shared.ContextURLType), page.Url}, "|")
+						if existsByComposite[composite] {
+							alreadyLoadedByComposite[composite] = page.Url
+							continue
+						}
+
+						loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+							ContextType: shared.ContextURLType,
+							Name:        urlContextName(page.Url),
+							Body:        page.Body,
+							Url:         page.Url,
+						})
+					}
+
+					if res.Truncated || res.NumFailed > 0 {
+						term.StopSpinner()
+						fmt.Printf("🕸️  Crawled %d pages from %s", len(res.Pages), u)
+						if res.Truncated {
+							fmt.Printf(" | stopped at the %d page limit", url.MaxCrawlPages)
+						}
+						if res.NumFailed > 0 {
+							fmt.Printf(" | %d linked pages failed to load", res.NumFailed)
+						}
+						fmt.Println()
+						term.ResumeSpinner()
+					}
+
+					errCh <- nil
+				}(u)
+				continue
+			}
+
 			if existsByComposite[composite] {
 				alreadyLoadedByComposite[composite] = u
 				continue
@@ -254,18 +585,12 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 					return
 				}
 
-				name := url.SanitizeURL(u)
-				// show the first 20 characters, then ellipsis then the last 20 characters of 'name'
-				if len(name) > 40 {
-					name = name[:20] + "⋯" + name[len(name)-20:]
-				}
-
 				contextMu.Lock()
 				defer contextMu.Unlock()
 
 				loadContextReq = append(loadContextReq, &shared.LoadContextParams{
 					ContextType: shared.ContextURLType,
-					Name:        name,
+					Name:        urlContextName(u),
 					Body:        body,
 					Url:         u,
 				})
@@ -282,9 +607,20 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		}
 	}
 
+	return loadContextReq
+}
+
+// mustSendLoadContextRequest loads the request into the plan, building first if it conflicts with pending changes, and prints the result
+func mustSendLoadContextRequest(loadContextReq shared.LoadContextRequest, alreadyLoadedByComposite, ignoredPaths map[string]string) {
+	onErr := onLoadContextErr
+
+	loadContextReq, redactionResults := mustRedactLoadContextRequest(loadContextReq)
+
 	filesToLoad := map[string]string{}
 	for _, context := range loadContextReq {
-		if context.ContextType == shared.ContextFileType {
+		if context.FileBody != "" {
+			filesToLoad[context.FilePath] = context.FileBody
+		} else if context.ContextType == shared.ContextFileType {
 			filesToLoad[context.FilePath] = context.Body
 		}
 	}
@@ -298,6 +634,9 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	if len(loadContextReq) == 0 {
 		term.StopSpinner()
 		fmt.Println("🤷‍♂️ No context loaded")
+		if len(redactionResults) > 0 {
+			printRedactionSummary(redactionResults)
+		}
 		if len(alreadyLoadedByComposite) > 0 {
 			printAlreadyLoadedMsg(alreadyLoadedByComposite)
 		}
@@ -307,6 +646,8 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 		os.Exit(0)
 	}
 
+	omitStoredLoadContextBodies(loadContextReq)
+
 	res, apiErr := api.Client.LoadContext(CurrentPlanId, CurrentBranch, loadContextReq)
 
 	if apiErr != nil {
@@ -316,8 +657,8 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 	term.StopSpinner()
 
 	if res.MaxTokensExceeded {
-		overage := res.TotalTokens - res.MaxTokens
-		term.OutputErrorAndExit("Update would add %d 🪙 and exceed token limit (%d) by %d 🪙\n", res.TokensAdded, res.MaxTokens, overage)
+		overage := res.PinnedTokens - res.MaxTokens
+		term.OutputErrorAndExit("Pinned context would take %d 🪙 and exceed token limit (%d) by %d 🪙. Unpin some context so it can be trimmed to fit.\n", res.PinnedTokens, res.MaxTokens, overage)
 	}
 
 	if hasConflicts {
@@ -333,6 +674,10 @@ func MustLoadContext(resources []string, params *types.LoadContextParams) {
 
 	fmt.Println("✅ " + res.Msg)
 
+	if len(redactionResults) > 0 {
+		printRedactionSummary(redactionResults)
+	}
+
 	if len(alreadyLoadedByComposite) > 0 {
 		printAlreadyLoadedMsg(alreadyLoadedByComposite)
 	}
@@ -363,3 +708,54 @@ func printIgnoredMsg() {
 	fmt.Println()
 	fmt.Println("ℹ️  " + color.New(color.FgWhite).Sprint("Due to .gitignore or .plandexignore, some paths weren't loaded.\nUse --force / -f to load ignored paths."))
 }
+
+type lineRangeRef struct {
+	path      string
+	startLine int
+	endLine   int
+}
+
+var lineRangeRegex = regexp.MustCompile(`^(.+):(\d+)-(\d+)$`)
+
+// parseLineRangeRef parses a 'path:start-end' reference. ok is false unless path is an existing file and the range is valid.
+func parseLineRangeRef(resource string) (lineRangeRef, bool) {
+	matches := lineRangeRegex.FindStringSubmatch(resource)
+	if matches == nil {
+		return lineRangeRef{}, false
+	}
+
+	startLine, _ := strconv.Atoi(matches[2])
+	endLine, _ := strconv.Atoi(matches[3])
+	if startLine < 1 || endLine < startLine {
+		return lineRangeRef{}, false
+	}
+
+	info, err := os.Stat(matches[1])
+	if err != nil || info.IsDir() {
+		return lineRangeRef{}, false
+	}
+
+	return lineRangeRef{path: filepath.Clean(matches[1]), startLine: startLine, endLine: endLine}, true
+}
+
+func urlContextName(u string) string {
+	name := url.SanitizeURL(u)
+	// show the first 20 characters, then ellipsis then the last 20 characters of 'name'
+	if len(name) > 40 {
+		name = name[:20] + "⋯" + name[len(name)-20:]
+	}
+	return name
+}
+
+// truncateName shortens a context name to max characters. It counts runes so that multi-byte characters aren't split.
+func truncateName(name string, max int) string {
+	runes := []rune(name)
+	if len(runes) <= max {
+		return name
+	}
+	return string(runes[:max-1]) + "⋯"
+}
+
+func lineRangeName(path string, startLine, endLine int) string {
+	return fmt.Sprintf("%s:%d-%d", path, startLine, endLine)
+}
@@ -0,0 +1,32 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLineRangeRef(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, ok := parseLineRangeRef(path + ":10-20")
+	if !ok || ref.path != path || ref.startLine != 10 || ref.endLine != 20 {
+		t.Errorf("got %+v, %v, want %s lines 10-20", ref, ok, path)
+	}
+
+	for _, resource := range []string{
+		path,                           // no range
+		path + ":20-10",                // backwards
+		path + ":0-5",                  // lines start at 1
+		filepath.Join(dir, "x.go:1-2"), // missing file
+		dir + ":1-2",                   // directory
+	} {
+		if ref, ok := parseLineRangeRef(resource); ok {
+			t.Errorf("%s: got %+v, want no match", resource, ref)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// MatchContextIds finds the contexts referred to by args, which can be numbers from the 'plandex ls' list, names, paths, urls, globs, or parent directories
+func MatchContextIds(contexts []*shared.Context, args []string) (map[string]bool, error) {
+	ids := map[string]bool{}
+
+	for i, context := range contexts {
+		for _, id := range args {
+			if fmt.Sprintf("%d", i+1) == id || context.Name == id || context.FilePath == id || context.Url == id {
+				ids[context.Id] = true
+				break
+			} else if context.FilePath != "" {
+				// Check if id is a glob pattern
+				matched, err := filepath.Match(id, context.FilePath)
+				if err != nil {
+					return nil, fmt.Errorf("error matching glob pattern: %v", err)
+				}
+				if matched {
+					ids[context.Id] = true
+					break
+				}
+
+				// Check if id is a parent directory
+				parentDir := context.FilePath
+				for parentDir != "." && parentDir != "/" && parentDir != "" {
+					if parentDir == id {
+						ids[context.Id] = true
+						break
+					}
+					parentDir = filepath.Dir(parentDir) // Move up one directory
+				}
+
+			}
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,188 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"plandex/api"
+	"plandex/auth"
+	"plandex/symbols"
+	"plandex/term"
+	"plandex/types"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// refs that load with the same options are read together so project paths are only walked once per group
+type contextSetGroup struct {
+	forceSkipIgnore bool
+	namesOnly       bool
+	outline         bool
+	repoMap         bool
+	mapTokens       int
+	priority        shared.ContextPriority
+	pinned          bool
+}
+
+// MustLoadContextSet loads a context set saved for the current project, re-reading files, trees, urls, git refs, and commands from their sources
+func MustLoadContextSet(name string) {
+	term.StartSpinner("📥 Loading context set...")
+
+	set, apiErr := api.Client.GetContextSet(CurrentProjectId, name)
+	if apiErr != nil {
+		onLoadContextErr(fmt.Errorf("failed to get context set %s: %v", name, apiErr.Msg))
+	}
+
+	existsByComposite := mustGetExistingContextComposites()
+	alreadyLoadedByComposite := make(map[string]string)
+	ignoredPaths := make(map[string]string)
+
+	var loadContextReq shared.LoadContextRequest
+	var missing []string
+	var skippedCommands []string
+
+	var groups []contextSetGroup
+	seenGroups := map[contextSetGroup]bool{}
+	resourcesByGroup := map[contextSetGroup][]string{}
+	commandsByGroup := map[contextSetGroup][]string{}
+
+	addGroup := func(group contextSetGroup) {
+		if !seenGroups[group] {
+			seenGroups[group] = true
+			groups = append(groups, group)
+		}
+	}
+
+	for _, ref := range set.Refs {
+		group := contextSetGroup{
+			forceSkipIgnore: ref.ForceSkipIgnore,
+			priority:        ref.Priority,
+			pinned:          ref.Pinned,
+		}
+
+		switch ref.ContextType {
+		case shared.ContextNoteType, shared.ContextPipedDataType:
+			hash := sha256.Sum256([]byte(ref.Body))
+			composite := strings.Join([]string{string(ref.ContextType), hex.EncodeToString(hash[:])}, "|")
+			if existsByComposite[composite] {
+				alreadyLoadedByComposite[composite] = ref.Label()
+				continue
+			}
+
+			loadContextReq = append(loadContextReq, &shared.LoadContextParams{
+				ContextType: ref.ContextType,
+				Name:        ref.Name,
+				Body:        ref.Body,
+				Priority:    ref.Priority,
+				Pinned:      ref.Pinned,
+			})
+
+		case shared.ContextURLType:
+			addGroup(group)
+			resourcesByGroup[group] = append(resourcesByGroup[group], ref.Url)
+
+		case shared.ContextGitDiffType, shared.ContextGitCommitType, shared.ContextGitChangesType:
+			if _, err := GitResolveCommit(ref.GitRef); err != nil {
+				missing = append(missing, ref.Label())
+				continue
+			}
+
+			// only one ref of each git type can be loaded per call
+			params := &types.LoadContextParams{Priority: ref.Priority, Pinned: ref.Pinned}
+			switch ref.ContextType {
+			case shared.ContextGitDiffType:
+				params.GitDiff = ref.GitRef
+			case shared.ContextGitCommitType:
+				params.GitCommit = ref.GitRef
+			case shared.ContextGitChangesType:
+				params.GitChangedSince = ref.GitRef
+			}
+			loadContextReq = append(loadContextReq, buildContextSetGroup(nil, params, existsByComposite, alreadyLoadedByComposite, ignoredPaths)...)
+
+		case shared.ContextExecType:
+			// as with 'plandex update', commands only run for the user who loaded them, so loading a shared set can't run someone else's commands, even if it was saved by the current user
+			if auth.Current == nil || ref.OwnerId != auth.Current.UserId {
+				skippedCommands = append(skippedCommands, ref.Command)
+				continue
+			}
+			addGroup(group)
+			commandsByGroup[group] = append(commandsByGroup[group], ref.Command)
+
+		default:
+			if _, err := os.Stat(ref.FilePath); err != nil {
+				missing = append(missing, ref.Label())
+				continue
+			}
+
+			var resource string
+			switch {
+			case ref.ContextType == shared.ContextDirectoryTreeType:
+				group.namesOnly = true
+				resource = ref.FilePath
+			case ref.ContextType == shared.ContextRepoMapType:
+				group.repoMap = true
+				group.mapTokens = ref.MapTokens
+				resource = ref.FilePath
+			case ref.ContextType == shared.ContextSymbolType:
+				resource = symbols.RefName(ref.FilePath, ref.Symbol)
+			case ref.StartLine > 0:
+				resource = lineRangeName(ref.FilePath, ref.StartLine, ref.EndLine)
+			default:
+				group.outline = ref.Outline
+				resource = ref.FilePath
+			}
+
+			addGroup(group)
+			resourcesByGroup[group] = append(resourcesByGroup[group], resource)
+		}
+	}
+
+	for _, group := range groups {
+		params := &types.LoadContextParams{
+			NamesOnly:       group.namesOnly,
+			Outline:         group.outline,
+			RepoMap:         group.repoMap,
+			MapTokens:       group.mapTokens,
+			ForceSkipIgnore: group.forceSkipIgnore,
+			ExecCommands:    commandsByGroup[group],
+			Priority:        group.priority,
+			Pinned:          group.pinned,
+		}
+		loadContextReq = append(loadContextReq, buildContextSetGroup(resourcesByGroup[group], params, existsByComposite, alreadyLoadedByComposite, ignoredPaths)...)
+	}
+
+	if len(missing) > 0 || len(skippedCommands) > 0 {
+		term.StopSpinner()
+		if len(missing) > 0 {
+			fmt.Printf("🤷‍♂️ Skipped because they no longer exist:\n")
+			for _, name := range missing {
+				fmt.Printf("  • %s\n", name)
+			}
+		}
+		if len(skippedCommands) > 0 {
+			fmt.Printf("🙅‍♂️ Skipped commands loaded by another user:\n")
+			for _, command := range skippedCommands {
+				fmt.Printf("  • %s\n", command)
+			}
+			fmt.Println("ℹ️  " + color.New(color.FgWhite).Sprint("Load them with --exec if you trust them."))
+		}
+		fmt.Println()
+		term.ResumeSpinner()
+	}
+
+	mustSendLoadContextRequest(loadContextReq, alreadyLoadedByComposite, ignoredPaths)
+}
+
+func buildContextSetGroup(resources []string, params *types.LoadContextParams, existsByComposite map[string]bool, alreadyLoadedByComposite, ignoredPaths map[string]string) shared.LoadContextRequest {
+	req := mustBuildLoadContextRequest(resources, params, existsByComposite, alreadyLoadedByComposite, ignoredPaths)
+
+	for _, context := range req {
+		context.Priority = params.Priority
+		context.Pinned = params.Pinned
+	}
+
+	return req
+}
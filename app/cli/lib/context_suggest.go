@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/search"
+	"plandex/term"
+	"plandex/types"
+	"sort"
+	"sync"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const maxSuggestions = 25
+
+// MustSuggestContext ranks project files by relevance to the prompt with a local keyword index, then lets the user pick which ones to load.
+func MustSuggestContext(prompt string, params *types.LoadContextParams) {
+	term.StartSpinner("🔎 Indexing project files...")
+
+	onErr := func(err error) {
+		term.StopSpinner()
+		term.OutputErrorAndExit("Failed to suggest context: %v", err)
+	}
+
+	paths, err := fs.GetProjectPaths(fs.ProjectRoot)
+	if err != nil {
+		onErr(fmt.Errorf("failed to get project paths: %v", err))
+	}
+
+	var activePaths []string
+	for path := range paths.ActivePaths {
+		activePaths = append(activePaths, path)
+	}
+	sort.Strings(activePaths)
+
+	idx, err := search.LoadIndex(filepath.Join(HomeCurrentProjectDir, "search_index.json"))
+	if err != nil {
+		onErr(err)
+	}
+
+	idx.Update(activePaths)
+
+	err = idx.Save()
+	if err != nil {
+		onErr(err)
+	}
+
+	existingContexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		onErr(fmt.Errorf("failed to list contexts: %v", apiErr.Msg))
+	}
+
+	loaded := map[string]bool{}
+	for _, context := range existingContexts {
+		if context.FilePath != "" {
+			loaded[context.FilePath] = true
+		}
+	}
+
+	var results []search.Result
+	for _, res := range idx.Search(prompt, 0) {
+		if loaded[res.Path] {
+			continue
+		}
+		results = append(results, res)
+		if len(results) == maxSuggestions {
+			break
+		}
+	}
+
+	if len(results) == 0 {
+		term.StopSpinner()
+		fmt.Println("🤷‍♂️ No relevant files found")
+		os.Exit(0)
+	}
+
+	numTokens := getSuggestionTokens(results)
+
+	term.StopSpinner()
+
+	opts := make([]string, len(results))
+	pathsByOpt := make(map[string]string, len(results))
+	for i, res := range results {
+		opt := fmt.Sprintf("%s | score %.2f | %d 🪙", res.Path, res.Score, numTokens[res.Path])
+		opts[i] = opt
+		pathsByOpt[opt] = res.Path
+	}
+
+	selected, err := term.SelectManyFromList("Select files to load:", opts)
+	if err != nil {
+		term.OutputErrorAndExit("Error selecting files: %v", err)
+	}
+
+	if len(selected) == 0 {
+		fmt.Println("🤷‍♂️ No files selected")
+		os.Exit(0)
+	}
+
+	var toLoad []string
+	for _, opt := range selected {
+		toLoad = append(toLoad, pathsByOpt[opt])
+	}
+
+	MustLoadContext(toLoad, params)
+}
+
+// token counts are only computed for suggested files rather than stored in the index, since counting every file in a large project is slow
+func getSuggestionTokens(results []search.Result) map[string]int {
+	numTokens := make(map[string]int, len(results))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, res := range results {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return
+			}
+
+			var n int
+			if shared.IsImagePath(path) {
+				n, err = shared.GetImageNumTokens(content)
+			} else {
+				n, err = shared.GetNumTokens(string(content))
+			}
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			numTokens[path] = n
+		}(res.Path)
+	}
+
+	wg.Wait()
+
+	return numTokens
+}
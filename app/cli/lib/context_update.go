@@ -2,12 +2,15 @@ package lib
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"os"
 	"plandex/api"
+	"plandex/auth"
 	"plandex/fs"
+	"plandex/symbols"
 	"plandex/term"
 	"plandex/types"
 	"plandex/url"
@@ -65,6 +68,22 @@ func MustCheckOutdatedContext(quiet bool, maybeContexts []*shared.Context) (cont
 			lbl = strconv.Itoa(outdatedRes.NumTrees) + " " + lbl
 			types = append(types, lbl)
 		}
+		if outdatedRes.NumCommands > 0 {
+			lbl := "command output"
+			if outdatedRes.NumCommands > 1 {
+				lbl = "command outputs"
+			}
+			lbl = strconv.Itoa(outdatedRes.NumCommands) + " " + lbl
+			types = append(types, lbl)
+		}
+		if outdatedRes.NumGit > 0 {
+			lbl := "git context"
+			if outdatedRes.NumGit > 1 {
+				lbl = "git contexts"
+			}
+			lbl = strconv.Itoa(outdatedRes.NumGit) + " " + lbl
+			types = append(types, lbl)
+		}
 
 		var msg string
 		if len(types) <= 2 {
@@ -142,7 +161,7 @@ func MustCheckOutdatedContext(quiet bool, maybeContexts []*shared.Context) (cont
 	}
 
 	if confirmed {
-		MustUpdateContext(maybeContexts)
+		MustUpdateContext(maybeContexts, false)
 		return true, true
 	} else {
 		return true, false
@@ -150,10 +169,17 @@ func MustCheckOutdatedContext(quiet bool, maybeContexts []*shared.Context) (cont
 
 }
 
-func MustUpdateContext(maybeUpdateContexts []*shared.Context) {
+// MustUpdateContext updates outdated context. Commands are only re-run if rerunCommands is set, which should only come from an explicit 'plandex update'.
+func MustUpdateContext(maybeUpdateContexts []*shared.Context, rerunCommands bool) {
 	term.StartSpinner("🔄 Updating context...")
 
-	updateRes, err := UpdateContext(maybeUpdateContexts)
+	var updateRes *types.ContextOutdatedResult
+	var err error
+	if rerunCommands {
+		updateRes, err = UpdateContextAndRerunCommands(maybeUpdateContexts)
+	} else {
+		updateRes, err = UpdateContext(maybeUpdateContexts)
+	}
 
 	if err != nil {
 		term.StopSpinner()
@@ -167,14 +193,19 @@ func MustUpdateContext(maybeUpdateContexts []*shared.Context) {
 }
 
 func UpdateContext(maybeContexts []*shared.Context) (*types.ContextOutdatedResult, error) {
-	return checkOutdatedAndMaybeUpdateContext(true, maybeContexts)
+	return checkOutdatedAndMaybeUpdateContext(true, false, maybeContexts)
+}
+
+func UpdateContextAndRerunCommands(maybeContexts []*shared.Context) (*types.ContextOutdatedResult, error) {
+	return checkOutdatedAndMaybeUpdateContext(true, true, maybeContexts)
 }
 
 func CheckOutdatedContext(maybeContexts []*shared.Context) (*types.ContextOutdatedResult, error) {
-	return checkOutdatedAndMaybeUpdateContext(false, maybeContexts)
+	return checkOutdatedAndMaybeUpdateContext(false, false, maybeContexts)
 }
 
-func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.Context) (*types.ContextOutdatedResult, error) {
+// commands can be slow or have side effects, so they're only re-run when rerunCommands is set, never just to check whether context is outdated
+func checkOutdatedAndMaybeUpdateContext(doUpdate, rerunCommands bool, maybeContexts []*shared.Context) (*types.ContextOutdatedResult, error) {
 	var contexts []*shared.Context
 
 	log.Println("Checking outdated context")
@@ -197,6 +228,8 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 	var numFiles int
 	var numUrls int
 	var numTrees int
+	var numGit int
+	var numCommands int
 	var numFilesRemoved int
 	var numTreesRemoved int
 	var mu sync.Mutex
@@ -208,7 +241,7 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 	var hasDirectoryTreeWithIgnoredPaths bool
 
 	for _, context := range contexts {
-		if context.ContextType == shared.ContextDirectoryTreeType && !context.ForceSkipIgnore {
+		if (context.ContextType == shared.ContextDirectoryTreeType || context.ContextType == shared.ContextRepoMapType) && !context.ForceSkipIgnore {
 			hasDirectoryTreeWithIgnoredPaths = true
 			break
 		}
@@ -248,6 +281,76 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 					return
 				}
 
+				// if secrets were masked when the file was loaded, they're masked before comparing
+				redacted, err := redactUpdatedContextBody(context, string(fileContent))
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				fileContent = []byte(redacted)
+
+				if context.StartLine > 0 {
+					// for a line range, only changes within the range make it outdated
+					body, ok := shared.GetLineRange(string(fileContent), context.StartLine, context.EndLine)
+					if !ok {
+						deleteIds[context.Id] = true
+						numFilesRemoved++
+						tokenDiffsById[context.Id] = -context.NumTokens
+						return
+					}
+
+					hash := sha256.Sum256([]byte(body))
+					sha := hex.EncodeToString(hash[:])
+
+					if sha != context.Sha {
+						numTokens, err := shared.GetNumTokens(body)
+						if err != nil {
+							errs = append(errs, fmt.Errorf("failed to get the number of tokens in %s: %v", context.Name, err))
+							return
+						}
+						tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+						numFiles++
+						updatedContexts = append(updatedContexts, context)
+
+						req[context.Id] = &shared.UpdateContextParams{
+							Body:     body,
+							FileBody: string(fileContent),
+						}
+					}
+					return
+				}
+
+				if context.Outline {
+					// as with line ranges, only changes to the outline make it outdated
+					body, ok := shared.GetOutline(context.FilePath, string(fileContent))
+					if !ok {
+						// the file no longer gets any smaller when outlined
+						body = string(fileContent)
+					}
+
+					hash := sha256.Sum256([]byte(body))
+					sha := hex.EncodeToString(hash[:])
+
+					if sha != context.Sha {
+						numTokens, err := shared.GetNumTokens(body)
+						if err != nil {
+							errs = append(errs, fmt.Errorf("failed to get the number of tokens in %s: %v", context.Name, err))
+							return
+						}
+						tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+						numFiles++
+						updatedContexts = append(updatedContexts, context)
+
+						req[context.Id] = &shared.UpdateContextParams{
+							Body:     body,
+							FileBody: string(fileContent),
+						}
+					}
+					return
+				}
+
 				hash := sha256.Sum256(fileContent)
 				sha := hex.EncodeToString(hash[:])
 
@@ -270,6 +373,113 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 				}
 			}(context)
 
+		} else if context.ContextType == shared.ContextImageType {
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if _, err := os.Stat(context.FilePath); os.IsNotExist(err) {
+					deleteIds[context.Id] = true
+					numFilesRemoved++
+					tokenDiffsById[context.Id] = -context.NumTokens
+					return
+				}
+
+				imageContent, err := os.ReadFile(context.FilePath)
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to read the image %s: %v", context.FilePath, err))
+					return
+				}
+
+				hash := sha256.Sum256(imageContent)
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					numTokens, err := shared.GetImageNumTokens(imageContent)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in the image %s: %v", context.FilePath, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numFiles++
+					updatedContexts = append(updatedContexts, context)
+
+					req[context.Id] = &shared.UpdateContextParams{
+						Body: base64.StdEncoding.EncodeToString(imageContent),
+					}
+				}
+			}(context)
+
+		} else if context.ContextType == shared.ContextSymbolType {
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if _, err := os.Stat(context.FilePath); os.IsNotExist(err) {
+					deleteIds[context.Id] = true
+					numFilesRemoved++
+					tokenDiffsById[context.Id] = -context.NumTokens
+					return
+				}
+
+				fileContent, err := os.ReadFile(context.FilePath)
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to read the file %s: %v", context.FilePath, err))
+					return
+				}
+
+				// if secrets were masked when the file was loaded, they're masked before comparing
+				redacted, err := redactUpdatedContextBody(context, string(fileContent))
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				fileContent = []byte(redacted)
+
+				// the sha is of the whole file, so any change (including the symbol moving) re-extracts it by name
+				hash := sha256.Sum256(fileContent)
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					symbol, err := symbols.Extract(context.FilePath, fileContent, context.Symbol)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to find symbol %s: %v", context.Name, err))
+						return
+					}
+
+					if symbol == nil {
+						deleteIds[context.Id] = true
+						numFilesRemoved++
+						tokenDiffsById[context.Id] = -context.NumTokens
+						return
+					}
+
+					numTokens, err := shared.GetNumTokens(symbol.Body)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in the symbol %s: %v", context.Name, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numFiles++
+					updatedContexts = append(updatedContexts, context)
+
+					req[context.Id] = &shared.UpdateContextParams{
+						Body:     symbol.Body,
+						FileBody: string(fileContent),
+					}
+				}
+			}(context)
+
 		} else if context.ContextType == shared.ContextDirectoryTreeType {
 			wg.Add(1)
 			go func(context *shared.Context) {
@@ -335,6 +545,142 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 				}
 			}(context)
 
+		} else if context.ContextType == shared.ContextRepoMapType {
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+
+				if _, err := os.Stat(context.FilePath); os.IsNotExist(err) {
+					mu.Lock()
+					defer mu.Unlock()
+					deleteIds[context.Id] = true
+					numTreesRemoved++
+					tokenDiffsById[context.Id] = -context.NumTokens
+					return
+				}
+
+				mapPaths := paths
+				if context.ForceSkipIgnore {
+					mapPaths = nil
+				}
+
+				body, err := buildRepoMap(context.FilePath, mapPaths, context.MapTokens)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to rebuild the repo map %s: %v", context.Name, err))
+					return
+				}
+
+				hash := sha256.Sum256([]byte(body))
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					numTokens, err := shared.GetNumTokens(body)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in %s: %v", context.Name, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numTrees++
+					updatedContexts = append(updatedContexts, context)
+					req[context.Id] = &shared.UpdateContextParams{
+						Body: body,
+					}
+				}
+			}(context)
+
+		} else if IsGitContextType(context.ContextType) {
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+				body, err := getGitContextBody(context.ContextType, context.GitRef, context.ForceSkipIgnore)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to refresh %s (remove it with 'plandex rm' if the ref no longer exists): %v", context.Name, err))
+					return
+				}
+
+				body, err = redactUpdatedContextBody(context, body)
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
+				hash := sha256.Sum256([]byte(body))
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					numTokens, err := shared.GetNumTokens(body)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in %s: %v", context.Name, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numGit++
+					updatedContexts = append(updatedContexts, context)
+					req[context.Id] = &shared.UpdateContextParams{
+						Body: body,
+					}
+				}
+			}(context)
+
+		} else if context.ContextType == shared.ContextExecType {
+			if !rerunCommands {
+				continue
+			}
+
+			// commands are only re-run for the user who loaded them, so sharing a plan can't run commands on someone else's machine
+			if auth.Current == nil || context.OwnerId != auth.Current.UserId {
+				log.Printf("Skipping command context %s loaded by another user\n", context.Name)
+				continue
+			}
+
+			wg.Add(1)
+			go func(context *shared.Context) {
+				defer wg.Done()
+				body, err := runExecContext(context.Command)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
+				body, err = redactUpdatedContextBody(context, body)
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
+				hash := sha256.Sum256([]byte(body))
+				sha := hex.EncodeToString(hash[:])
+
+				if sha != context.Sha {
+					numTokens, err := shared.GetNumTokens(body)
+					if err != nil {
+						errs = append(errs, fmt.Errorf("failed to get the number of tokens in %s: %v", context.Name, err))
+						return
+					}
+					tokenDiffsById[context.Id] = numTokens - context.NumTokens
+
+					numCommands++
+					updatedContexts = append(updatedContexts, context)
+					req[context.Id] = &shared.UpdateContextParams{
+						Body: body,
+					}
+				}
+			}(context)
+
 		} else if context.ContextType == shared.ContextURLType {
 			wg.Add(1)
 			go func(context *shared.Context) {
@@ -349,6 +695,12 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 					return
 				}
 
+				body, err = redactUpdatedContextBody(context, body)
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+
 				hash := sha256.Sum256([]byte(body))
 				sha := hex.EncodeToString(hash[:])
 
@@ -387,9 +739,11 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		}, nil
 	} else if doUpdate {
 		filesToLoad := map[string]string{}
-		for id := range req {
+		for id, params := range req {
 			context := contextsById[id]
-			if context.ContextType == shared.ContextFileType {
+			if context.IsPartialFile() {
+				filesToLoad[context.FilePath] = params.FileBody
+			} else if context.ContextType == shared.ContextFileType {
 				filesToLoad[context.FilePath] = context.Body
 			}
 		}
@@ -407,10 +761,16 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		}
 
 		if len(req) > 0 {
+			omitStoredUpdateContextBodies(req)
+
 			res, apiErr := api.Client.UpdateContext(CurrentPlanId, CurrentBranch, req)
 			if apiErr != nil {
 				return nil, fmt.Errorf("failed to update context: %v", apiErr)
 			}
+			if res.MaxTokensExceeded {
+				overage := res.PinnedTokens - res.MaxTokens
+				return nil, fmt.Errorf("pinned context would take %d 🪙 and exceed token limit (%d) by %d 🪙. Unpin some context so it can be trimmed to fit", res.PinnedTokens, res.MaxTokens, overage)
+			}
 			msg = res.Msg
 		}
 
@@ -449,6 +809,8 @@ func checkOutdatedAndMaybeUpdateContext(doUpdate bool, maybeContexts []*shared.C
 		NumFiles:        numFiles,
 		NumUrls:         numUrls,
 		NumTrees:        numTrees,
+		NumGit:          numGit,
+		NumCommands:     numCommands,
 		NumFilesRemoved: numFilesRemoved,
 		NumTreesRemoved: numTreesRemoved,
 	}, nil
@@ -0,0 +1,368 @@
+package lib
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
+	"github.com/plandex/plandex/shared"
+)
+
+const DefaultWatchDebounce = 750 * time.Millisecond
+
+type contextWatcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+
+	mu          sync.Mutex
+	watchedDirs map[string]bool
+	filePaths   map[string]bool // absolute paths of file, image, symbol and line range contexts
+	watchTree   bool            // directory tree and git contexts change when any non-ignored file does
+	paths       *fs.ProjectPaths
+	isGitRepo   bool
+	timer       *time.Timer
+	updating    bool
+	pending     bool
+
+	// what changed since the last update, so only the context it affects is refreshed
+	changedPaths map[string]bool // absolute paths
+	refsChanged  bool
+}
+
+// MustWatchContext watches the files behind the current plan's context and pushes updates to the server as they change, until interrupted
+func MustWatchContext(debounce time.Duration) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		term.OutputErrorAndExit("Error starting file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	w := &contextWatcher{
+		watcher:      watcher,
+		debounce:     debounce,
+		watchedDirs:  map[string]bool{},
+		isGitRepo:    fs.ProjectRootIsGitRepo(),
+		changedPaths: map[string]bool{},
+	}
+
+	// catch up on anything that changed before watching started
+	w.update(true)
+
+	numContexts, totalTokens, err := w.sync()
+	if err != nil {
+		term.OutputErrorAndExit("Error watching context: %v", err)
+	}
+
+	fmt.Printf("👀 Watching %d %s | total → %d 🪙\n", numContexts, pluralize(numContexts, "piece of context", "pieces of context"), totalTokens)
+	fmt.Println(color.New(color.FgWhite).Sprint("Context updates are sent as files change. Press ctrl+c to stop."))
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if w.recordChange(event) {
+				w.schedule()
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("File watcher error:", err)
+
+		case <-sigCh:
+			fmt.Println()
+			fmt.Println("👋 Stopped watching context")
+			return
+		}
+	}
+}
+
+// sync lists the plan's context and makes sure every directory it depends on is watched. It's re-run after each update so context loaded while watching is picked up.
+func (w *contextWatcher) sync() (int, int, error) {
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		return 0, 0, fmt.Errorf("error listing context: %v", apiErr.Msg)
+	}
+
+	filePaths := map[string]bool{}
+	dirs := map[string]bool{}
+	watchTree := false
+	totalTokens := 0
+
+	for _, context := range contexts {
+		totalTokens += context.NumTokens
+
+		switch context.ContextType {
+		case shared.ContextFileType, shared.ContextImageType, shared.ContextSymbolType:
+			absPath := toAbs(context.FilePath)
+			filePaths[absPath] = true
+			// watch the parent dir rather than the file so saves that replace the file are seen
+			dirs[filepath.Dir(absPath)] = true
+		case shared.ContextDirectoryTreeType, shared.ContextRepoMapType, shared.ContextGitDiffType, shared.ContextGitChangesType:
+			watchTree = true
+		}
+	}
+
+	var paths *fs.ProjectPaths
+	if watchTree {
+		var err error
+		paths, err = fs.GetProjectPaths(fs.ProjectRoot)
+		if err != nil {
+			return 0, 0, fmt.Errorf("error getting project paths: %v", err)
+		}
+
+		dirs[fs.ProjectRoot] = true
+		for path := range paths.ActivePaths {
+			dirs[filepath.Dir(toAbs(path))] = true
+		}
+
+		if w.isGitRepo {
+			// so commits and checkouts that move refs are seen
+			dirs[filepath.Join(fs.ProjectRoot, ".git")] = true
+			dirs[filepath.Join(fs.ProjectRoot, ".git", "refs", "heads")] = true
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for dir := range dirs {
+		if w.watchedDirs[dir] {
+			continue
+		}
+		if err := w.watcher.Add(dir); err != nil {
+			// a dir may have been removed since it was listed
+			log.Printf("Error watching %s: %v\n", dir, err)
+			continue
+		}
+		w.watchedDirs[dir] = true
+	}
+
+	w.filePaths = filePaths
+	w.watchTree = watchTree
+	w.paths = paths
+
+	return len(contexts), totalTokens, nil
+}
+
+// recordChange notes a change that affects context so the next update refreshes it, and reports whether there was one
+func (w *contextWatcher) recordChange(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	absPath := toAbs(event.Name)
+	if w.filePaths[absPath] {
+		w.changedPaths[absPath] = true
+		return true
+	}
+
+	if !w.watchTree {
+		return false
+	}
+
+	relPath, err := filepath.Rel(fs.ProjectRoot, absPath)
+	if err != nil {
+		return false
+	}
+
+	if relPath == ".git" || strings.HasPrefix(relPath, ".git"+string(os.PathSeparator)) {
+		// only ref moves matter; index and object writes are noise
+		base := filepath.Base(relPath)
+		if base == "HEAD" || base == "packed-refs" || strings.HasPrefix(relPath, filepath.Join(".git", "refs")+string(os.PathSeparator)) {
+			w.refsChanged = true
+			return true
+		}
+		return false
+	}
+
+	if w.isIgnored(relPath) {
+		return false
+	}
+
+	w.changedPaths[absPath] = true
+	return true
+}
+
+// contextsToRefresh picks the context affected by what changed since the last update. Commands and urls don't depend on project files, so they're never refreshed by watching.
+func (w *contextWatcher) contextsToRefresh(contexts []*shared.Context, catchUp bool) []*shared.Context {
+	w.mu.Lock()
+	changedPaths := w.changedPaths
+	refsChanged := w.refsChanged
+	w.changedPaths = map[string]bool{}
+	w.refsChanged = false
+	w.mu.Unlock()
+
+	var res []*shared.Context
+	for _, context := range contexts {
+		var refresh bool
+
+		switch context.ContextType {
+		case shared.ContextFileType, shared.ContextImageType, shared.ContextSymbolType:
+			refresh = catchUp || changedPaths[toAbs(context.FilePath)]
+
+		case shared.ContextDirectoryTreeType, shared.ContextRepoMapType:
+			refresh = catchUp
+			dir := toAbs(context.FilePath)
+			for path := range changedPaths {
+				if path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+					refresh = true
+					break
+				}
+			}
+
+		case shared.ContextGitDiffType, shared.ContextGitChangesType, shared.ContextGitCommitType:
+			refresh = catchUp || refsChanged || (context.ContextType != shared.ContextGitCommitType && len(changedPaths) > 0)
+		}
+
+		if refresh {
+			res = append(res, context)
+		}
+	}
+
+	return res
+}
+
+// isIgnored checks a path against .plandexignore and .gitignore, using the paths listed at the last sync where possible
+func (w *contextWatcher) isIgnored(relPath string) bool {
+	if strings.HasPrefix(relPath, ".plandex") {
+		return true
+	}
+
+	if w.paths != nil {
+		if w.paths.ActivePaths[relPath] {
+			return false
+		}
+		if _, ok := w.paths.IgnoredPaths[relPath]; ok {
+			return true
+		}
+		if w.paths.PlandexIgnored != nil && w.paths.PlandexIgnored.MatchesPath(relPath) {
+			return true
+		}
+	}
+
+	if w.isGitRepo {
+		cmd := exec.Command("git", "check-ignore", "-q", relPath)
+		cmd.Dir = fs.ProjectRoot
+		// exit code 0 means the path is ignored
+		return cmd.Run() == nil
+	}
+
+	return false
+}
+
+// schedule debounces updates so a burst of saves results in one update
+func (w *contextWatcher) schedule() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	w.timer = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		if w.updating {
+			// run again once the current update finishes
+			w.pending = true
+			w.mu.Unlock()
+			return
+		}
+		w.updating = true
+		w.mu.Unlock()
+
+		for {
+			updated := w.update(false)
+
+			_, totalTokens, err := w.sync()
+			if err != nil {
+				log.Println("Error syncing watched context:", err)
+			} else if updated {
+				fmt.Printf("  total → %d 🪙\n", totalTokens)
+			}
+
+			w.mu.Lock()
+			if !w.pending {
+				w.updating = false
+				w.mu.Unlock()
+				return
+			}
+			w.pending = false
+			w.mu.Unlock()
+		}
+	})
+}
+
+// update refreshes the context affected by changes since the last update, or all file-backed context if catchUp is set. It reports whether any context was updated or removed.
+func (w *contextWatcher) update(catchUp bool) bool {
+	contexts, apiErr := api.Client.ListContext(CurrentPlanId, CurrentBranch)
+	if apiErr != nil {
+		fmt.Fprintf(os.Stderr, "%s Error listing context: %v\n", time.Now().Format("15:04:05"), apiErr.Msg)
+		return false
+	}
+
+	toRefresh := w.contextsToRefresh(contexts, catchUp)
+	if len(toRefresh) == 0 {
+		return false
+	}
+
+	res, err := UpdateContext(toRefresh)
+	if err != nil {
+		term.StopSpinner()
+		fmt.Fprintf(os.Stderr, "%s Error updating context: %v\n", time.Now().Format("15:04:05"), err)
+		return false
+	}
+
+	if len(res.UpdatedContexts) == 0 && len(res.RemovedContexts) == 0 {
+		return false
+	}
+
+	var names []string
+	for _, context := range append(res.UpdatedContexts, res.RemovedContexts...) {
+		_, icon := GetContextLabelAndIcon(context.ContextType)
+		names = append(names, icon+" "+context.Name)
+	}
+
+	fmt.Printf("%s 🔄 %s\n", color.New(color.FgWhite).Sprint(time.Now().Format("15:04:05")), strings.TrimSpace(res.Msg))
+	for _, name := range names {
+		fmt.Println("  • " + name)
+	}
+
+	return true
+}
+
+// context paths are relative to the project root
+func toAbs(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(fs.ProjectRoot, path)
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/types"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/plandex/plandex/shared"
+)
+
+// fakeWatchClient counts how often the plan's context is listed, which happens once for each update and each sync
+type fakeWatchClient struct {
+	types.ApiClient
+	mu        sync.Mutex
+	numListed int
+}
+
+func (c *fakeWatchClient) ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.numListed++
+	return nil, nil
+}
+
+func newTestContextWatcher(t *testing.T, client types.ApiClient) *contextWatcher {
+	t.Helper()
+
+	projectRoot, apiClient := fs.ProjectRoot, api.Client
+	t.Cleanup(func() { fs.ProjectRoot, api.Client = projectRoot, apiClient })
+
+	dir := t.TempDir()
+	fs.ProjectRoot = dir
+	api.Client = client
+
+	initTestGitRepo(t, dir, map[string]string{".gitignore": "*.log\n", "main.go": "package main\n"})
+
+	return &contextWatcher{
+		debounce:     50 * time.Millisecond,
+		watchedDirs:  map[string]bool{},
+		isGitRepo:    true,
+		changedPaths: map[string]bool{},
+		filePaths:    map[string]bool{filepath.Join(dir, "main.go"): true},
+		watchTree:    true,
+	}
+}
+
+func TestContextWatcherRecordChange(t *testing.T) {
+	w := newTestContextWatcher(t, &fakeWatchClient{})
+
+	tests := []struct {
+		name string
+		op   fsnotify.Op
+		path string
+		want bool
+	}{
+		{name: "chmod", op: fsnotify.Chmod, path: "main.go", want: false},
+		{name: "context file", op: fsnotify.Write, path: "main.go", want: true},
+		{name: "project file", op: fsnotify.Create, path: "other.go", want: true},
+		{name: "gitignored file", op: fsnotify.Write, path: "debug.log", want: false},
+		{name: "plandex dir", op: fsnotify.Write, path: ".plandex/settings.json", want: false},
+		{name: "git index", op: fsnotify.Write, path: ".git/index", want: false},
+		{name: "git ref", op: fsnotify.Write, path: ".git/refs/heads/main", want: true},
+	}
+
+	for _, tt := range tests {
+		event := fsnotify.Event{Name: filepath.Join(fs.ProjectRoot, tt.path), Op: tt.op}
+		if got := w.recordChange(event); got != tt.want {
+			t.Errorf("%s: recordChange = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !w.refsChanged {
+		t.Error("expected the ref move to be recorded")
+	}
+
+	var changed []string
+	for path := range w.changedPaths {
+		rel, _ := filepath.Rel(fs.ProjectRoot, path)
+		changed = append(changed, rel)
+	}
+	sort.Strings(changed)
+	if !reflect.DeepEqual(changed, []string{"main.go", "other.go"}) {
+		t.Errorf("changed paths = %v, want [main.go other.go]", changed)
+	}
+}
+
+func TestContextWatcherContextsToRefresh(t *testing.T) {
+	w := newTestContextWatcher(t, &fakeWatchClient{})
+
+	contexts := []*shared.Context{
+		{Id: "file", ContextType: shared.ContextFileType, FilePath: "main.go"},
+		{Id: "otherFile", ContextType: shared.ContextFileType, FilePath: "util.go"},
+		{Id: "tree", ContextType: shared.ContextDirectoryTreeType, FilePath: "."},
+		{Id: "subTree", ContextType: shared.ContextDirectoryTreeType, FilePath: "sub"},
+		{Id: "commit", ContextType: shared.ContextGitCommitType, GitRef: "HEAD"},
+		{Id: "url", ContextType: shared.ContextURLType, Url: "https://example.com"},
+	}
+
+	ids := func(contexts []*shared.Context) []string {
+		var res []string
+		for _, context := range contexts {
+			res = append(res, context.Id)
+		}
+		return res
+	}
+
+	w.recordChange(fsnotify.Event{Name: filepath.Join(fs.ProjectRoot, "main.go"), Op: fsnotify.Write})
+	if got := ids(w.contextsToRefresh(contexts, false)); !reflect.DeepEqual(got, []string{"file", "tree"}) {
+		t.Errorf("after a file change: got %v, want [file tree]", got)
+	}
+
+	// changes are only refreshed once
+	if got := ids(w.contextsToRefresh(contexts, false)); got != nil {
+		t.Errorf("with nothing changed: got %v, want nothing", got)
+	}
+
+	w.recordChange(fsnotify.Event{Name: filepath.Join(fs.ProjectRoot, ".git", "HEAD"), Op: fsnotify.Write})
+	if got := ids(w.contextsToRefresh(contexts, false)); !reflect.DeepEqual(got, []string{"commit"}) {
+		t.Errorf("after a checkout: got %v, want [commit]", got)
+	}
+
+	if got := ids(w.contextsToRefresh(contexts, true)); !reflect.DeepEqual(got, []string{"file", "otherFile", "tree", "subTree", "commit"}) {
+		t.Errorf("catching up: got %v, want everything but the url", got)
+	}
+}
+
+func TestContextWatcherDebounce(t *testing.T) {
+	client := &fakeWatchClient{}
+	w := newTestContextWatcher(t, client)
+
+	for i := 0; i < 5; i++ {
+		w.schedule()
+		time.Sleep(w.debounce / 5)
+	}
+
+	time.Sleep(w.debounce * 4)
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// one update and the sync after it
+	if client.numListed != 2 {
+		t.Errorf("context was listed %d times, want 2 for a single update", client.numListed)
+	}
+}
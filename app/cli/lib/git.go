@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -102,11 +103,13 @@ func CheckUncommittedChanges() (bool, error) {
 	return strings.TrimSpace(string(res)) != "", nil
 }
 
-func GitStashCreate(message string) error {
+// GitStashCreate stashes the uncommitted changes to paths, including untracked files among them
+func GitStashCreate(message string, paths []string) error {
 	gitMutex.Lock()
 	defer gitMutex.Unlock()
 
-	res, err := exec.Command("git", "stash", "push", "--include-untracked", "-m", message).CombinedOutput()
+	args := append([]string{"stash", "push", "--include-untracked", "-m", message, "--"}, paths...)
+	res, err := exec.Command("git", args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("error creating git stash: %v, output: %s", err, string(res))
 	}
@@ -114,6 +117,56 @@ func GitStashCreate(message string) error {
 	return nil
 }
 
+// GitStashablePaths returns the paths with uncommitted changes to tracked files, along with any of untrackedPaths that exist and aren't tracked. Other untracked files are left out, as is the .plandex directory.
+func GitStashablePaths(untrackedPaths []string) ([]string, error) {
+	tracked, err := gitCmdOutput("status", "--porcelain", "-z", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+
+	var untracked string
+	if len(untrackedPaths) > 0 {
+		untracked, err = gitCmdOutput(append([]string{"status", "--porcelain", "-z", "--untracked-files=all", "--"}, untrackedPaths...)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	seen := map[string]bool{}
+	var paths []string
+
+	entries := strings.Split(tracked+untracked, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+
+		status, path := entry[:2], entry[3:]
+		if status == "!!" || path == ".plandex" || strings.HasPrefix(path, ".plandex/") {
+			continue
+		}
+
+		add := func(path string) {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+		add(path)
+
+		// renames and copies are followed by the original path, which is stashed too so the rename is restored whole
+		if (status[0] == 'R' || status[0] == 'C') && i+1 < len(entries) {
+			i++
+			add(entries[i])
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
 // this matches output for git version 2.39.3
 // need to test on other versions and check for more variations
 // there isn't any structured way to get stash conflicts from git, unfortunately
@@ -212,6 +265,81 @@ func GitCheckoutFile(path string) error {
 	return nil
 }
 
+// GitResolveCommit returns the full sha that ref points to, or an error if it isn't a commit
+func GitResolveCommit(ref string) (string, error) {
+	res, err := gitCmdOutput("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s isn't a valid git ref", ref)
+	}
+
+	return strings.TrimSpace(res), nil
+}
+
+// GitDiff returns the diff of the working tree against ref
+func GitDiff(ref string) (string, error) {
+	return gitCmdOutput("diff", ref, "--")
+}
+
+// GitShowCommit returns a commit's message, stats and patch
+func GitShowCommit(ref string) (string, error) {
+	return gitCmdOutput("show", "--stat", "--patch", ref, "--")
+}
+
+// GitCommitSummary returns a commit's short sha and subject
+func GitCommitSummary(ref string) (string, error) {
+	res, err := gitCmdOutput("log", "-1", "--format=%h %s", ref, "--")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(res), nil
+}
+
+// GitChangedFilesSince lists paths (relative to the current directory) that differ between ref and the working tree, along with any untracked files that aren't ignored
+func GitChangedFilesSince(ref string) ([]string, error) {
+	changed, err := gitCmdOutput("diff", "--name-only", "--relative", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := gitCmdOutput("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var paths []string
+	for _, line := range strings.Split(changed+"\n"+untracked, "\n") {
+		path := strings.TrimSpace(line)
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// gitCmdOutput runs a read-only git command in the current directory and returns its stdout
+func gitCmdOutput(args ...string) (string, error) {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	res, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var stderr string
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+		}
+		return "", fmt.Errorf("error running git %s | err: %v, output: %s", args[0], err, stderr)
+	}
+
+	return string(res), nil
+}
+
 func parseConflictFiles(gitOutput string) []string {
 	var conflictFiles []string
 	lines := strings.Split(gitOutput, "\n")
@@ -233,3 +361,50 @@ func parseConflictFiles(gitOutput string) []string {
 	}
 	return conflictFiles
 }
+
+// GitCurrentBranch returns the checked out branch, or the commit sha if HEAD is detached
+func GitCurrentBranch() (string, error) {
+	res, err := gitCmdOutput("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	branch := strings.TrimSpace(res)
+	if branch != "HEAD" {
+		return branch, nil
+	}
+
+	return GitResolveCommit("HEAD")
+}
+
+// GitValidateBranchName returns an error if name can't be used as a branch name
+func GitValidateBranchName(name string) error {
+	_, err := gitCmdOutput("check-ref-format", "--branch", name)
+	if err != nil {
+		return fmt.Errorf("%s isn't a valid branch name", name)
+	}
+	return nil
+}
+
+func GitBranchExists(name string) bool {
+	_, err := gitCmdOutput("rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	return err == nil
+}
+
+// GitCheckout checks out a branch or commit, first creating the branch from the current commit if create is true
+func GitCheckout(ref string, create bool) error {
+	gitMutex.Lock()
+	defer gitMutex.Unlock()
+
+	args := []string{"checkout", ref}
+	if create {
+		args = []string{"checkout", "-b", ref}
+	}
+
+	res, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error checking out %s | err: %v, output: %s", ref, err, string(res))
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// initTestGitRepo writes files to dir and commits them on main, then runs the test from dir since git commands run in the working directory
+func initTestGitRepo(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	writeTestFiles(t, files)
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.email", "test@plandex.ai"},
+		{"config", "user.name", "test"},
+		{"add", "."},
+		{"commit", "-q", "-m", "init"},
+	} {
+		gitTest(t, args...)
+	}
+}
+
+// writeTestFiles writes files relative to the working directory
+func writeTestFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func gitTest(t *testing.T, args ...string) string {
+	t.Helper()
+	res, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v, output: %s", strings.Join(args, " "), err, res)
+	}
+	return string(res)
+}
+
+func TestGitChangedFilesSince(t *testing.T) {
+	initTestGitRepo(t, t.TempDir(), map[string]string{
+		".gitignore": "*.log\n",
+		"a.go":       "package a\n",
+		"b.go":       "package b\n",
+		"sub/c.go":   "package sub\n",
+	})
+
+	writeTestFiles(t, map[string]string{"b.go": "package b // committed\n"})
+	gitTest(t, "commit", "-q", "-am", "update b")
+
+	writeTestFiles(t, map[string]string{
+		"sub/c.go":   "package sub // uncommitted\n",
+		"new.go":     "package main\n",
+		"sub/new.go": "package sub\n",
+		"debug.log":  "ignored\n",
+	})
+
+	tests := []struct {
+		name string
+		dir  string
+		ref  string
+		want []string
+	}{
+		{name: "since the last commit", ref: "HEAD", want: []string{"new.go", "sub/c.go", "sub/new.go"}},
+		{name: "since an earlier commit", ref: "HEAD~1", want: []string{"b.go", "new.go", "sub/c.go", "sub/new.go"}},
+		{name: "from a subdirectory", dir: "sub", ref: "HEAD~1", want: []string{"c.go", "new.go"}},
+	}
+
+	for _, tt := range tests {
+		func() {
+			if tt.dir != "" {
+				if err := os.Chdir(tt.dir); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Chdir("..")
+			}
+
+			got, err := GitChangedFilesSince(tt.ref)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			}
+		}()
+	}
+}
+
+func TestGitResolveCommit(t *testing.T) {
+	initTestGitRepo(t, t.TempDir(), map[string]string{"a.go": "package a\n"})
+
+	head := strings.TrimSpace(gitTest(t, "rev-parse", "HEAD"))
+
+	for _, ref := range []string{"HEAD", "main", head[:7]} {
+		got, err := GitResolveCommit(ref)
+		if err != nil {
+			t.Errorf("%s: %v", ref, err)
+		} else if got != head {
+			t.Errorf("%s resolved to %s, want %s", ref, got, head)
+		}
+	}
+
+	// a ref has to point to a commit, not some other object
+	for _, ref := range []string{"missing", "HEAD~1", "HEAD:a.go"} {
+		if got, err := GitResolveCommit(ref); err == nil {
+			t.Errorf("%s resolved to %s, want an error", ref, got)
+		}
+	}
+}
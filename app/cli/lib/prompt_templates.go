@@ -0,0 +1,132 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/fs"
+	"plandex/term"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// project templates are kept alongside project.json so they can be committed with the project
+func projectPromptTemplatesPath() string {
+	return filepath.Join(fs.PlandexDir, "templates.json")
+}
+
+func LoadProjectPromptTemplates() ([]*shared.PromptTemplate, error) {
+	templates := []*shared.PromptTemplate{}
+
+	if fs.PlandexDir == "" {
+		return templates, nil
+	}
+
+	bytes, err := os.ReadFile(projectPromptTemplatesPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return templates, nil
+		}
+		return nil, fmt.Errorf("error reading project templates: %v", err)
+	}
+
+	err = json.Unmarshal(bytes, &templates)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling project templates: %v", err)
+	}
+
+	return templates, nil
+}
+
+func WriteProjectPromptTemplates(templates []*shared.PromptTemplate) error {
+	if fs.PlandexDir == "" {
+		return fmt.Errorf("no project found")
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	bytes, err := json.MarshalIndent(templates, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshalling project templates: %v", err)
+	}
+
+	err = os.WriteFile(projectPromptTemplatesPath(), bytes, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing project templates: %v", err)
+	}
+
+	return nil
+}
+
+// ResolvePromptTemplate looks up a template by name, checking project templates before org templates.
+func ResolvePromptTemplate(name string) (template *shared.PromptTemplate, isOrg bool, err error) {
+	projectTemplates, err := LoadProjectPromptTemplates()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, t := range projectTemplates {
+		if t.Name == name {
+			return t, false, nil
+		}
+	}
+
+	orgTemplates, apiErr := api.Client.ListPromptTemplates()
+	if apiErr != nil {
+		return nil, false, fmt.Errorf("error listing org templates: %v", apiErr.Msg)
+	}
+
+	for _, t := range orgTemplates {
+		if t.Name == name {
+			return t, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// MustExpandPromptTemplate fills in the named template with key=value pairs from varArgs, prompting for any variables that weren't passed.
+func MustExpandPromptTemplate(name string, varArgs []string) string {
+	term.StartSpinner("")
+	template, _, err := ResolvePromptTemplate(name)
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error loading template: %v", err)
+	}
+
+	if template == nil {
+		term.OutputErrorAndExit("Template %s not found", name)
+	}
+
+	vars := map[string]string{}
+	for _, arg := range varArgs {
+		key, val, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			term.OutputErrorAndExit("Invalid template variable %q, expected key=value", arg)
+		}
+		vars[key] = val
+	}
+
+	expanded, missing := shared.ExpandPromptTemplate(template.Body, vars)
+
+	if len(missing) > 0 {
+		for _, key := range missing {
+			val, err := term.GetUserStringInput(fmt.Sprintf("%s:", key))
+			if err != nil {
+				term.OutputErrorAndExit("Error getting value for %s: %v", key, err)
+			}
+			vars[key] = val
+		}
+
+		expanded, _ = shared.ExpandPromptTemplate(template.Body, vars)
+	}
+
+	return expanded
+}
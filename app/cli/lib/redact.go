@@ -0,0 +1,288 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"plandex/redact"
+	"plandex/term"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/plandex/plandex/shared"
+)
+
+const (
+	redactActionMask  = "mask"
+	redactActionBlock = "block"
+	redactActionAllow = "allow"
+)
+
+// redactConfig is read from redact.json alongside project.json so it can be committed with the project
+type redactConfig struct {
+	// what loading does with context that contains secrets: "mask" (the default) replaces them with placeholders and "block" skips the context
+	Action string `json:"action"`
+	Rules  []struct {
+		Name    string `json:"name"`
+		Pattern string `json:"pattern"`
+	} `json:"rules"`
+}
+
+var (
+	redactOnce   sync.Once
+	redactAction string
+	redactRules  []*redact.Rule
+	redactErr    error
+)
+
+func redactConfigPath() string {
+	return filepath.Join(fs.PlandexDir, "redact.json")
+}
+
+// loadRedactRules returns the configured action along with the built-in rules and any custom rules from redact.json
+func loadRedactRules() (string, []*redact.Rule, error) {
+	redactOnce.Do(func() {
+		redactAction = redactActionMask
+		redactRules = redact.BuiltInRules()
+
+		if fs.PlandexDir == "" {
+			return
+		}
+
+		bytes, err := os.ReadFile(redactConfigPath())
+		if err != nil {
+			if !os.IsNotExist(err) {
+				redactErr = fmt.Errorf("error reading redact.json: %v", err)
+			}
+			return
+		}
+
+		var config redactConfig
+		err = json.Unmarshal(bytes, &config)
+		if err != nil {
+			redactErr = fmt.Errorf("error unmarshalling redact.json: %v", err)
+			return
+		}
+
+		switch config.Action {
+		case "", redactActionMask:
+		case redactActionBlock:
+			redactAction = redactActionBlock
+		default:
+			redactErr = fmt.Errorf("invalid action '%s' in redact.json, expected 'mask' or 'block'", config.Action)
+			return
+		}
+
+		for _, rule := range config.Rules {
+			regex, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				redactErr = fmt.Errorf("invalid pattern for rule '%s' in redact.json: %v", rule.Name, err)
+				return
+			}
+			redactRules = append(redactRules, &redact.Rule{Name: rule.Name, Regex: regex})
+		}
+	})
+
+	return redactAction, redactRules, redactErr
+}
+
+// RedactContextBody masks secrets in a body that's sent without the chance to prompt, like a context update or a file loaded from the missing file prompt
+func RedactContextBody(body string) (string, error) {
+	_, rules, err := loadRedactRules()
+	if err != nil {
+		return "", err
+	}
+
+	redacted, _ := redact.Redact(body, rules)
+	return redacted, nil
+}
+
+// redactUpdatedContextBody masks secrets in an updated body if secrets were masked when the context was loaded. Context that was loaded as-is is left as-is, so its sha still matches an unchanged source.
+func redactUpdatedContextBody(context *shared.Context, body string) (string, error) {
+	if !context.Redacted {
+		return body, nil
+	}
+
+	return RedactContextBody(body)
+}
+
+// restoreRedactedSecrets puts secrets that were masked when a file was loaded back into changes made to it before they're written
+func restoreRedactedSecrets(content, original string) string {
+	_, rules, err := loadRedactRules()
+	if err != nil {
+		return content
+	}
+
+	return redact.Restore(content, original, rules)
+}
+
+type redactionResult struct {
+	name     string
+	findings []*redact.Finding
+	action   string
+}
+
+// mustRedactLoadContextRequest scans context for secrets before it's uploaded. If any are found, the user chooses whether to mask them, skip the context that has them, or load it as-is, with the configured action as the default. Without a terminal to prompt in, the configured action is used.
+func mustRedactLoadContextRequest(req shared.LoadContextRequest) (shared.LoadContextRequest, []*redactionResult) {
+	configAction, rules, err := loadRedactRules()
+	if err != nil {
+		onLoadContextErr(err)
+	}
+
+	type redacted struct {
+		body, fileBody string
+	}
+
+	var results []*redactionResult
+	redactedByParams := map[*shared.LoadContextParams]redacted{}
+
+	for _, params := range req {
+		if params.ContextType == shared.ContextImageType {
+			continue
+		}
+
+		// unless the user chooses to load secrets as-is below, any that show up in later updates are masked too
+		params.Redacted = true
+
+		body, bodyFindings := redact.Redact(params.Body, rules)
+		fileBody, fileBodyFindings := redact.Redact(params.FileBody, rules)
+
+		// a partial file's body is part of its file body, so the file body's findings cover both
+		findings := bodyFindings
+		if params.FileBody != "" {
+			findings = fileBodyFindings
+		}
+
+		if len(findings) == 0 {
+			continue
+		}
+
+		name := params.Name
+		if name == "" {
+			name, _ = GetContextLabelAndIcon(params.ContextType)
+		}
+
+		results = append(results, &redactionResult{name: name, findings: findings})
+		redactedByParams[params] = redacted{body: body, fileBody: fileBody}
+	}
+
+	if len(results) == 0 {
+		return req, nil
+	}
+
+	action := configAction
+
+	if term.IsInteractive() {
+		term.StopSpinner()
+
+		fmt.Println("🔐 " + color.New(color.Bold, term.ColorHiYellow).Sprint("Found possible secrets"))
+		for _, result := range results {
+			fmt.Printf("  • %s | %s\n", result.name, summarizeFindings(result.findings))
+		}
+		fmt.Println()
+
+		maskOpt := "Mask them and load"
+		blockOpt := "Skip context with secrets"
+		allowOpt := "Load as-is"
+		actionsByOpt := map[string]string{maskOpt: redactActionMask, blockOpt: redactActionBlock, allowOpt: redactActionAllow}
+
+		opts := []string{maskOpt, blockOpt, allowOpt}
+		if configAction == redactActionBlock {
+			opts = []string{blockOpt, maskOpt, allowOpt}
+		}
+
+		selected, err := term.SelectFromList("What do you want to do?", opts)
+		if err != nil {
+			onLoadContextErr(fmt.Errorf("failed to get a response: %v", err))
+		}
+		action = actionsByOpt[selected]
+
+		fmt.Println()
+		term.ResumeSpinner()
+	}
+
+	for _, result := range results {
+		result.action = action
+	}
+
+	var res shared.LoadContextRequest
+	for _, params := range req {
+		redacted, ok := redactedByParams[params]
+		if !ok {
+			res = append(res, params)
+			continue
+		}
+
+		switch action {
+		case redactActionMask:
+			params.Body = redacted.body
+			params.FileBody = redacted.fileBody
+			res = append(res, params)
+		case redactActionAllow:
+			params.Redacted = false
+			res = append(res, params)
+		}
+	}
+
+	return res, results
+}
+
+func summarizeFindings(findings []*redact.Finding) string {
+	countsByRule := map[string]int{}
+	var lines []string
+	for _, finding := range findings {
+		countsByRule[finding.Rule]++
+		lines = append(lines, strconv.Itoa(finding.Line))
+	}
+
+	var rules []string
+	for rule, count := range countsByRule {
+		if count > 1 {
+			rules = append(rules, fmt.Sprintf("%s ×%d", rule, count))
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Strings(rules)
+
+	return strings.Join(rules, ", ") + " | line " + strings.Join(lines, ", ")
+}
+
+func printRedactionSummary(results []*redactionResult) {
+	fmt.Println()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"🔐 Secrets", "Found", "Action"})
+	table.SetAutoWrapText(false)
+
+	for _, result := range results {
+		var action string
+		var tableColor int
+		switch result.action {
+		case redactActionMask:
+			action = "masked"
+			tableColor = tablewriter.FgHiGreenColor
+		case redactActionBlock:
+			action = "skipped"
+			tableColor = tablewriter.FgHiYellowColor
+		case redactActionAllow:
+			action = "loaded as-is"
+			tableColor = tablewriter.FgHiRedColor
+		}
+
+		table.Rich([]string{result.name, summarizeFindings(result.findings), action}, []tablewriter.Colors{
+			{tableColor, tablewriter.Bold},
+			{tableColor},
+			{tableColor},
+		})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"plandex/fs"
+	"plandex/symbols"
+	"plandex/types"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// DefaultRepoMapTokens is the token budget for a repo map loaded without --map-tokens
+const DefaultRepoMapTokens = 4000
+
+// larger files are usually generated or minified, so they're left out of repo maps
+const maxRepoMapFileSize = 512 * 1024
+
+var repoMapWordRegex = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+type repoMapDecl struct {
+	path string
+	decl *symbols.Decl
+	refs int
+}
+
+func repoMapName(root string) string {
+	name := root
+	if name == "." {
+		name = "cwd"
+	}
+	if name == ".." {
+		name = "parent"
+	}
+	return name
+}
+
+// buildRepoMap lists the exported functions, types, and methods of each source file under root. Declarations are ranked by how often their names appear across those files, and the most referenced are kept until maxTokens is used up. The map is ordered by file and then by line so it reads like the codebase. If paths is nil, ignored files are included.
+func buildRepoMap(root string, paths *fs.ProjectPaths, maxTokens int) (string, error) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultRepoMapTokens
+	}
+
+	flattenedPaths, err := ParseInputPaths([]string{root}, &types.LoadContextParams{
+		Recursive:       true,
+		ForceSkipIgnore: paths == nil,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse input paths: %v", err)
+	}
+
+	var decls []*repoMapDecl
+	wordCounts := map[string]int{}
+
+	for _, path := range flattenedPaths {
+		if !symbols.HasLister(path) {
+			continue
+		}
+		if paths != nil && !paths.ActivePaths[path] {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to stat %s: %v", path, err)
+		}
+		if info.Size() > maxRepoMapFileSize {
+			continue
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		for _, word := range repoMapWordRegex.FindAllString(string(src), -1) {
+			wordCounts[word]++
+		}
+
+		fileDecls, err := symbols.List(path, src)
+		if err != nil {
+			// files that don't parse, like ones mid-edit, are left out rather than failing the whole map
+			continue
+		}
+
+		for _, decl := range fileDecls {
+			decls = append(decls, &repoMapDecl{path: path, decl: decl})
+		}
+	}
+
+	for _, d := range decls {
+		name := d.decl.Name
+		if _, method, ok := strings.Cut(name, "."); ok {
+			name = method
+		}
+		// the declaration itself isn't a reference
+		d.refs = max(wordCounts[name]-1, 0)
+	}
+
+	sort.SliceStable(decls, func(i, j int) bool {
+		if decls[i].refs != decls[j].refs {
+			return decls[i].refs > decls[j].refs
+		}
+		if decls[i].path != decls[j].path {
+			return decls[i].path < decls[j].path
+		}
+		return decls[i].decl.Line < decls[j].decl.Line
+	})
+
+	included := map[string][]*symbols.Decl{}
+	numTokens := 0
+	numOmitted := 0
+
+	for i, d := range decls {
+		lineTokens, err := shared.GetNumTokens("  " + d.decl.Signature + "\n")
+		if err != nil {
+			return "", fmt.Errorf("failed to get the number of tokens in the repo map: %v", err)
+		}
+
+		if _, ok := included[d.path]; !ok {
+			pathTokens, err := shared.GetNumTokens(d.path + ":\n")
+			if err != nil {
+				return "", fmt.Errorf("failed to get the number of tokens in the repo map: %v", err)
+			}
+			lineTokens += pathTokens
+		}
+
+		if numTokens+lineTokens > maxTokens {
+			numOmitted = len(decls) - i
+			break
+		}
+
+		numTokens += lineTokens
+		included[d.path] = append(included[d.path], d.decl)
+	}
+
+	var filePaths []string
+	for path := range included {
+		filePaths = append(filePaths, path)
+	}
+	sort.Strings(filePaths)
+
+	var lines []string
+	for _, path := range filePaths {
+		fileDecls := included[path]
+		sort.Slice(fileDecls, func(i, j int) bool { return fileDecls[i].Line < fileDecls[j].Line })
+
+		lines = append(lines, path+":")
+		for _, decl := range fileDecls {
+			lines = append(lines, "  "+decl.Signature)
+		}
+	}
+
+	if numOmitted > 0 {
+		lines = append(lines, fmt.Sprintf("\n(%d less referenced declarations left out to fit the map's %d token budget)", numOmitted, maxTokens))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,187 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plandex/api"
+	"plandex/format"
+	"plandex/fs"
+	"plandex/term"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/plandex/plandex/shared"
+)
+
+// MustUnapplyPlan undoes the most recent apply. Files are put back to how they were before it, files it created are removed, and its changes are marked pending again in the plan.
+func MustUnapplyPlan(autoConfirm bool) {
+	snapshot, err := getLatestApplySnapshot()
+
+	if err != nil {
+		term.OutputErrorAndExit("Error getting last apply: %v", err)
+	}
+
+	if snapshot == nil {
+		fmt.Println("🤷‍♂️ No applied changes to undo")
+		return
+	}
+
+	var changedFiles []string
+	for _, file := range snapshot.Files {
+		bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, file.Path))
+		if err != nil && !os.IsNotExist(err) {
+			term.OutputErrorAndExit("failed to read %s: %v", file.Path, err)
+		}
+		if err != nil || contentSha(string(bytes)) != file.AppliedSha {
+			changedFiles = append(changedFiles, file.Path)
+		}
+	}
+
+	fmt.Printf("↩️  Undo the apply from %s", format.Time(snapshot.CreatedAt))
+	if snapshot.PlanId != CurrentPlanId || snapshot.Branch != CurrentBranch {
+		fmt.Printf(" on branch %s of another plan", color.New(color.Bold).Sprint(snapshot.Branch))
+	}
+	fmt.Println()
+	fmt.Println()
+
+	for _, file := range snapshot.Files {
+		if file.Existed {
+			fmt.Printf("  • restore %s\n", file.Path)
+		} else {
+			fmt.Printf("  • remove %s\n", file.Path)
+		}
+	}
+
+	if len(changedFiles) > 0 {
+		fmt.Println()
+		fmt.Println("⚠️  " + color.New(term.ColorHiYellow, color.Bold).Sprint("These files were changed after the apply, and those changes will be lost:"))
+		for _, path := range changedFiles {
+			fmt.Printf("  • %s\n", path)
+		}
+	}
+
+	fmt.Println()
+
+	if !autoConfirm || len(changedFiles) > 0 {
+		shouldContinue, err := term.ConfirmYesNo("Undo the apply?")
+
+		if err != nil {
+			term.OutputErrorAndExit("failed to get confirmation user input: %s", err)
+		}
+
+		if !shouldContinue {
+			fmt.Println("Unapply canceled")
+			return
+		}
+	}
+
+	term.StartSpinner("")
+
+	// files are restored before the server is updated, so a failure on either side leaves the apply as it was rather than half undone
+	rollback, err := restoreApplySnapshotFiles(snapshot)
+
+	if err != nil {
+		term.StopSpinner()
+		term.OutputErrorAndExit("failed to restore files: %v", err)
+	}
+
+	apiErr := api.Client.UnapplyPlan(snapshot.PlanId, snapshot.Branch, shared.UnapplyPlanRequest{
+		ResultIds:           snapshot.ResultIds,
+		ContextBodiesByPath: snapshot.ContextBodiesByPath,
+		RemoveContextPaths:  snapshot.RemoveContextPaths,
+	})
+
+	if apiErr != nil {
+		term.StopSpinner()
+		if err := rollback(); err != nil {
+			term.OutputErrorAndExit("failed to mark applied changes pending: %s, and failed to put back the applied files: %v", apiErr.Msg, err)
+		}
+		term.OutputErrorAndExit("failed to mark applied changes pending, so the applied files were left as they were: %s", apiErr.Msg)
+	}
+
+	err = removeApplySnapshot(snapshot.Id)
+
+	term.StopSpinner()
+
+	if err != nil {
+		term.OutputErrorAndExit("%v", err)
+	}
+
+	suffix := ""
+	if len(snapshot.Files) != 1 {
+		suffix = "s"
+	}
+	fmt.Printf("✅ Undid the apply, %d file%s restored and the changes are pending again\n", len(snapshot.Files), suffix)
+
+	if fs.ProjectRootIsGitRepo() {
+		fmt.Println()
+		fmt.Println("ℹ️  Commits made when applying aren't reverted")
+	}
+
+	fmt.Println()
+	term.PrintCmds("", "changes", "apply")
+}
+
+// restoreApplySnapshotFiles puts the files an apply changed back how they were before it, and removes the files it created. If that fails partway, the files already restored are put back how they were. The returned rollback does the same after a successful restore.
+func restoreApplySnapshotFiles(snapshot *applySnapshot) (func() error, error) {
+	// each file as it was before the restore, in the order they were restored
+	var replaced []*applySnapshotFile
+
+	rollback := func() error {
+		var errs []string
+		for i := len(replaced) - 1; i >= 0; i-- {
+			if err := writeApplySnapshotFile(replaced[i]); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+		return nil
+	}
+
+	for _, file := range snapshot.Files {
+		bytes, err := os.ReadFile(filepath.Join(fs.ProjectRoot, file.Path))
+		if err != nil && !os.IsNotExist(err) {
+			err = fmt.Errorf("failed to read %s: %v", file.Path, err)
+		} else {
+			replaced = append(replaced, &applySnapshotFile{Path: file.Path, Existed: err == nil, Content: string(bytes)})
+			err = writeApplySnapshotFile(file)
+		}
+
+		if err != nil {
+			if rollbackErr := rollback(); rollbackErr != nil {
+				return nil, fmt.Errorf("%v, and failed to put back the files already restored: %v", err, rollbackErr)
+			}
+			return nil, err
+		}
+	}
+
+	return rollback, nil
+}
+
+// writeApplySnapshotFile writes a file's content, or removes it if it didn't exist
+func writeApplySnapshotFile(file *applySnapshotFile) error {
+	dstPath := filepath.Join(fs.ProjectRoot, file.Path)
+
+	if !file.Existed {
+		err := os.Remove(dstPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", dstPath, err)
+		}
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(dstPath), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory %s: %v", filepath.Dir(dstPath), err)
+	}
+
+	err = os.WriteFile(dstPath, []byte(file.Content), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", dstPath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package lib
+
+import (
+	"plandex/types"
+	"reflect"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func newPendingPlanState() *shared.CurrentPlanState {
+	results := []*shared.PlanFileResult{
+		{Id: "result-1", Path: "main.go", Content: "package main // updated\n"},
+		{Id: "result-2", Path: "pkg/new.go", Content: "package pkg\n"},
+	}
+
+	state := &shared.CurrentPlanState{
+		PlanResult: &shared.PlanResult{
+			Results:           results,
+			FileResultsByPath: shared.PlanFileResultsByPath{},
+		},
+		CurrentPlanFiles: &shared.CurrentPlanFiles{Files: map[string]string{}},
+		ContextsByPath: map[string]*shared.Context{
+			"main.go": {ContextType: shared.ContextFileType, FilePath: "main.go", Body: "package main\n"},
+		},
+	}
+	for _, result := range results {
+		state.PlanResult.FileResultsByPath[result.Path] = []*shared.PlanFileResult{result}
+		state.CurrentPlanFiles.Files[result.Path] = result.Content
+	}
+
+	return state
+}
+
+func TestApplyThenUnapply(t *testing.T) {
+	client := &fakeApplyClient{state: newPendingPlanState()}
+	useTempProject(t, client)
+
+	writeProjectFile(t, "main.go", "package main\n")
+	writeProjectFile(t, "untouched.go", "package main // untouched\n")
+
+	MustApplyPlan("plan", "main", &types.ApplyPlanParams{AutoConfirm: true})
+
+	if client.numApplied != 1 {
+		t.Fatalf("ApplyPlan called %d times, want 1", client.numApplied)
+	}
+	if content, _ := readProjectFile(t, "main.go"); content != "package main // updated\n" {
+		t.Errorf("main.go wasn't updated: %q", content)
+	}
+	if content, ok := readProjectFile(t, "pkg/new.go"); !ok || content != "package pkg\n" {
+		t.Errorf("pkg/new.go wasn't created")
+	}
+
+	MustUnapplyPlan(true)
+
+	if content, _ := readProjectFile(t, "main.go"); content != "package main\n" {
+		t.Errorf("main.go wasn't restored: %q", content)
+	}
+	if _, ok := readProjectFile(t, "pkg/new.go"); ok {
+		t.Errorf("pkg/new.go was created by the apply but wasn't removed")
+	}
+	if content, _ := readProjectFile(t, "untouched.go"); content != "package main // untouched\n" {
+		t.Errorf("a file the apply didn't change was changed: %q", content)
+	}
+
+	if client.unapplyReq == nil {
+		t.Fatal("UnapplyPlan wasn't called")
+	}
+	want := shared.UnapplyPlanRequest{
+		ResultIds:           []string{"result-1", "result-2"},
+		ContextBodiesByPath: map[string]string{"main.go": "package main\n"},
+		RemoveContextPaths:  []string{"pkg/new.go"},
+	}
+	if !reflect.DeepEqual(*client.unapplyReq, want) {
+		t.Errorf("unapply request = %+v, want %+v", *client.unapplyReq, want)
+	}
+
+	if snapshot, err := getLatestApplySnapshot(); err != nil || snapshot != nil {
+		t.Errorf("the snapshot wasn't removed after unapply (err %v)", err)
+	}
+}
+
+func TestRestoreApplySnapshotFilesRollback(t *testing.T) {
+	useTempProject(t, nil)
+
+	writeProjectFile(t, "a.go", "a applied")
+	writeProjectFile(t, "created.go", "created")
+
+	snapshot := &applySnapshot{
+		Files: []*applySnapshotFile{
+			{Path: "a.go", Existed: true, Content: "a before"},
+			{Path: "created.go"},
+		},
+	}
+
+	// the server couldn't be updated, so the restore is rolled back
+	rollback, err := restoreApplySnapshotFiles(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := readProjectFile(t, "a.go"); content != "a before" {
+		t.Errorf("a.go wasn't restored: %q", content)
+	}
+	if _, ok := readProjectFile(t, "created.go"); ok {
+		t.Errorf("created.go wasn't removed")
+	}
+
+	if err := rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := readProjectFile(t, "a.go"); content != "a applied" {
+		t.Errorf("a.go wasn't put back after rollback: %q", content)
+	}
+	if content, _ := readProjectFile(t, "created.go"); content != "created" {
+		t.Errorf("created.go wasn't put back after rollback: %q", content)
+	}
+
+	// a file that can't be written undoes the files restored before it
+	writeProjectFile(t, "blocked/placeholder", "")
+	snapshot.Files = append(snapshot.Files, &applySnapshotFile{Path: "blocked", Existed: true, Content: "can't write over a directory"})
+
+	if _, err := restoreApplySnapshotFiles(snapshot); err == nil {
+		t.Fatal("expected an error writing over a directory")
+	}
+	if content, _ := readProjectFile(t, "a.go"); content != "a applied" {
+		t.Errorf("a.go wasn't put back after a failed restore: %q", content)
+	}
+	if content, _ := readProjectFile(t, "created.go"); content != "created" {
+		t.Errorf("created.go wasn't put back after a failed restore: %q", content)
+	}
+}
@@ -0,0 +1,214 @@
+package merge
+
+import "strings"
+
+// past this many line edits between two versions, lines aren't matched up and the whole region is treated as changed, which keeps diffing bounded for rewritten files
+const maxEditDistance = 2000
+
+const (
+	conflictStartMarker = "<<<<<<<"
+	conflictSepMarker   = "======="
+	conflictEndMarker   = ">>>>>>>"
+)
+
+// Merge does a line-based three-way merge of ours and theirs, which were both changed from base. Changes to different parts of base are combined. Where both sides changed the same lines differently, both versions are kept between conflict markers labeled with oursLabel and theirsLabel. It returns the merged text and the number of conflicts.
+func Merge(base, ours, theirs, oursLabel, theirsLabel string) (string, int) {
+	baseLines := splitLines(base)
+	oursLines := splitLines(ours)
+	theirsLines := splitLines(theirs)
+
+	oursMatches := matchLines(baseLines, oursLines)
+	theirsMatches := matchLines(baseLines, theirsLines)
+
+	var sb strings.Builder
+	numConflicts := 0
+
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			sb.WriteString(line)
+		}
+	}
+
+	// resolve the lines between two points where all three versions agree
+	writeChunk := func(baseChunk, oursChunk, theirsChunk []string) {
+		switch {
+		case equalLines(oursChunk, baseChunk):
+			writeLines(theirsChunk)
+		case equalLines(theirsChunk, baseChunk), equalLines(oursChunk, theirsChunk):
+			writeLines(oursChunk)
+		default:
+			numConflicts++
+			sb.WriteString(conflictStartMarker + " " + oursLabel + "\n")
+			writeLines(terminated(oursChunk))
+			sb.WriteString(conflictSepMarker + "\n")
+			writeLines(terminated(theirsChunk))
+			sb.WriteString(conflictEndMarker + " " + theirsLabel + "\n")
+		}
+	}
+
+	i, a, b := 0, 0, 0
+	for k := 0; k < len(baseLines); k++ {
+		if oursMatches[k] == -1 || theirsMatches[k] == -1 {
+			continue
+		}
+
+		writeChunk(baseLines[i:k], oursLines[a:oursMatches[k]], theirsLines[b:theirsMatches[k]])
+		sb.WriteString(baseLines[k])
+
+		i, a, b = k+1, oursMatches[k]+1, theirsMatches[k]+1
+	}
+	writeChunk(baseLines[i:], oursLines[a:], theirsLines[b:])
+
+	return sb.String(), numConflicts
+}
+
+// HasConflictMarkers returns whether content still has conflict markers left by Merge
+func HasConflictMarkers(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, conflictStartMarker+" ") || strings.HasPrefix(line, conflictEndMarker+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines keeps each line's newline so the merge reproduces the original line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// terminated adds a newline to a chunk's last line if it's missing so a marker that follows starts on its own line
+func terminated(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	res := append([]string{}, lines...)
+	res[len(res)-1] += "\n"
+	return res
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLines returns, for each line of a, the index of the line of b it's matched with in a longest common subsequence, or -1 if it was changed or removed
+func matchLines(a, b []string) []int {
+	res := make([]int, len(a))
+	for i := range res {
+		res[i] = -1
+	}
+
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		res[start] = start
+		start++
+	}
+
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+		res[endA] = endB
+	}
+
+	for _, pair := range diffMatches(a[start:endA], b[start:endB]) {
+		res[start+pair[0]] = start + pair[1]
+	}
+
+	return res
+}
+
+// diffMatches finds the matching lines of a and b with Myers' diff algorithm
+func diffMatches(a, b []string) [][2]int {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return nil
+	}
+
+	maxD := min(n+m, maxEditDistance)
+	offset := maxD + 1
+	v := make([]int, 2*offset+1)
+
+	// the furthest x reached on each diagonal after each number of edits, for walking back the path
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int{}, v[offset-d:offset+d+1]...))
+				return backtrack(trace, n, m)
+			}
+		}
+		trace = append(trace, append([]int{}, v[offset-d:offset+d+1]...))
+	}
+
+	return nil
+}
+
+func backtrack(trace [][]int, n, m int) [][2]int {
+	var pairs [][2]int
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			pairs = append(pairs, [2]int{x, y})
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		pairs = append(pairs, [2]int{x, y})
+	}
+
+	// reversed into the order the lines appear
+	for i, j := 0, len(pairs)-1; i < j; i, j = i+1, j-1 {
+		pairs[i], pairs[j] = pairs[j], pairs[i]
+	}
+
+	return pairs
+}
@@ -0,0 +1,154 @@
+package merge
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         string
+		ours         string
+		theirs       string
+		want         string
+		numConflicts int
+	}{
+		{
+			name:   "non-overlapping edits are combined",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "a\nB\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "a\nB\nc\nd\nE\n",
+		},
+		{
+			name:   "insertions and removals on different lines",
+			base:   "a\nb\nc\nd\n",
+			ours:   "a\nnew\nb\nc\nd\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nnew\nb\nc\n",
+		},
+		{
+			name:         "overlapping edits conflict",
+			base:         "a\nb\nc\n",
+			ours:         "a\nmine\nc\n",
+			theirs:       "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> plandex\nc\n",
+			numConflicts: 1,
+		},
+		{
+			name:         "separate conflicts are counted",
+			base:         "a\nb\nc\nd\ne\n",
+			ours:         "A1\nb\nc\nd\nE1\n",
+			theirs:       "A2\nb\nc\nd\nE2\n",
+			want:         "<<<<<<< local\nA1\n=======\nA2\n>>>>>>> plandex\nb\nc\nd\n<<<<<<< local\nE1\n=======\nE2\n>>>>>>> plandex\n",
+			numConflicts: 2,
+		},
+		{
+			name:   "identical edits on both sides",
+			base:   "a\nb\nc\n",
+			ours:   "a\nsame\nc\nadded\n",
+			theirs: "a\nsame\nc\nadded\n",
+			want:   "a\nsame\nc\nadded\n",
+		},
+		{
+			name:   "no newline at end of file is kept",
+			base:   "a\nb\nc",
+			ours:   "A\nb\nc",
+			theirs: "a\nb\nC",
+			want:   "A\nb\nC",
+		},
+		{
+			name:         "conflict at end of file without a newline",
+			base:         "a\nb",
+			ours:         "a\nmine",
+			theirs:       "a\ntheirs",
+			want:         "a\n<<<<<<< local\nmine\n=======\ntheirs\n>>>>>>> plandex\n",
+			numConflicts: 1,
+		},
+		{
+			name:   "only one side changed",
+			base:   "a\nb\n",
+			ours:   "a\nb\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "only ours changed",
+			base:   "a\nb\n",
+			ours:   "a\nmine\n",
+			theirs: "a\nb\n",
+			want:   "a\nmine\n",
+		},
+		{
+			name:   "empty base",
+			base:   "",
+			ours:   "",
+			theirs: "new file\n",
+			want:   "new file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		got, numConflicts := Merge(tt.base, tt.ours, tt.theirs, "local", "plandex")
+		if got != tt.want {
+			t.Errorf("%s: got:\n%q\nwant:\n%q", tt.name, got, tt.want)
+		}
+		if numConflicts != tt.numConflicts {
+			t.Errorf("%s: got %d conflicts, want %d", tt.name, numConflicts, tt.numConflicts)
+		}
+		if HasConflictMarkers(got) != (tt.numConflicts > 0) {
+			t.Errorf("%s: HasConflictMarkers = %v", tt.name, HasConflictMarkers(got))
+		}
+	}
+}
+
+func TestMergeMaxEditDistance(t *testing.T) {
+	var base, rewritten []string
+	for i := 0; i < maxEditDistance; i++ {
+		base = append(base, fmt.Sprintf("base %d\n", i))
+		rewritten = append(rewritten, fmt.Sprintf("rewritten %d\n", i))
+	}
+
+	// past the limit nothing in the middle is matched, so the whole region is one change
+	baseText := "first\n" + strings.Join(base, "") + "last\n"
+	oursText := "first\n" + strings.Join(rewritten, "") + "last\n"
+
+	if matches := diffMatches(base, rewritten); matches != nil {
+		t.Errorf("expected no matches past maxEditDistance, got %d", len(matches))
+	}
+
+	got, numConflicts := Merge(baseText, oursText, baseText, "local", "plandex")
+	if got != oursText || numConflicts != 0 {
+		t.Errorf("a rewrite on one side wasn't taken as is (%d conflicts)", numConflicts)
+	}
+
+	theirsText := strings.Replace(baseText, "base 10\n", "changed 10\n", 1)
+	got, numConflicts = Merge(baseText, oursText, theirsText, "local", "plandex")
+	if numConflicts != 1 {
+		t.Errorf("got %d conflicts, want the rewritten region as a single conflict", numConflicts)
+	}
+	if !strings.HasPrefix(got, "first\n<<<<<<< local\nrewritten 0\n") || !strings.HasSuffix(got, ">>>>>>> plandex\nlast\n") {
+		t.Errorf("the unchanged first and last lines should stay outside the conflict")
+	}
+}
+
+func TestHasConflictMarkers(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"plain\ntext\n", false},
+		{"a\n<<<<<<< local\nb\n", true},
+		{"a\n>>>>>>> plandex\n", true},
+		{"// <<<<<<< in a comment\n", false},
+		{"=======\n", false},
+	}
+
+	for _, tt := range tests {
+		if got := HasConflictMarkers(tt.content); got != tt.want {
+			t.Errorf("HasConflictMarkers(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Rule matches one kind of secret. If the regex has a capture group, only the first group is the secret and the rest of the match is left in place.
+type Rule struct {
+	Name  string
+	Regex *regexp.Regexp
+	// if set, a secret needs at least this much entropy (bits per char) and both letters and digits to count, which keeps ordinary words and identifiers from matching
+	MinEntropy float64
+}
+
+// Finding is a secret found in a body. The secret itself isn't kept so findings are safe to print.
+type Finding struct {
+	Rule string
+	Line int
+}
+
+var builtInRules = []*Rule{
+	{Name: "private-key", Regex: regexp.MustCompile(`-----BEGIN[ A-Z0-9]*PRIVATE KEY-----[\s\S]*?-----END[ A-Z0-9]*PRIVATE KEY-----`)},
+	{Name: "aws-access-key", Regex: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "github-token", Regex: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{Name: "gitlab-token", Regex: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{Name: "slack-token", Regex: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{Name: "stripe-key", Regex: regexp.MustCompile(`\b(?:sk|rk)_live_[A-Za-z0-9]{20,}\b`)},
+	{Name: "model-api-key", Regex: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+	{Name: "google-api-key", Regex: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{Name: "jwt", Regex: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{
+		Name:       "secret-assignment",
+		Regex:      regexp.MustCompile(`(?i)(?:key|secret|token|passw(?:or)?d|pwd|credential)[\w.-]*["']?\s*[:=]+\s*["']?([A-Za-z0-9+/=_\-.~]{16,})`),
+		MinEntropy: 3.5,
+	},
+	{
+		Name:       "high-entropy-string",
+		Regex:      regexp.MustCompile("[\"'`]([A-Za-z0-9+/=_-]{32,})[\"'`]"),
+		MinEntropy: 4.0,
+	},
+}
+
+func BuiltInRules() []*Rule {
+	return builtInRules
+}
+
+type match struct {
+	rule       string
+	start, end int
+}
+
+// Redact replaces each secret in body with a placeholder naming the rule it matched. Placeholders include a short hash of the secret so Restore can put the original back, and are padded with newlines for multi-line secrets so line numbers don't shift.
+func Redact(body string, rules []*Rule) (string, []*Finding) {
+	redacted, findings, _ := redact(body, rules)
+	return redacted, findings
+}
+
+// Restore puts back secrets that were redacted from original wherever their placeholders appear in content, so changes built against a redacted body don't overwrite secrets on disk
+func Restore(content, original string, rules []*Rule) string {
+	if !strings.Contains(content, "[redacted:") {
+		return content
+	}
+
+	_, _, secretsByPlaceholder := redact(original, rules)
+	for placeholder, secret := range secretsByPlaceholder {
+		// the line padding after a multi-line secret's placeholder is removed with it if it's still there
+		content = strings.ReplaceAll(content, placeholder+linePadding(secret), secret)
+		content = strings.ReplaceAll(content, placeholder, secret)
+	}
+
+	return content
+}
+
+func redact(body string, rules []*Rule) (string, []*Finding, map[string]string) {
+	var matches []match
+
+	for _, rule := range rules {
+		for _, loc := range rule.Regex.FindAllStringSubmatchIndex(body, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+
+			if rule.MinEntropy > 0 && !looksRandom(body[start:end], rule.MinEntropy) {
+				continue
+			}
+
+			matches = append(matches, match{rule: rule.Name, start: start, end: end})
+		}
+	}
+
+	if len(matches) == 0 {
+		return body, nil, nil
+	}
+
+	// when matches overlap, the one that starts first is kept
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+
+	var sb strings.Builder
+	var findings []*Finding
+	secretsByPlaceholder := map[string]string{}
+	pos := 0
+
+	for _, m := range matches {
+		if m.start < pos {
+			continue
+		}
+
+		secret := body[m.start:m.end]
+		hash := sha256.Sum256([]byte(secret))
+		placeholder := fmt.Sprintf("[redacted:%s:%s]", m.rule, hex.EncodeToString(hash[:4]))
+
+		sb.WriteString(body[pos:m.start])
+		sb.WriteString(placeholder)
+		sb.WriteString(linePadding(secret))
+		pos = m.end
+
+		secretsByPlaceholder[placeholder] = secret
+		findings = append(findings, &Finding{
+			Rule: m.rule,
+			Line: strings.Count(body[:m.start], "\n") + 1,
+		})
+	}
+	sb.WriteString(body[pos:])
+
+	return sb.String(), findings, secretsByPlaceholder
+}
+
+func linePadding(secret string) string {
+	return strings.Repeat("\n", strings.Count(secret, "\n"))
+}
+
+func looksRandom(s string, minEntropy float64) bool {
+	if !strings.ContainsAny(s, "0123456789") || strings.IndexFunc(s, func(r rune) bool { return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') }) == -1 {
+		return false
+	}
+
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+
+	var entropy float64
+	n := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+
+	if entropy < minEntropy {
+		return false
+	}
+
+	// identifiers like 'config_value_for_production_2024' can have high entropy too, but they break into a few word-length runs where random strings break into many short ones
+	return averageRunLength(s) < minWordRunLength
+}
+
+const minWordRunLength = 4
+
+// averageRunLength splits s at separators, lower to upper case changes, and letter to digit changes, and returns the average length of the runs between them
+func averageRunLength(s string) float64 {
+	numRuns, numChars := 0, 0
+	var prev rune
+
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			prev = 0
+			continue
+		}
+
+		if prev == 0 || unicode.IsDigit(r) != unicode.IsDigit(prev) || (unicode.IsLower(prev) && unicode.IsUpper(r)) {
+			numRuns++
+		}
+		numChars++
+		prev = r
+	}
+
+	if numRuns == 0 {
+		return 0
+	}
+
+	return float64(numChars) / float64(numRuns)
+}
@@ -0,0 +1,188 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+// samples are split up so the test file itself doesn't look like it holds secrets
+var ruleSamples = []struct {
+	rule   string
+	body   string
+	secret string
+}{
+	{
+		rule:   "private-key",
+		body:   "key := `-----BEGIN RSA " + "PRIVATE KEY-----\nMIIBOgIBAAJBAKj34GkxFhD90vcNLYLInFEX6Ppy1tPf9Cnzj4p4WGeKLs1Pt8Qu\nKUpRKfFLfRYC9AIKjbJTWit+CqvjWYzvQwECAwEAAQ==\n-----END RSA " + "PRIVATE KEY-----`",
+		secret: "-----BEGIN RSA " + "PRIVATE KEY-----\nMIIBOgIBAAJBAKj34GkxFhD90vcNLYLInFEX6Ppy1tPf9Cnzj4p4WGeKLs1Pt8Qu\nKUpRKfFLfRYC9AIKjbJTWit+CqvjWYzvQwECAwEAAQ==\n-----END RSA " + "PRIVATE KEY-----",
+	},
+	{
+		rule:   "aws-access-key",
+		body:   "aws_access_key_id = " + "AKIA" + "IOSFODNN7EXAMPLE",
+		secret: "AKIA" + "IOSFODNN7EXAMPLE",
+	},
+	{
+		rule:   "github-token",
+		body:   "export GH=" + "ghp_" + "aB3dE5fG7hI9jK1lM3nO5pQ7rS9tU1vW3xY5",
+		secret: "ghp_" + "aB3dE5fG7hI9jK1lM3nO5pQ7rS9tU1vW3xY5",
+	},
+	{
+		rule:   "gitlab-token",
+		body:   "CI_TOKEN: " + "glpat-" + "x7Yk2Pq9Lm4Nw8Rt3Vb6",
+		secret: "glpat-" + "x7Yk2Pq9Lm4Nw8Rt3Vb6",
+	},
+	{
+		rule:   "slack-token",
+		body:   "webhook(" + "xoxb-" + "1234567890-AbCdEfGhIj)",
+		secret: "xoxb-" + "1234567890-AbCdEfGhIj",
+	},
+	{
+		rule:   "stripe-key",
+		body:   "stripe.init(" + "sk_live_" + "4eC39HqLyjWDarjtT1zdp7dc)",
+		secret: "sk_live_" + "4eC39HqLyjWDarjtT1zdp7dc",
+	},
+	{
+		rule:   "model-api-key",
+		body:   "client = Anthropic(api=" + "sk-ant-" + "api03-Zx8Qw2Er4Ty6Ui8Op0As)",
+		secret: "sk-ant-" + "api03-Zx8Qw2Er4Ty6Ui8Op0As",
+	},
+	{
+		rule:   "google-api-key",
+		body:   "maps.load(" + "AIza" + "SyA1b2C3d4E5f6G7h8I9j0K1l2M3n4O5p6Q)",
+		secret: "AIza" + "SyA1b2C3d4E5f6G7h8I9j0K1l2M3n4O5p6Q",
+	},
+	{
+		rule:   "jwt",
+		body:   "Authorization: Bearer " + "eyJhbGciOiJIUzI1NiJ9" + ".eyJzdWIiOiIxMjM0NTY3ODkwIn0.dozjgNryP4J3jVmNHl0w5N_XgL0n3I9PlFUP0THsR8U",
+		secret: "eyJhbGciOiJIUzI1NiJ9" + ".eyJzdWIiOiIxMjM0NTY3ODkwIn0.dozjgNryP4J3jVmNHl0w5N_XgL0n3I9PlFUP0THsR8U",
+	},
+	{
+		rule:   "secret-assignment",
+		body:   `DB_PASSWORD = "q8Zr4Lm2Xw9Tb7Nc"`,
+		secret: "q8Zr4Lm2Xw9Tb7Nc",
+	},
+	{
+		rule:   "high-entropy-string",
+		body:   `const seed = "a8F3kL9qZ2mX7vB4nR6tY1wC5pH0jD3s"`,
+		secret: "a8F3kL9qZ2mX7vB4nR6tY1wC5pH0jD3s",
+	},
+}
+
+func TestBuiltInRules(t *testing.T) {
+	covered := map[string]bool{}
+
+	for _, tt := range ruleSamples {
+		covered[tt.rule] = true
+
+		redacted, findings := Redact(tt.body, BuiltInRules())
+
+		if len(findings) != 1 || findings[0].Rule != tt.rule {
+			var rules []string
+			for _, finding := range findings {
+				rules = append(rules, finding.Rule)
+			}
+			t.Errorf("%s: got findings %v, want one %s finding", tt.rule, rules, tt.rule)
+			continue
+		}
+
+		if strings.Contains(redacted, tt.secret) {
+			t.Errorf("%s: secret wasn't masked: %s", tt.rule, redacted)
+		}
+		if !strings.Contains(redacted, "[redacted:"+tt.rule+":") {
+			t.Errorf("%s: placeholder missing: %s", tt.rule, redacted)
+		}
+		if strings.Count(redacted, "\n") != strings.Count(tt.body, "\n") {
+			t.Errorf("%s: line count changed", tt.rule)
+		}
+	}
+
+	for _, rule := range BuiltInRules() {
+		if !covered[rule.Name] {
+			t.Errorf("no sample for built-in rule %s", rule.Name)
+		}
+	}
+}
+
+func TestRedactIgnoresOrdinaryCode(t *testing.T) {
+	bodies := []string{
+		`const maxRetryCount = 3`,
+		`tokenizerVersion := "cl100k_base"`,
+		`password_reset_url = "/users/password/reset/confirm"`,
+		`func handleUserAuthenticationRequest2(w http.ResponseWriter) {}`,
+		`secretName: "production_database_credentials"`,
+		`import "github.com/plandex/plandex/shared"`,
+		`sha = "0000000000000000000000000000000000000000"`,
+	}
+
+	for _, body := range bodies {
+		if redacted, findings := Redact(body, BuiltInRules()); len(findings) > 0 {
+			t.Errorf("false positive in %q: %s", body, redacted)
+		}
+	}
+}
+
+func TestLooksRandom(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"handleUserAuthenticationRequest2", false},
+		{"TestRedactReplacesSecretsInPlace", false},
+		{"config_value_for_production_2024", false},
+		{"aaaaaaaaaaaaaaaa1111111111111111", false},
+		{"abcdefghijklmnopqrstuvwxyzabcdef", false}, // no digits
+		{"12345678901234567890123456789012", false}, // no letters
+		{"a8F3kL9qZ2mX7vB4nR6tY1wC5pH0jD3s", true},
+		{"q8Zr4Lm2Xw9Tb7Nc", true},
+	}
+
+	for _, tt := range tests {
+		if got := looksRandom(tt.s, 4.0); got != tt.want {
+			t.Errorf("looksRandom(%q, 4.0) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+
+	// the lower threshold used for values assigned to secret-sounding names
+	if !looksRandom("wJalrXUtnFEMI/K7MDENG/bPxRfiCY"+"EXAMPLEKEY", 3.5) {
+		t.Errorf("an AWS-style secret key wasn't flagged")
+	}
+	if !looksRandom("4f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c", 3.5) {
+		t.Errorf("a hex secret wasn't flagged")
+	}
+	if looksRandom("DefaultConnectionString2", 3.5) {
+		t.Errorf("an identifier was flagged")
+	}
+}
+
+func TestRestoreRoundTrip(t *testing.T) {
+	var parts []string
+	for _, tt := range ruleSamples {
+		parts = append(parts, tt.body)
+	}
+	original := "package main\n\n" + strings.Join(parts, "\n") + "\n"
+
+	redacted, findings := Redact(original, BuiltInRules())
+	if len(findings) != len(ruleSamples) {
+		t.Fatalf("got %d findings, want %d", len(findings), len(ruleSamples))
+	}
+
+	if restored := Restore(redacted, original, BuiltInRules()); restored != original {
+		t.Errorf("round trip changed the body:\n%s", restored)
+	}
+
+	// changes built against the redacted body get the secrets back, including a multi-line secret whose padding was edited away
+	edited := strings.Replace(redacted, "package main", "package main\n\n// edited", 1)
+	want := strings.Replace(original, "package main", "package main\n\n// edited", 1)
+	if restored := Restore(edited, original, BuiltInRules()); restored != want {
+		t.Errorf("secrets weren't restored in edited content:\n%s", restored)
+	}
+
+	placeholderOnly := "key := `" + strings.SplitN(strings.SplitN(redacted, "key := `", 2)[1], "\n", 2)[0] + "`"
+	if restored := Restore(placeholderOnly, original, BuiltInRules()); !strings.Contains(restored, ruleSamples[0].secret) {
+		t.Errorf("a multi-line secret wasn't restored without its padding: %s", restored)
+	}
+
+	if restored := Restore("no placeholders here", original, BuiltInRules()); restored != "no placeholders here" {
+		t.Errorf("content without placeholders was changed: %s", restored)
+	}
+}
@@ -0,0 +1,327 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// bump when the tokenizer or stored format changes so stale indexes are rebuilt
+const indexVersion = 1
+
+const (
+	maxIndexedFileSize = 1024 * 1024
+	pathTermWeight     = 3
+	bm25K1             = 1.2
+	bm25B              = 0.75
+)
+
+type indexedDoc struct {
+	ModTime int64          `json:"modTime"`
+	Size    int64          `json:"size"`
+	Length  int            `json:"length"`
+	Terms   map[string]int `json:"terms"`
+}
+
+// Index is a BM25 keyword index over project files, persisted as json and updated incrementally based on file mtimes.
+type Index struct {
+	Version int                    `json:"version"`
+	Docs    map[string]*indexedDoc `json:"docs"`
+
+	path string
+}
+
+type Result struct {
+	Path  string
+	Score float64
+}
+
+func LoadIndex(path string) (*Index, error) {
+	idx := &Index{
+		Version: indexVersion,
+		Docs:    map[string]*indexedDoc{},
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("error reading search index: %v", err)
+	}
+
+	var stored Index
+	err = json.Unmarshal(data, &stored)
+	if err != nil || stored.Version != indexVersion || stored.Docs == nil {
+		// a corrupt or outdated index is just rebuilt
+		return idx, nil
+	}
+
+	stored.path = path
+	return &stored, nil
+}
+
+func (idx *Index) Save() error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("error marshalling search index: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(idx.path), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("error creating search index dir: %v", err)
+	}
+
+	err = os.WriteFile(idx.path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing search index: %v", err)
+	}
+
+	return nil
+}
+
+// Update re-indexes any paths that are new or have changed since they were last indexed, and drops paths that are no longer present. Files that can't be read are logged and left out. It returns the number of files that were (re-)indexed.
+func (idx *Index) Update(paths []string) int {
+	current := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		current[path] = true
+	}
+
+	for path := range idx.Docs {
+		if !current[path] {
+			delete(idx.Docs, path)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	numIndexed := 0
+
+	// limit concurrent file reads
+	sem := make(chan struct{}, 16)
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			delete(idx.Docs, path)
+			continue
+		}
+
+		existing := idx.Docs[path]
+		if existing != nil && existing.ModTime == info.ModTime().UnixNano() && existing.Size == info.Size() {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(path string, info os.FileInfo) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			doc, err := indexFile(path, info)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				log.Printf("skipping %s in search index: %v\n", path, err)
+				delete(idx.Docs, path)
+				return
+			}
+
+			idx.Docs[path] = doc
+			numIndexed++
+		}(path, info)
+	}
+
+	wg.Wait()
+
+	return numIndexed
+}
+
+// Search ranks indexed files against the query with BM25 and returns up to limit results with a positive score.
+func (idx *Index) Search(query string, limit int) []Result {
+	queryTerms := uniqueTerms(tokenize(query))
+	if len(queryTerms) == 0 || len(idx.Docs) == 0 {
+		return nil
+	}
+
+	numDocs := float64(len(idx.Docs))
+	totalLength := 0
+	docFreqs := make(map[string]int, len(queryTerms))
+
+	for _, doc := range idx.Docs {
+		totalLength += doc.Length
+		for _, term := range queryTerms {
+			if doc.Terms[term] > 0 {
+				docFreqs[term]++
+			}
+		}
+	}
+
+	avgLength := float64(totalLength) / numDocs
+	if avgLength == 0 {
+		avgLength = 1
+	}
+
+	var results []Result
+	for path, doc := range idx.Docs {
+		score := 0.0
+		for _, term := range queryTerms {
+			tf := float64(doc.Terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreqs[term])
+			idf := math.Log(1 + (numDocs-df+0.5)/(df+0.5))
+			score += idf * (tf * (bm25K1 + 1)) / (tf + bm25K1*(1-bm25B+bm25B*float64(doc.Length)/avgLength))
+		}
+
+		if score > 0 {
+			results = append(results, Result{Path: path, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Path < results[j].Path
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
+
+func indexFile(path string, info os.FileInfo) (*indexedDoc, error) {
+	doc := &indexedDoc{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Terms:   map[string]int{},
+	}
+
+	// path terms count for more than content terms since file names are usually a strong signal
+	for _, term := range tokenize(path) {
+		doc.Terms[term] += pathTermWeight
+		doc.Length += pathTermWeight
+	}
+
+	// large, binary, and image files are only indexed by path
+	if info.Size() > maxIndexedFileSize || shared.IsImagePath(path) {
+		return doc, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	if isBinary(content) {
+		return doc, nil
+	}
+
+	for _, term := range tokenize(string(content)) {
+		doc.Terms[term]++
+		doc.Length++
+	}
+
+	return doc, nil
+}
+
+func isBinary(content []byte) bool {
+	sample := content
+	if len(sample) > 8000 {
+		sample = sample[:8000]
+	}
+	return bytes.IndexByte(sample, 0) != -1
+}
+
+// tokenize splits text into lowercase terms on non-alphanumeric characters, and additionally splits camelCase and snake_case identifiers into their parts
+func tokenize(text string) []string {
+	var terms []string
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+
+	for _, word := range words {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			if term := normalizeTerm(word); term != "" {
+				terms = append(terms, term)
+			}
+		}
+		for _, part := range parts {
+			if term := normalizeTerm(part); term != "" {
+				terms = append(terms, term)
+			}
+		}
+	}
+
+	return terms
+}
+
+func splitIdentifier(word string) []string {
+	var parts []string
+
+	for _, snakePart := range strings.Split(word, "_") {
+		runes := []rune(snakePart)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			// split on lower->upper transitions and before the last upper in a run of uppers followed by a lower (e.g. HTTPServer -> HTTP, Server)
+			if unicode.IsUpper(runes[i]) &&
+				(unicode.IsLower(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]))) {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+
+	return parts
+}
+
+func normalizeTerm(term string) string {
+	term = strings.ToLower(strings.Trim(term, "_"))
+	if len(term) < 2 || len(term) > 64 || stopWords[term] {
+		return ""
+	}
+	return term
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	var res []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			res = append(res, term)
+		}
+	}
+	return res
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "or": true, "of": true, "to": true, "in": true, "is": true, "it": true,
+	"for": true, "on": true, "with": true, "as": true, "at": true, "by": true, "be": true, "this": true,
+	"that": true, "an": true, "are": true, "from": true, "if": true, "we": true, "so": true, "do": true,
+	"can": true, "all": true, "not": true, "but": true, "into": true, "should": true, "would": true,
+	"please": true, "make": true, "add": true, "use": true, "using": true, "need": true, "want": true,
+}
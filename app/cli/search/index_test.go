@@ -0,0 +1,171 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"parseHTTPServer", []string{"parsehttpserver", "parse", "http", "server"}},
+		{"load_context_file", []string{"load_context_file", "load", "context", "file"}},
+		{"Fix the login flow", []string{"fix", "login", "flow"}},
+		{"a b x9 // --", []string{"x9"}},
+		{"cli/lib/apply.go", []string{"cli", "lib", "apply", "go"}},
+	}
+
+	for _, tt := range tests {
+		got := tokenize(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) []string {
+	t.Helper()
+
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestSearchRanksByRelevance(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeFiles(t, dir, map[string]string{
+		"auth/session.go":   "func refreshSession(token string) { validateToken(token); refreshSession(token) }",
+		"billing/stripe.go": "func chargeCard(amount int) { createInvoice(amount) }",
+		"docs/notes.md":     "The session timeout is configurable.",
+	})
+
+	idx := &Index{Version: indexVersion, Docs: map[string]*indexedDoc{}}
+	if n := idx.Update(paths); n != 3 {
+		t.Fatalf("indexed %d files, want 3", n)
+	}
+
+	results := idx.Search("refresh the session token", 0)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2: %v", len(results), results)
+	}
+	if results[0].Path != filepath.Join(dir, "auth/session.go") {
+		t.Errorf("top result = %s, want auth/session.go", results[0].Path)
+	}
+	if results[0].Score <= results[1].Score {
+		t.Errorf("results aren't sorted by score: %v", results)
+	}
+
+	if got := idx.Search("stripe", 1); len(got) != 1 || got[0].Path != filepath.Join(dir, "billing/stripe.go") {
+		t.Errorf("path terms should match: got %v", got)
+	}
+
+	if got := idx.Search("the and of", 0); got != nil {
+		t.Errorf("stop words alone should match nothing, got %v", got)
+	}
+}
+
+func TestUpdateIsIncremental(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeFiles(t, dir, map[string]string{
+		"a.go": "package a // alpha",
+		"b.go": "package b // beta",
+	})
+
+	idxPath := filepath.Join(dir, "index", "search_index.json")
+	idx, err := LoadIndex(idxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := idx.Update(paths); n != 2 {
+		t.Fatalf("indexed %d files, want 2", n)
+	}
+	if err := idx.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err = LoadIndex(idxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := idx.Update(paths); n != 0 {
+		t.Errorf("unchanged files were re-indexed: %d", n)
+	}
+
+	// a different size and mtime mark the file as changed
+	aPath := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(aPath, []byte("package a // gamma delta"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(aPath, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := idx.Update(paths); n != 1 {
+		t.Errorf("re-indexed %d files, want 1", n)
+	}
+	if got := idx.Search("gamma", 0); len(got) != 1 {
+		t.Errorf("changed content wasn't indexed: %v", got)
+	}
+
+	// paths that are no longer passed, or that are gone, are dropped
+	if err := os.Remove(aPath); err != nil {
+		t.Fatal(err)
+	}
+	idx.Update(paths)
+	if len(idx.Docs) != 1 {
+		t.Errorf("got %d docs after removing a file, want 1", len(idx.Docs))
+	}
+}
+
+func TestUpdateSkipsUnreadableFiles(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions don't apply to root")
+	}
+
+	dir := t.TempDir()
+	paths := writeFiles(t, dir, map[string]string{
+		"ok.go":     "package ok",
+		"secret.go": "package secret",
+	})
+	if err := os.Chmod(filepath.Join(dir, "secret.go"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := &Index{Version: indexVersion, Docs: map[string]*indexedDoc{}}
+	if n := idx.Update(paths); n != 1 {
+		t.Errorf("indexed %d files, want 1", n)
+	}
+	if _, ok := idx.Docs[filepath.Join(dir, "ok.go")]; !ok {
+		t.Errorf("readable file wasn't indexed")
+	}
+}
+
+func TestLoadIndexRebuildsOutdatedVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "search_index.json")
+	if err := os.WriteFile(path, []byte(`{"version":0,"docs":{"x.go":{"length":1,"terms":{"x":1}}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := LoadIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Docs) != 0 {
+		t.Errorf("outdated index wasn't discarded: %v", idx.Docs)
+	}
+}
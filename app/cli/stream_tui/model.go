@@ -27,6 +27,14 @@ var missingFileSelectOpts = []string{
 	MissingFileOverwriteLabel,
 }
 
+// a file loaded as an outline is always built against the full file, so overwriting isn't offered
+func (m streamUIModel) numMissingFileOpts() int {
+	if m.missingFileOutline {
+		return 2
+	}
+	return len(missingFileSelectOpts)
+}
+
 type streamUIModel struct {
 	buildOnly bool
 	keymap    keymap
@@ -56,13 +64,26 @@ type streamUIModel struct {
 	promptedMissingFile    bool
 	missingFileContent     string
 	missingFileTokens      int
+	missingFileOutline     bool
 
 	prompt string
 
+	// set when context was trimmed to fit the planner's token budget
+	contextBudget *shared.ContextBudgetReport
+
+	// the latest auto-continue decision. A decision to continue is cleared when the next reply starts
+	autoContinue *shared.AutoContinueDecision
+
 	stopped    bool
 	background bool
 	finished   bool
 
+	replaying   bool
+	replaySpeed int
+
+	watching bool
+	watchers []*shared.StreamWatcher
+
 	err    error
 	apiErr *shared.ApiError
 }
@@ -78,7 +99,14 @@ type keymap = struct {
 	up,
 	down,
 	quit,
-	enter bubbleKey.Binding
+	enter,
+	faster,
+	skipToEnd bubbleKey.Binding
+}
+
+// replayed and watched streams are read-only
+func (m streamUIModel) canControl() bool {
+	return !m.replaying && !m.watching
 }
 
 func (m streamUIModel) Init() tea.Cmd {
@@ -152,6 +180,16 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 				bubbleKey.WithKeys("G", "end"),
 				bubbleKey.WithHelp("G", "end"),
 			),
+
+			faster: bubbleKey.NewBinding(
+				bubbleKey.WithKeys("f"),
+				bubbleKey.WithHelp("f", "faster"),
+			),
+
+			skipToEnd: bubbleKey.NewBinding(
+				bubbleKey.WithKeys("e"),
+				bubbleKey.WithHelp("e", "skip to end"),
+			),
 		},
 
 		tokensByPath:   make(map[string]int),
@@ -159,6 +197,7 @@ func initialModel(prestartReply, prompt string, buildOnly bool) *streamUIModel {
 		spinner:        s,
 		atScrollBottom: true,
 		starting:       true,
+		replaySpeed:    1,
 	}
 
 	return &initialState
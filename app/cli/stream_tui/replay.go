@@ -0,0 +1,72 @@
+package streamtui
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+const maxReplaySpeed = 64
+
+// long pauses in the original stream (waiting on a model response or a missing file prompt) are capped during replay
+const maxReplayDelay = 3 * time.Second
+
+var replaySpeed atomic.Int32
+var replaySkipToEnd atomic.Bool
+
+func StartStreamReplayUI(streamLog *shared.StreamLog) error {
+	replaySpeed.Store(1)
+	replaySkipToEnd.Store(false)
+
+	initial := initialModel("", "", false)
+	initial.replaying = true
+
+	go playStreamLog(streamLog.Entries)
+
+	return runStreamUI(initial)
+}
+
+func playStreamLog(entries []*shared.StreamLogEntry) {
+	// wait for the UI to start
+	for {
+		mu.Lock()
+		started := ui != nil
+		mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for i, entry := range entries {
+		if i > 0 && !replaySkipToEnd.Load() {
+			delay := entry.Ts.Sub(entries[i-1].Ts)
+			if delay > maxReplayDelay {
+				delay = maxReplayDelay
+			}
+			time.Sleep(delay / time.Duration(replaySpeed.Load()))
+		}
+
+		if entry.Message == nil {
+			log.Println("stream log entry has no message")
+			continue
+		}
+
+		Send(*entry.Message)
+	}
+}
+
+func speedUpReplay() int {
+	speed := replaySpeed.Load()
+	if speed < maxReplaySpeed {
+		speed *= 2
+		replaySpeed.Store(speed)
+	}
+	return int(speed)
+}
+
+func skipReplayToEnd() {
+	replaySkipToEnd.Store(true)
+}
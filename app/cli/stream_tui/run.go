@@ -32,6 +32,21 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 
 	initial := initialModel(prestartReply, prompt, buildOnly)
 
+	return runStreamUI(initial)
+}
+
+func StartStreamWatchUI() error {
+	if prestartErr != nil {
+		term.OutputErrorAndExit("Server error: " + prestartErr.Msg)
+	}
+
+	initial := initialModel(prestartReply, "", false)
+	initial.watching = true
+
+	return runStreamUI(initial)
+}
+
+func runStreamUI(initial *streamUIModel) error {
 	mu.Lock()
 	ui = tea.NewProgram(initial, tea.WithAltScreen())
 	mu.Unlock()
@@ -79,7 +94,7 @@ func StartStreamUI(prompt string, buildOnly bool) error {
 		fmt.Println()
 		term.PrintCmds("", "log", "rewind", "tell")
 		os.Exit(0)
-	} else if mod.background {
+	} else if mod.background && mod.canControl() {
 		fmt.Println()
 		color.New(color.BgBlack, color.Bold, color.FgHiGreen).Println(" ✅ Plan is active in the background ")
 		fmt.Println()
@@ -57,7 +57,13 @@ func (m streamUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.background = true
 			return &m, tea.Quit
 
-		case bubbleKey.Matches(msg, m.keymap.stop):
+		case m.replaying && bubbleKey.Matches(msg, m.keymap.faster):
+			m.replaySpeed = speedUpReplay()
+
+		case m.replaying && bubbleKey.Matches(msg, m.keymap.skipToEnd):
+			skipReplayToEnd()
+
+		case m.canControl() && bubbleKey.Matches(msg, m.keymap.stop):
 			apiErr := api.Client.StopPlan(lib.CurrentPlanId, lib.CurrentBranch)
 			if apiErr != nil {
 				log.Println("stop plan api error:", apiErr)
@@ -122,6 +128,10 @@ func (m *streamUIModel) updateReplyDisplay() {
 		s += "\n\n" + strings.TrimSpace(promptTxt) + "\n"
 	}
 
+	if m.contextBudget != nil && m.contextBudget.Trimmed() {
+		s += "\n" + color.New(color.FgHiYellow).Sprint("✂️  "+m.contextBudget.Summary()) + "\n"
+	}
+
 	if m.reply != "" {
 		replyMd, _ := term.GetMarkdown(m.reply)
 		s += "\n" + color.New(color.BgBlue, color.Bold, color.FgHiWhite).Sprintf(" 🤖 Plandex reply 👇 ")
@@ -130,6 +140,14 @@ func (m *streamUIModel) updateReplyDisplay() {
 		s += "\n"
 	}
 
+	if m.autoContinue != nil {
+		if m.autoContinue.ShouldContinue {
+			s += "\n\n" + color.New(color.FgHiCyan).Sprintf("🔁 Continuing automatically (continuation %d)", m.autoContinue.Iteration+1) + "\n"
+		} else {
+			s += "\n\n" + color.New(color.FgHiYellow).Sprintf("⏹️  Stopped continuing automatically: %s", m.autoContinue.Reason) + "\n"
+		}
+	}
+
 	m.mainDisplay = s
 	m.mainViewport.SetContent(s)
 	m.updateViewportDimensions()
@@ -221,9 +239,11 @@ func (m *streamUIModel) scrollEnd() {
 func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.Cmd) {
 
 	checkMissingFileFn := func() {
-		if msg.MissingFilePath != "" {
+		// replayed and watched streams can't respond to missing file prompts
+		if msg.MissingFilePath != "" && m.canControl() {
 			m.promptingMissingFile = true
 			m.missingFilePath = msg.MissingFilePath
+			m.missingFileOutline = msg.MissingFileOutline
 
 			bytes, err := os.ReadFile(m.missingFilePath)
 			if err != nil {
@@ -231,7 +251,13 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 				m.err = fmt.Errorf("failed to read file: %w", err)
 				return
 			}
-			m.missingFileContent = string(bytes)
+			// there's no chance to prompt here, so secrets are always masked
+			m.missingFileContent, err = lib.RedactContextBody(string(bytes))
+			if err != nil {
+				log.Println("failed to redact file:", err)
+				m.err = fmt.Errorf("failed to redact file: %w", err)
+				return
+			}
 
 			numTokens, err := shared.GetNumTokens(m.missingFileContent)
 
@@ -256,9 +282,15 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 		if msg.InitBuildOnly {
 			m.buildOnly = true
 		}
+		if msg.ReadOnly {
+			m.watching = true
+		}
 		if len(msg.InitReplies) > 0 {
 			m.reply = strings.Join(msg.InitReplies, "\n\n👉 ")
 		}
+		if msg.AutoContinue != nil {
+			m.autoContinue = msg.AutoContinue
+		}
 		m.updateReplyDisplay()
 
 		checkMissingFileFn()
@@ -280,6 +312,10 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 			}
 		}
 
+		if m.autoContinue != nil && m.autoContinue.ShouldContinue {
+			m.autoContinue = nil
+		}
+
 		// log.Println("reply chunk:", msg.ReplyChunk)
 
 		m.reply += msg.ReplyChunk
@@ -334,6 +370,18 @@ func (m *streamUIModel) streamUpdate(msg *shared.StreamMessage) (tea.Model, tea.
 	case shared.StreamMessageRepliesFinished:
 		m.processing = false
 
+	case shared.StreamMessageWatchers:
+		m.watchers = msg.Watchers
+		m.updateViewportDimensions()
+
+	case shared.StreamMessageContextBudget:
+		m.contextBudget = msg.ContextBudget
+		m.updateReplyDisplay()
+
+	case shared.StreamMessageAutoContinue:
+		m.autoContinue = msg.AutoContinue
+		m.updateReplyDisplay()
+
 	}
 
 	return m, nil
@@ -394,7 +442,7 @@ func (m *streamUIModel) up() {
 
 func (m *streamUIModel) down() {
 	if m.promptingMissingFile {
-		m.missingFileSelectedIdx = min(m.missingFileSelectedIdx+1, len(missingFileSelectOpts)-1)
+		m.missingFileSelectedIdx = min(m.missingFileSelectedIdx+1, m.numMissingFileOpts()-1)
 	}
 
 }
@@ -429,6 +477,7 @@ func (m *streamUIModel) selectedMissingFileOpt() (tea.Model, tea.Cmd) {
 	m.missingFileSelectedIdx = 0
 	m.missingFileContent = ""
 	m.missingFileTokens = 0
+	m.missingFileOutline = false
 	m.promptedMissingFile = true
 	m.processing = true
 
@@ -41,11 +41,32 @@ func (m streamUIModel) renderMainView() string {
 func (m streamUIModel) renderHelp() string {
 	style := lipgloss.NewStyle().Width(m.width).Foreground(lipgloss.Color(helpTextColor)).BorderStyle(lipgloss.NormalBorder()).BorderTop(true).BorderForeground(lipgloss.Color(borderColor))
 
-	if m.buildOnly {
-		return style.Render(" (s)top • (b)ackground")
+	if m.replaying {
+		return style.Render(fmt.Sprintf(" ⏪ replay %dx • (f)aster • (e) skip to end • (b) quit • (j/k) scroll • (d/u) page • (g/G) start/end", m.replaySpeed))
+	} else if m.watching {
+		return style.Render(" 👀 watching (read-only) • (b) quit • (j/k) scroll • (d/u) page • (g/G) start/end" + m.renderWatchers())
+	} else if m.buildOnly {
+		return style.Render(" (s)top • (b)ackground" + m.renderWatchers())
 	} else {
-		return style.Render(" (s)top • (b)ackground • (j/k) scroll • (d/u) page • (g/G) start/end")
+		return style.Render(" (s)top • (b)ackground • (j/k) scroll • (d/u) page • (g/G) start/end" + m.renderWatchers())
+	}
+}
+
+func (m streamUIModel) renderWatchers() string {
+	// only worth showing when someone else is connected
+	if len(m.watchers) < 2 {
+		return ""
+	}
+
+	names := make([]string, len(m.watchers))
+	for i, watcher := range m.watchers {
+		names[i] = watcher.UserName
+		if watcher.ReadOnly {
+			names[i] += " 👀"
+		}
 	}
+
+	return "\n 👥 " + strings.Join(names, ", ")
 }
 
 func (m streamUIModel) renderProcessing() string {
@@ -168,12 +189,17 @@ func (m streamUIModel) doRenderBuild(outputStatic bool) string {
 func (m streamUIModel) renderMissingFilePrompt() string {
 	style := lipgloss.NewStyle().Padding(1).BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color(borderColor)).Width(m.width - 2).Height(m.height - 2)
 
-	prompt := "📄 " + color.New(color.Bold, term.ColorHiYellow).Sprint(m.missingFilePath) + " isn't in context."
+	var prompt, desc string
+	if m.missingFileOutline {
+		prompt = "📄 " + color.New(color.Bold, term.ColorHiYellow).Sprint(m.missingFilePath) + " is only loaded as an outline."
+		desc = "Plandex is about to make changes to this file, but only its outline is in context. Load the full file so the changes can be written against it, or skip generating it."
+	} else {
+		prompt = "📄 " + color.New(color.Bold, term.ColorHiYellow).Sprint(m.missingFilePath) + " isn't in context."
+		desc = "This file exists in your project, but isn't loaded into context. Unless you load it into context or skip generating it, Plandex will fully overwrite the existing file rather than applying updates."
+	}
 
 	prompt += "\n\n"
 
-	desc := "This file exists in your project, but isn't loaded into context. Unless you load it into context or skip generating it, Plandex will fully overwrite the existing file rather than applying updates."
-
 	words := strings.Split(desc, " ")
 	for i, word := range words {
 		words[i] = color.New(color.FgWhite).Sprint(word)
@@ -183,7 +209,7 @@ func (m streamUIModel) renderMissingFilePrompt() string {
 
 	prompt += "\n\n" + color.New(term.ColorHiMagenta, color.Bold).Sprintln("🧐 What do you want to do?")
 
-	for i, opt := range missingFileSelectOpts {
+	for i, opt := range missingFileSelectOpts[:m.numMissingFileOpts()] {
 		if i == m.missingFileSelectedIdx {
 			prompt += color.New(term.ColorHiCyan, color.Bold).Sprint(" > " + opt)
 		} else {
@@ -0,0 +1,207 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// braceExtractor is a heuristic extractor for languages that delimit blocks with braces. It finds the first line that looks like a declaration of the symbol, then takes everything up to the matching closing brace (or the first ';' for declarations without a body), along with any comments or decorators directly above it.
+type braceExtractor struct {
+	// modifier a top-level declaration needs to be exported, if the language has one
+	exportKeyword string
+}
+
+func init() {
+	for _, ext := range []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"} {
+		Register(ext, braceExtractor{exportKeyword: "export"})
+	}
+	for _, ext := range []string{".java", ".cs"} {
+		Register(ext, braceExtractor{exportKeyword: "public"})
+	}
+	Register(".rs", braceExtractor{exportKeyword: "pub"})
+	for _, ext := range []string{
+		".kt", ".kts", ".scala", ".swift", ".dart",
+		".c", ".h", ".cc", ".cpp", ".hpp", ".php",
+	} {
+		Register(ext, braceExtractor{})
+	}
+}
+
+func (braceExtractor) Extract(src []byte, name string) (*Symbol, error) {
+	lines := strings.Split(string(src), "\n")
+
+	start, end := 0, len(lines)
+
+	// for 'Class.method', find the class first and look for the method inside it
+	if outer, inner, ok := strings.Cut(name, "."); ok {
+		outerStart, outerEnd, found := findBraceDecl(lines, outer, start, end)
+		if !found {
+			return nil, nil
+		}
+		start, end = outerStart+1, outerEnd
+		name = inner
+	}
+
+	declStart, declEnd, found := findBraceDecl(lines, name, start, end)
+	if !found {
+		return nil, nil
+	}
+
+	// include comments and decorators directly above the declaration
+	for declStart > start {
+		prev := strings.TrimSpace(lines[declStart-1])
+		if strings.HasPrefix(prev, "//") || strings.HasPrefix(prev, "/*") || strings.HasPrefix(prev, "*") || strings.HasPrefix(prev, "@") || strings.HasPrefix(prev, "#[") {
+			declStart--
+		} else {
+			break
+		}
+	}
+
+	return &Symbol{
+		Name:      name,
+		Body:      strings.Join(lines[declStart:declEnd+1], "\n"),
+		StartLine: declStart + 1,
+		EndLine:   declEnd + 1,
+	}, nil
+}
+
+var braceListDeclRegex = regexp.MustCompile(`^((?:[\w@]+(?:\([\w ]*\))?\s+)*?)(function\*?|class|interface|struct|enum|type|fn|trait|object|record|protocol)\s+([A-Za-z_$][\w$]*)`)
+
+// functions assigned to a variable, like 'export const foo = async (a: string): Promise<void> => {' or 'export const foo = function (' . Parameters that continue on the next line are matched by the open paren alone.
+var braceListAssignedFuncRegex = regexp.MustCompile(`^((?:[\w@]+\s+)*?)(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\((?:[^)]*\)\s*(?::[^=]+)?=>|\s*$)|[A-Za-z_$][\w$]*\s*=>)`)
+
+var bracePrivateModifiers = map[string]bool{"private": true, "internal": true, "fileprivate": true, "static": true, "protected": true}
+
+// List returns top-level declarations that look exported. Members of classes aren't listed.
+func (e braceExtractor) List(src []byte) ([]*Decl, error) {
+	var decls []*Decl
+
+	for i, line := range strings.Split(string(src), "\n") {
+		// top-level declarations aren't indented
+		var modifiers []string
+		var name string
+		var assigned bool
+		if match := braceListDeclRegex.FindStringSubmatch(line); match != nil {
+			modifiers = strings.Fields(match[1])
+			name = match[3]
+		} else if match := braceListAssignedFuncRegex.FindStringSubmatch(line); match != nil {
+			modifiers = strings.Fields(match[1])
+			name = match[2]
+			assigned = true
+		} else {
+			continue
+		}
+
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		exported := e.exportKeyword == ""
+		private := false
+		for _, modifier := range modifiers {
+			// 'pub(crate)' and the like don't count as exported
+			if modifier == e.exportKeyword {
+				exported = true
+			}
+			if bracePrivateModifiers[modifier] {
+				private = true
+			}
+		}
+		if !exported || private {
+			continue
+		}
+
+		signature := strings.TrimSpace(line)
+		if assigned && strings.Contains(signature, "=>") {
+			// leave out an arrow function's body. A block body follows the last arrow on the line, and an expression body the first, since parameter types can contain arrows too.
+			idx := strings.Index(signature, "=>")
+			if strings.HasSuffix(signature, "{") {
+				idx = strings.LastIndex(signature, "=>")
+			}
+			signature = signature[:idx+2]
+		} else if idx := strings.Index(signature, "{"); idx > 0 {
+			signature = strings.TrimSpace(signature[:idx])
+		}
+
+		decls = append(decls, &Decl{
+			Name:      name,
+			Signature: signature,
+			Line:      i + 1,
+		})
+	}
+
+	return decls, nil
+}
+
+// findBraceDecl returns the first and last line (0-based, inclusive) of the declaration of name within lines[from:to]
+func findBraceDecl(lines []string, name string, from, to int) (int, int, bool) {
+	quoted := regexp.QuoteMeta(name)
+	keywordDecl := regexp.MustCompile(`^\s*(?:[\w@]+\s+)*(?:function\*?|class|interface|struct|enum|type|fn|trait|impl|const|let|var|val|object|namespace)\s+` + quoted + `\b`)
+	callableDecl := regexp.MustCompile(`^\s*(?:[\w<>\[\],@*&:]+\s+)*` + quoted + `\s*(?:<[^>]*>)?\s*\(`)
+	assignedDecl := regexp.MustCompile(`^\s*(?:[\w@]+\s+)*` + quoted + `\s*[:=]\s*(?:async\s+)?(?:function\b|\(|[\w$]+\s*=>)`)
+
+	for i := from; i < to; i++ {
+		line := lines[i]
+		isCallable := callableDecl.MatchString(line) && !strings.HasSuffix(strings.TrimSpace(line), ";")
+		if !keywordDecl.MatchString(line) && !isCallable && !assignedDecl.MatchString(line) {
+			continue
+		}
+
+		if end, ok := findBlockEnd(lines, i, to); ok {
+			return i, end, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// findBlockEnd scans from the declaration line to the brace that closes its block, or to a ';' at the top level if the declaration has no block
+func findBlockEnd(lines []string, from, to int) (int, bool) {
+	depth := 0
+	opened := false
+	var inString rune
+
+	for i := from; i < to; i++ {
+		line := lines[i]
+		for j := 0; j < len(line); j++ {
+			c := rune(line[j])
+
+			if inString != 0 {
+				if c == '\\' {
+					j++
+				} else if c == inString {
+					inString = 0
+				}
+				continue
+			}
+
+			switch c {
+			case '"', '\'', '`':
+				inString = c
+			case '/':
+				if j+1 < len(line) && line[j+1] == '/' {
+					j = len(line)
+				}
+			case '{':
+				depth++
+				opened = true
+			case '}':
+				depth--
+				if opened && depth == 0 {
+					return i, true
+				}
+			case ';':
+				if depth == 0 {
+					return i, true
+				}
+			}
+		}
+
+		// strings don't span lines except for template literals
+		if inString != '`' {
+			inString = 0
+		}
+	}
+
+	return 0, false
+}
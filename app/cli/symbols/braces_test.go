@@ -0,0 +1,139 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBraceList(t *testing.T) {
+	tests := []struct {
+		name          string
+		exportKeyword string
+		src           string
+		want          []*Decl
+	}{
+		{
+			name:          "typescript",
+			exportKeyword: "export",
+			src: `import { x } from "./x"
+
+export function parse(input: string): Node {
+  return x(input)
+}
+
+function helper() {}
+
+export default class Parser {
+  run() {}
+}
+
+export interface Options {
+  strict: boolean
+}
+
+export const handler = async (req: Request): Promise<Response> => {
+  return new Response()
+}
+
+export const double = (n: number) => n * 2
+
+export const withCallback = (cb: () => void) => {
+  cb()
+}
+
+export const legacy = function (a, b) {
+  return a + b
+}
+
+export let single = x => x
+
+export const multiline = (
+  a: string,
+) => a
+
+export const config = { strict: true }
+
+const internal = () => {}
+
+export const _private = () => {}
+`,
+			want: []*Decl{
+				{Name: "parse", Signature: "export function parse(input: string): Node", Line: 3},
+				{Name: "Parser", Signature: "export default class Parser", Line: 9},
+				{Name: "Options", Signature: "export interface Options", Line: 13},
+				{Name: "handler", Signature: "export const handler = async (req: Request): Promise<Response> =>", Line: 17},
+				{Name: "double", Signature: "export const double = (n: number) =>", Line: 21},
+				{Name: "withCallback", Signature: "export const withCallback = (cb: () => void) =>", Line: 23},
+				{Name: "legacy", Signature: "export const legacy = function (a, b)", Line: 27},
+				{Name: "single", Signature: "export let single = x =>", Line: 31},
+				{Name: "multiline", Signature: "export const multiline = (", Line: 33},
+			},
+		},
+		{
+			name:          "rust",
+			exportKeyword: "pub",
+			src: `pub struct Config {
+    pub name: String,
+}
+
+pub(crate) fn internal() {}
+
+fn private() {}
+
+pub fn load(path: &str) -> Config {
+    Config { name: path.to_string() }
+}
+
+pub trait Store {
+    fn get(&self);
+}
+`,
+			want: []*Decl{
+				{Name: "Config", Signature: "pub struct Config", Line: 1},
+				{Name: "load", Signature: "pub fn load(path: &str) -> Config", Line: 9},
+				{Name: "Store", Signature: "pub trait Store", Line: 13},
+			},
+		},
+		{
+			name:          "java",
+			exportKeyword: "public",
+			src: `public class Service {
+    public void run() {}
+}
+
+class Hidden {}
+
+private static class Inner {}
+`,
+			want: []*Decl{
+				{Name: "Service", Signature: "public class Service", Line: 1},
+			},
+		},
+		{
+			name: "no export keyword",
+			src: `struct point {
+  int x;
+};
+
+static struct cache {};
+`,
+			want: []*Decl{
+				{Name: "point", Signature: "struct point", Line: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		decls, err := braceExtractor{exportKeyword: tt.exportKeyword}.List([]byte(tt.src))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(decls, tt.want) {
+			for _, decl := range decls {
+				t.Logf("%s: %+v", tt.name, decl)
+			}
+			t.Errorf("%s: got %v, want %v", tt.name, declNames(decls), declNames(tt.want))
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package symbols
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+type goExtractor struct{}
+
+func init() {
+	Register(".go", goExtractor{})
+}
+
+// Extract finds a top-level func, type, var or const by name, or a method as 'Type.Method'. Doc comments are included.
+func (goExtractor) Extract(src []byte, name string) (*Symbol, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing go file: %v", err)
+	}
+
+	recvName, funcName, isMethod := strings.Cut(name, ".")
+	if !isMethod {
+		funcName = name
+	}
+
+	var found ast.Node
+	var doc *ast.CommentGroup
+
+	// a method named without its receiver is only used if nothing else matches
+	var methodFallback *ast.FuncDecl
+
+	for _, decl := range file.Decls {
+		if found != nil {
+			break
+		}
+
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.Name != funcName {
+				continue
+			}
+
+			if d.Recv == nil {
+				if !isMethod {
+					found, doc = d, d.Doc
+				}
+				continue
+			}
+
+			if isMethod {
+				if len(d.Recv.List) > 0 && goRecvTypeName(d.Recv.List[0].Type) == recvName {
+					found, doc = d, d.Doc
+				}
+			} else if methodFallback == nil {
+				methodFallback = d
+			}
+
+		case *ast.GenDecl:
+			if isMethod {
+				continue
+			}
+
+			for _, spec := range d.Specs {
+				var specDoc *ast.CommentGroup
+				var matches bool
+
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					matches = s.Name.Name == name
+					specDoc = s.Doc
+				case *ast.ValueSpec:
+					for _, ident := range s.Names {
+						if ident.Name == name {
+							matches = true
+						}
+					}
+					specDoc = s.Doc
+				}
+
+				if !matches {
+					continue
+				}
+
+				// a grouped declaration only contributes the matching spec
+				if d.Lparen.IsValid() {
+					found, doc = spec, specDoc
+				} else {
+					found, doc = d, d.Doc
+				}
+				break
+			}
+		}
+	}
+
+	if found == nil && methodFallback != nil {
+		found, doc = methodFallback, methodFallback.Doc
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	start := found.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	startPos := fset.Position(start)
+	endPos := fset.Position(found.End())
+
+	return &Symbol{
+		Name:      name,
+		Body:      string(src[startPos.Offset:endPos.Offset]),
+		StartLine: startPos.Line,
+		EndLine:   endPos.Line,
+	}, nil
+}
+
+// List returns exported funcs and types, and exported methods of exported types
+func (goExtractor) List(src []byte) ([]*Decl, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing go file: %v", err)
+	}
+
+	var decls []*Decl
+
+	source := func(from, to token.Pos) string {
+		return strings.Join(strings.Fields(string(src[fset.Position(from).Offset:fset.Position(to).Offset])), " ")
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+
+			name := d.Name.Name
+			if d.Recv != nil {
+				if len(d.Recv.List) == 0 {
+					continue
+				}
+				recvName := goRecvTypeName(d.Recv.List[0].Type)
+				if !ast.IsExported(recvName) {
+					continue
+				}
+				name = recvName + "." + name
+			}
+
+			end := d.End()
+			if d.Body != nil {
+				end = d.Body.Lbrace
+			}
+
+			decls = append(decls, &Decl{
+				Name:      name,
+				Signature: source(d.Pos(), end),
+				Line:      fset.Position(d.Pos()).Line,
+			})
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range d.Specs {
+				s, ok := spec.(*ast.TypeSpec)
+				if !ok || !s.Name.IsExported() {
+					continue
+				}
+
+				// struct and interface fields are left out
+				var signature string
+				switch s.Type.(type) {
+				case *ast.StructType:
+					signature = "type " + source(s.Pos(), s.Type.Pos()) + " struct"
+				case *ast.InterfaceType:
+					signature = "type " + source(s.Pos(), s.Type.Pos()) + " interface"
+				default:
+					signature = "type " + source(s.Pos(), s.End())
+				}
+
+				decls = append(decls, &Decl{
+					Name:      s.Name.Name,
+					Signature: signature,
+					Line:      fset.Position(s.Pos()).Line,
+				})
+			}
+		}
+	}
+
+	return decls, nil
+}
+
+func goRecvTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return goRecvTypeName(t.X)
+	case *ast.IndexExpr:
+		return goRecvTypeName(t.X)
+	case *ast.IndexListExpr:
+		return goRecvTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
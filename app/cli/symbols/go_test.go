@@ -0,0 +1,71 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+)
+
+func declNames(decls []*Decl) []string {
+	var names []string
+	for _, decl := range decls {
+		names = append(names, decl.Name)
+	}
+	return names
+}
+
+func TestGoList(t *testing.T) {
+	src := `package server
+
+// Server handles requests
+type Server struct {
+	addr string
+}
+
+type handler func()
+
+type Option interface {
+	apply(*Server)
+}
+
+type ID = string
+
+func New(addr string, opts ...Option) *Server {
+	return &Server{addr: addr}
+}
+
+func (s *Server) Start() error {
+	return nil
+}
+
+func (s *Server) stop() {}
+
+func (h handler) Run() {}
+
+func helper() {}
+`
+
+	decls, err := goExtractor{}.List([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Decl{
+		{Name: "Server", Signature: "type Server struct", Line: 4},
+		{Name: "Option", Signature: "type Option interface", Line: 10},
+		{Name: "ID", Signature: "type ID = string", Line: 14},
+		{Name: "New", Signature: "func New(addr string, opts ...Option) *Server", Line: 16},
+		{Name: "Server.Start", Signature: "func (s *Server) Start() error", Line: 20},
+	}
+	if !reflect.DeepEqual(decls, want) {
+		for _, decl := range decls {
+			t.Logf("%+v", decl)
+		}
+		t.Errorf("got %v, want %v", declNames(decls), declNames(want))
+	}
+}
+
+func TestGoListInvalidSource(t *testing.T) {
+	if _, err := (goExtractor{}).List([]byte("package x\nfunc {")); err == nil {
+		t.Errorf("expected an error for source that doesn't parse")
+	}
+}
@@ -0,0 +1,246 @@
+package symbols
+
+import (
+	"regexp"
+	"strings"
+)
+
+// pythonExtractor finds declarations by indentation. A block runs from its 'def' or 'class' line to the last line before anything indented at or above its own level, not counting blank lines, comments, or lines inside multi-line strings.
+type pythonExtractor struct{}
+
+func init() {
+	for _, ext := range []string{".py", ".pyi"} {
+		Register(ext, pythonExtractor{})
+	}
+}
+
+var pythonDeclRegex = regexp.MustCompile(`^(\s*)(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`)
+
+// Extract finds a top-level function or class by name, or a method as 'Class.method'. Decorators and comments directly above are included.
+func (pythonExtractor) Extract(src []byte, name string) (*Symbol, error) {
+	lines := strings.Split(string(src), "\n")
+	inString := pythonMultilineStrings(lines)
+
+	start, end := 0, len(lines)
+	indent := 0
+
+	if outer, inner, ok := strings.Cut(name, "."); ok {
+		outerStart, outerEnd, found := findPythonDecl(lines, inString, outer, start, end, 0)
+		if !found {
+			return nil, nil
+		}
+		start, end = outerStart+1, outerEnd+1
+		indent = pythonBodyIndent(lines, inString, start, end)
+		name = inner
+	}
+
+	declStart, declEnd, found := findPythonDecl(lines, inString, name, start, end, indent)
+	if !found {
+		return nil, nil
+	}
+
+	for declStart > start {
+		prev := strings.TrimSpace(lines[declStart-1])
+		if strings.HasPrefix(prev, "@") || strings.HasPrefix(prev, "#") {
+			declStart--
+		} else {
+			break
+		}
+	}
+
+	return &Symbol{
+		Name:      name,
+		Body:      strings.Join(lines[declStart:declEnd+1], "\n"),
+		StartLine: declStart + 1,
+		EndLine:   declEnd + 1,
+	}, nil
+}
+
+// List returns public top-level functions and classes, and public methods of public classes. Names starting with '_' are private by convention.
+func (pythonExtractor) List(src []byte) ([]*Decl, error) {
+	lines := strings.Split(string(src), "\n")
+	inString := pythonMultilineStrings(lines)
+
+	var decls []*Decl
+
+	// the public class whose methods are being listed, and the indentation of its body once it's known
+	var class string
+	classIndent := -1
+
+	// continuation lines of a multi-line header
+	headerEnd := -1
+
+	for i, line := range lines {
+		if inString[i] || i <= headerEnd {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			class = ""
+		} else if class != "" && classIndent == -1 {
+			classIndent = indent
+		}
+
+		match := pythonDeclRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		keyword, name := match[2], match[3]
+		headerEnd = pythonHeaderEnd(lines, i, len(lines))
+
+		var declName string
+		if indent == 0 {
+			if keyword == "class" && !strings.HasPrefix(name, "_") {
+				class = name
+				classIndent = -1
+			}
+			declName = name
+		} else if class != "" && indent == classIndent && keyword == "def" {
+			declName = class + "." + name
+		} else {
+			continue
+		}
+
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+
+		var headerLines []string
+		for _, headerLine := range lines[i : headerEnd+1] {
+			headerLines = append(headerLines, stripPythonComment(headerLine))
+		}
+		signature := strings.Join(strings.Fields(strings.Join(headerLines, " ")), " ")
+		signature = strings.NewReplacer("( ", "(", " )", ")", ", )", ")").Replace(signature)
+		signature = strings.TrimSuffix(signature, ":")
+
+		decls = append(decls, &Decl{
+			Name:      declName,
+			Signature: strings.TrimSpace(signature),
+			Line:      i + 1,
+		})
+	}
+
+	return decls, nil
+}
+
+// findPythonDecl returns the first and last line (0-based, inclusive) of the declaration of name indented by indent within lines[from:to]
+func findPythonDecl(lines []string, inString []bool, name string, from, to, indent int) (int, int, bool) {
+	for i := from; i < to; i++ {
+		if inString[i] {
+			continue
+		}
+
+		match := pythonDeclRegex.FindStringSubmatch(lines[i])
+		if match == nil || match[3] != name || len(match[1]) != indent {
+			continue
+		}
+
+		end := pythonHeaderEnd(lines, i, to)
+		for j := end + 1; j < to; j++ {
+			trimmed := strings.TrimSpace(lines[j])
+			if trimmed == "" {
+				continue
+			}
+			if !inString[j] && !strings.HasPrefix(trimmed, "#") && len(lines[j])-len(strings.TrimLeft(lines[j], " \t")) <= indent {
+				break
+			}
+			end = j
+		}
+
+		// trailing comments belong to whatever follows
+		for end > i && strings.HasPrefix(strings.TrimSpace(lines[end]), "#") {
+			end--
+		}
+
+		return i, end, true
+	}
+
+	return 0, 0, false
+}
+
+// pythonHeaderEnd returns the line that ends a declaration's header, which can span lines inside parentheses
+func pythonHeaderEnd(lines []string, from, to int) int {
+	depth := 0
+	for i := from; i < to; i++ {
+		line := stripPythonComment(lines[i])
+		depth += strings.Count(line, "(") + strings.Count(line, "[") - strings.Count(line, ")") - strings.Count(line, "]")
+		// a header that doesn't end in a colon is a one-liner like 'def f(): pass'
+		if depth <= 0 && !strings.HasSuffix(strings.TrimSpace(line), "\\") {
+			return i
+		}
+	}
+	return from
+}
+
+// pythonBodyIndent returns the indentation of the first statement in lines[from:to]
+func pythonBodyIndent(lines []string, inString []bool, from, to int) int {
+	for i := from; i < to; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if inString[i] || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return len(lines[i]) - len(strings.TrimLeft(lines[i], " \t"))
+	}
+	return 0
+}
+
+// pythonMultilineStrings reports which lines start inside a triple-quoted string, so docstrings aren't mistaken for code
+func pythonMultilineStrings(lines []string) []bool {
+	res := make([]bool, len(lines))
+	var quote string
+
+	for i, line := range lines {
+		res[i] = quote != ""
+
+		for len(line) > 0 {
+			if quote == "" {
+				idx := strings.Index(line, `"""`)
+				if alt := strings.Index(line, `'''`); alt >= 0 && (idx < 0 || alt < idx) {
+					idx = alt
+				}
+				if idx < 0 || strings.Contains(line[:idx], "#") {
+					break
+				}
+				quote = line[idx : idx+3]
+				line = line[idx+3:]
+			} else {
+				idx := strings.Index(line, quote)
+				if idx < 0 {
+					break
+				}
+				quote = ""
+				line = line[idx+3:]
+			}
+		}
+	}
+
+	return res
+}
+
+func stripPythonComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			quote = c
+		case '#':
+			return strings.TrimRight(line[:i], " \t")
+		}
+	}
+	return line
+}
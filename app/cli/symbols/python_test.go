@@ -0,0 +1,135 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+)
+
+const pythonSrc = `import os
+
+
+def load(path: str) -> str:
+    """Load a file.
+
+def not_a_function():
+    """
+    with open(path) as f:
+        return f.read()
+
+
+async def fetch(
+    url: str,
+    timeout: int = 10,  # seconds
+) -> bytes:
+    pass
+
+
+def _helper():
+    pass
+
+
+# a cache
+@dataclass
+class Cache:
+    """In-memory cache."""
+
+    size: int = 0
+
+    def get(self, key):
+        return None
+
+    @property
+    def count(self) -> int:
+        return self.size
+
+    def _evict(self):
+        pass
+
+    class Entry:
+        def value(self):
+            pass
+
+
+class _Private:
+    def method(self):
+        pass
+
+
+def one_liner(): return 1
+`
+
+func TestPythonList(t *testing.T) {
+	decls, err := pythonExtractor{}.List([]byte(pythonSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Decl{
+		{Name: "load", Signature: "def load(path: str) -> str", Line: 4},
+		{Name: "fetch", Signature: "async def fetch(url: str, timeout: int = 10) -> bytes", Line: 13},
+		{Name: "Cache", Signature: "class Cache", Line: 26},
+		{Name: "Cache.get", Signature: "def get(self, key)", Line: 31},
+		{Name: "Cache.count", Signature: "def count(self) -> int", Line: 35},
+		{Name: "one_liner", Signature: "def one_liner(): return 1", Line: 51},
+	}
+	if !reflect.DeepEqual(decls, want) {
+		for _, decl := range decls {
+			t.Logf("%+v", decl)
+		}
+		t.Errorf("got %v, want %v", declNames(decls), declNames(want))
+	}
+}
+
+func TestPythonExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		startLine int
+		endLine   int
+	}{
+		{
+			name:      "load",
+			body:      "def load(path: str) -> str:\n    \"\"\"Load a file.\n\ndef not_a_function():\n    \"\"\"\n    with open(path) as f:\n        return f.read()",
+			startLine: 4,
+			endLine:   10,
+		},
+		{
+			name:      "Cache.count",
+			body:      "    @property\n    def count(self) -> int:\n        return self.size",
+			startLine: 34,
+			endLine:   36,
+		},
+		{
+			name:      "one_liner",
+			body:      "def one_liner(): return 1",
+			startLine: 51,
+			endLine:   51,
+		},
+	}
+
+	for _, tt := range tests {
+		symbol, err := pythonExtractor{}.Extract([]byte(pythonSrc), tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if symbol == nil {
+			t.Errorf("%s: not found", tt.name)
+			continue
+		}
+		if symbol.Body != tt.body || symbol.StartLine != tt.startLine || symbol.EndLine != tt.endLine {
+			t.Errorf("%s: got %q (lines %d-%d), want %q (lines %d-%d)", tt.name, symbol.Body, symbol.StartLine, symbol.EndLine, tt.body, tt.startLine, tt.endLine)
+		}
+	}
+
+	symbol, err := pythonExtractor{}.Extract([]byte(pythonSrc), "Cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if symbol == nil || symbol.StartLine != 24 || symbol.EndLine != 43 {
+		t.Errorf("Cache: got %+v, want lines 24-43 including the comment and decorator", symbol)
+	}
+
+	if symbol, _ := (pythonExtractor{}).Extract([]byte(pythonSrc), "not_a_function"); symbol != nil {
+		t.Errorf("a declaration inside a docstring was found")
+	}
+}
@@ -0,0 +1,100 @@
+package symbols
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol is the source of a single named declaration in a file
+type Symbol struct {
+	Name      string
+	Body      string
+	StartLine int
+	EndLine   int
+}
+
+// Extractor finds a named symbol in a file's source. It returns nil if the symbol isn't found.
+type Extractor interface {
+	Extract(src []byte, name string) (*Symbol, error)
+}
+
+// Decl is a declaration listed in a repo map
+type Decl struct {
+	// methods are named 'Type.Method'
+	Name      string
+	Signature string
+	Line      int
+}
+
+// Lister is implemented by extractors that can also list the exported functions, types, and methods declared in a file
+type Lister interface {
+	List(src []byte) ([]*Decl, error)
+}
+
+var extractorsByExt = map[string]Extractor{}
+
+// Register adds an extractor for files with the given extension (e.g. ".go"), replacing any existing one
+func Register(ext string, extractor Extractor) {
+	extractorsByExt[strings.ToLower(ext)] = extractor
+}
+
+func HasExtractor(path string) bool {
+	_, ok := extractorsByExt[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func Extract(path string, src []byte, name string) (*Symbol, error) {
+	extractor, ok := extractorsByExt[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("symbols aren't supported for %s files", filepath.Ext(path))
+	}
+
+	return extractor.Extract(src, name)
+}
+
+func HasLister(path string) bool {
+	_, ok := extractorsByExt[strings.ToLower(filepath.Ext(path))].(Lister)
+	return ok
+}
+
+// List returns the exported declarations in a file, in source order
+func List(path string, src []byte) ([]*Decl, error) {
+	lister, ok := extractorsByExt[strings.ToLower(filepath.Ext(path))].(Lister)
+	if !ok {
+		return nil, fmt.Errorf("listing symbols isn't supported for %s files", filepath.Ext(path))
+	}
+
+	return lister.List(src)
+}
+
+var symbolNameRegex = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)?$`)
+
+// ParseRef splits a 'path:Symbol' or 'path#Symbol' reference. ok is false unless path is an existing file with a registered extractor and the symbol is a valid name, so other resources with a ':' or '#' pass through untouched.
+func ParseRef(resource string) (path, name string, ok bool) {
+	idx := strings.LastIndexAny(resource, ":#")
+	if idx <= 0 || idx == len(resource)-1 {
+		return "", "", false
+	}
+
+	path = resource[:idx]
+	name = resource[idx+1:]
+
+	if !symbolNameRegex.MatchString(name) || !HasExtractor(path) {
+		return "", "", false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", "", false
+	}
+
+	return path, name, true
+}
+
+// RefName is the context name for a symbol reference
+func RefName(path, name string) string {
+	return path + "#" + name
+}
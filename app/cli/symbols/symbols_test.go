@@ -0,0 +1,170 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const goExtractSrc = `package server
+
+import "fmt"
+
+const (
+	// DefaultAddr is used when no address is given
+	DefaultAddr = ":8080"
+	timeout     = 10
+)
+
+// Server handles requests
+type Server struct {
+	addr string
+}
+
+// Start starts the server
+func (s *Server) Start() error {
+	fmt.Println("starting", s.addr)
+	return nil
+}
+
+func (s *Server) Stop() {}
+
+func Start() {}
+`
+
+func TestGoExtract(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		startLine int
+		endLine   int
+	}{
+		{
+			name:      "Server",
+			body:      "// Server handles requests\ntype Server struct {\n\taddr string\n}",
+			startLine: 11,
+			endLine:   14,
+		},
+		{
+			name:      "Server.Start",
+			body:      "// Start starts the server\nfunc (s *Server) Start() error {\n\tfmt.Println(\"starting\", s.addr)\n\treturn nil\n}",
+			startLine: 16,
+			endLine:   20,
+		},
+		{
+			// a function is preferred over a method with the same name
+			name:      "Start",
+			body:      "func Start() {}",
+			startLine: 24,
+			endLine:   24,
+		},
+		{
+			// a method can be named without its receiver if nothing else matches
+			name:      "Stop",
+			body:      "func (s *Server) Stop() {}",
+			startLine: 22,
+			endLine:   22,
+		},
+		{
+			// only the matching spec of a grouped declaration is extracted
+			name:      "DefaultAddr",
+			body:      "// DefaultAddr is used when no address is given\n\tDefaultAddr = \":8080\"",
+			startLine: 6,
+			endLine:   7,
+		},
+	}
+
+	for _, tt := range tests {
+		symbol, err := Extract("server.go", []byte(goExtractSrc), tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if symbol == nil {
+			t.Errorf("%s: not found", tt.name)
+			continue
+		}
+		if symbol.Body != tt.body || symbol.StartLine != tt.startLine || symbol.EndLine != tt.endLine {
+			t.Errorf("%s: got %q (lines %d-%d), want %q (lines %d-%d)", tt.name, symbol.Body, symbol.StartLine, symbol.EndLine, tt.body, tt.startLine, tt.endLine)
+		}
+	}
+
+	for _, name := range []string{"Missing", "Client.Start"} {
+		symbol, err := Extract("server.go", []byte(goExtractSrc), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if symbol != nil {
+			t.Errorf("%s: expected no match, got %+v", name, symbol)
+		}
+	}
+}
+
+func TestBraceExtractArrowFunction(t *testing.T) {
+	src := `import { x } from "./x"
+
+// doubles n
+export const double = (n: number) => {
+  return n * 2
+}
+
+export const other = 1
+`
+
+	symbol, err := braceExtractor{exportKeyword: "export"}.Extract([]byte(src), "double")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if symbol == nil {
+		t.Fatal("symbol not found")
+	}
+
+	want := "// doubles n\nexport const double = (n: number) => {\n  return n * 2\n}"
+	if symbol.Body != want || symbol.StartLine != 3 || symbol.EndLine != 6 {
+		t.Errorf("got %q (lines %d-%d), want %q (lines 3-6)", symbol.Body, symbol.StartLine, symbol.EndLine, want)
+	}
+}
+
+func TestExtractUnsupportedExtension(t *testing.T) {
+	if _, err := Extract("notes.txt", []byte("Server"), "Server"); err == nil {
+		t.Errorf("expected an error for a file type without an extractor")
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	dir := t.TempDir()
+	goPath := filepath.Join(dir, "server.go")
+	txtPath := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{goPath, txtPath} {
+		if err := os.WriteFile(path, []byte(goExtractSrc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		resource string
+		path     string
+		name     string
+		ok       bool
+	}{
+		{resource: goPath + ":Server", path: goPath, name: "Server", ok: true},
+		{resource: goPath + "#Server.Start", path: goPath, name: "Server.Start", ok: true},
+		// everything else passes through as a regular resource
+		{resource: goPath + ":"},
+		{resource: goPath + ":10-20"},
+		{resource: txtPath + ":Server"},
+		{resource: filepath.Join(dir, "missing.go") + ":Server"},
+		{resource: dir + ":Server"},
+		{resource: "https://example.com:8080"},
+	}
+
+	for _, tt := range tests {
+		path, name, ok := ParseRef(tt.resource)
+		if ok != tt.ok || path != tt.path || name != tt.name {
+			t.Errorf("ParseRef(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.resource, path, name, ok, tt.path, tt.name, tt.ok)
+		}
+	}
+
+	if got := RefName("server.go", "Server.Start"); got != "server.go#Server.Start" {
+		t.Errorf("RefName = %q", got)
+	}
+}
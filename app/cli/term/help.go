@@ -19,30 +19,46 @@ var CmdDesc = map[string][2]string{
 	// "diffs":       {"d", "show diffs between plan and project files"},
 	// "preview":     {"pv", "preview the plan in a branch"},
 	"apply":    {"ap", "apply plan changes to project files"},
+	"unapply":  {"", "undo the last apply"},
 	"continue": {"c", "continue the plan"},
 	// "status":      {"s", "show status of the plan"},
-	"rewind":        {"rw", "rewind to a previous state"},
-	"ls":            {"", "list everything in context"},
-	"rm":            {"", "remove context by name, index, or glob"},
-	"clear":         {"", "remove all context"},
-	"delete-plan":   {"dp", "delete plan by name or index"},
-	"delete-branch": {"db", "delete a branch by name or index"},
-	"plans":         {"pl", "list plans"},
-	"update":        {"u", "update outdated context"},
-	"log":           {"", "show log of plan updates"},
-	"convo":         {"", "show plan conversation"},
-	"branches":      {"br", "list plan branches"},
-	"checkout":      {"co", "checkout or create a branch"},
-	"build":         {"b", "build any pending changes"},
-	"models":        {"", "show model settings"},
-	"set-model":     {"", "update model settings"},
-	"ps":            {"", "list active and recently finished plan streams"},
-	"stop":          {"", "stop an active plan stream"},
-	"connect":       {"conn", "connect to an active plan stream"},
-	"sign-in":       {"", "sign in, accept an invite, or create an account"},
-	"invite":        {"", "invite a user to join your org"},
-	"revoke":        {"", "revoke an invite or remove a user from your org"},
-	"users":         {"", "list users and pending invites in your org"},
+	"rewind":           {"rw", "rewind to a previous state"},
+	"retry":            {"", "retry the last prompt on a new branch"},
+	"ls":               {"", "list everything in context"},
+	"rm":               {"", "remove context by name, index, or glob"},
+	"clear":            {"", "remove all context"},
+	"delete-plan":      {"dp", "delete plan by name or index"},
+	"delete-branch":    {"db", "delete a branch by name or index"},
+	"plans":            {"pl", "list plans"},
+	"update":           {"u", "update outdated context"},
+	"watch":            {"", "keep context in sync as files change"},
+	"pin":              {"", "pin context so it's never trimmed to fit the token budget"},
+	"unpin":            {"", "unpin context"},
+	"priority":         {"", "set context priority: low, normal, or high"},
+	"load --set":       {"", "load a saved context set"},
+	"context save":     {"", "save the plan's context as a named set for the project"},
+	"context sets":     {"", "list the project's context sets"},
+	"context history":  {"", "show the versions of a context set"},
+	"context delete":   {"", "delete a context set"},
+	"log":              {"", "show log of plan updates"},
+	"convo":            {"", "show plan conversation"},
+	"branches":         {"br", "list plan branches"},
+	"checkout":         {"co", "checkout or create a branch"},
+	"build":            {"b", "build any pending changes"},
+	"models":           {"", "show model settings"},
+	"set-model":        {"", "update model settings"},
+	"ps":               {"", "list active and recently finished plan streams"},
+	"stop":             {"", "stop an active plan stream"},
+	"connect":          {"conn", "connect to an active plan stream or replay a finished one"},
+	"templates":        {"tpl", "list prompt templates"},
+	"templates create": {"", "create a project or org prompt template"},
+	"templates edit":   {"", "edit a prompt template"},
+	"templates delete": {"", "delete a prompt template"},
+	"tell --template":  {"", "send a prompt from a template"},
+	"sign-in":          {"", "sign in, accept an invite, or create an account"},
+	"invite":           {"", "invite a user to join your org"},
+	"revoke":           {"", "revoke an invite or remove a user from your org"},
+	"users":            {"", "list users and pending invites in your org"},
 }
 
 func PrintCmds(prefix string, cmds ...string) {
@@ -116,11 +132,15 @@ func PrintCustomHelp() {
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Changes ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "apply")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "changes", "apply", "unapply")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Context ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "clear")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "load", "ls", "rm", "update", "watch", "pin", "unpin", "priority", "clear")
+	fmt.Fprintln(builder)
+
+	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Context Sets ")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "context save", "load --set", "context sets", "context history", "context delete")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Branches ")
@@ -128,13 +148,17 @@ func PrintCustomHelp() {
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " History ")
-	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "convo", "log", "rewind")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "convo", "log", "rewind", "retry")
 	fmt.Fprintln(builder)
 
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Control ")
 	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "tell", "continue", "build")
 	fmt.Fprintln(builder)
 
+	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Templates ")
+	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "templates", "templates create", "templates edit", "templates delete")
+	fmt.Fprintln(builder)
+
 	color.New(color.Bold, color.BgCyan, color.FgHiWhite).Fprintln(builder, " Streams ")
 	printCmds(builder, " ", []color.Attribute{color.Bold, ColorHiCyan}, "ps", "connect", "stop")
 	fmt.Fprintln(builder)
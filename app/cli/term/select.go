@@ -27,6 +27,25 @@ func SelectFromList(msg string, options []string) (string, error) {
 	return selected, nil
 }
 
+func SelectManyFromList(msg string, options []string) ([]string, error) {
+	var selected []string
+	prompt := &survey.MultiSelect{
+		Message:  color.New(ColorHiMagenta, color.Bold).Sprint(msg),
+		Options:  options,
+		PageSize: 20,
+	}
+	err := survey.AskOne(prompt, &selected)
+	if err != nil {
+		if err.Error() == "interrupt" {
+			os.Exit(0)
+		}
+
+		return nil, err
+	}
+
+	return selected, nil
+}
+
 func convertToStringSlice[T any](input []T) []string {
 	var result []string
 	for _, v := range input {
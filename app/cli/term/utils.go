@@ -79,3 +79,8 @@ func getTerminalWidth() (int, error) {
 	}
 	return width, nil
 }
+
+// IsInteractive is false when stdin isn't a terminal, like when data is piped in, so prompts can't be answered
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
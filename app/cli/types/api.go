@@ -36,6 +36,17 @@ type ApiClient interface {
 	ListAllInvites() ([]*shared.Invite, *shared.ApiError)
 	DeleteInvite(inviteId string) *shared.ApiError
 
+	ListPromptTemplates() ([]*shared.PromptTemplate, *shared.ApiError)
+	CreatePromptTemplate(req shared.CreatePromptTemplateRequest) (*shared.PromptTemplate, *shared.ApiError)
+	UpdatePromptTemplate(templateId string, req shared.UpdatePromptTemplateRequest) *shared.ApiError
+	DeletePromptTemplate(templateId string) *shared.ApiError
+
+	SaveContextSet(planId, branch string, req shared.SaveContextSetRequest) (*shared.SaveContextSetResponse, *shared.ApiError)
+	ListContextSets(projectId string) ([]*shared.ContextSet, *shared.ApiError)
+	GetContextSet(projectId, name string) (*shared.ContextSet, *shared.ApiError)
+	ListContextSetVersions(projectId, name string) ([]*shared.ContextSetVersion, *shared.ApiError)
+	DeleteContextSet(projectId, name string) *shared.ApiError
+
 	CreateProject(req shared.CreateProjectRequest) (*shared.CreateProjectResponse, *shared.ApiError)
 	ListProjects() ([]*shared.Project, *shared.ApiError)
 	SetProjectPlan(projectId string, req shared.SetProjectPlanRequest) *shared.ApiError
@@ -43,7 +54,7 @@ type ApiClient interface {
 
 	ListPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
 	ListArchivedPlans(projectIds []string) ([]*shared.Plan, *shared.ApiError)
-	ListPlansRunning(projectIds []string, includeRecent bool) (*shared.ListPlansRunningResponse, *shared.ApiError)
+	ListPlansRunning(projectIds []string, includeRecent, includeShared bool) (*shared.ListPlansRunningResponse, *shared.ApiError)
 
 	GetCurrentBranchByPlanId(projectId string, req shared.GetCurrentBranchByPlanIdRequest) (map[string]*shared.Branch, *shared.ApiError)
 
@@ -56,24 +67,29 @@ type ApiClient interface {
 
 	DeletePlan(planId string) *shared.ApiError
 	DeleteAllPlans(projectId string) *shared.ApiError
-	ConnectPlan(planId, branch string, onStreamPlan OnStreamPlan) *shared.ApiError
+	ConnectPlan(planId, branch string, watch bool, onStreamPlan OnStreamPlan) *shared.ApiError
+	GetStreamLog(streamId string) (*shared.StreamLog, *shared.ApiError)
 	StopPlan(planId, branch string) *shared.ApiError
 
 	ArchivePlan(planId string) *shared.ApiError
 
 	GetCurrentPlanState(planId, branch string) (*shared.CurrentPlanState, *shared.ApiError)
-	ApplyPlan(planId, branch string) *shared.ApiError
+	ApplyPlan(planId, branch string, req shared.ApplyPlanRequest) *shared.ApiError
+	UnapplyPlan(planId, branch string, req shared.UnapplyPlanRequest) *shared.ApiError
 	RejectAllChanges(planId, branch string) *shared.ApiError
 	RejectFile(planId, branch, filePath string) *shared.ApiError
 
 	LoadContext(planId, branch string, req shared.LoadContextRequest) (*shared.LoadContextResponse, *shared.ApiError)
 	UpdateContext(planId, branch string, req shared.UpdateContextRequest) (*shared.UpdateContextResponse, *shared.ApiError)
 	DeleteContext(planId, branch string, req shared.DeleteContextRequest) (*shared.DeleteContextResponse, *shared.ApiError)
+	SetContextPriority(planId, branch string, req shared.SetContextPriorityRequest) (*shared.SetContextPriorityResponse, *shared.ApiError)
 	ListContext(planId, branch string) ([]*shared.Context, *shared.ApiError)
+	GetMissingContextBlobs(planId, branch string, req shared.MissingContextBlobsRequest) (*shared.MissingContextBlobsResponse, *shared.ApiError)
 
 	ListConvo(planId, branch string) ([]*shared.ConvoMessage, *shared.ApiError)
 	ListLogs(planId, branch string) (*shared.LogResponse, *shared.ApiError)
 	RewindPlan(planId, branch string, req shared.RewindPlanRequest) (*shared.RewindPlanResponse, *shared.ApiError)
+	RetryPlan(planId, branch string, req shared.RetryPlanRequest) (*shared.RetryPlanResponse, *shared.ApiError)
 
 	ListBranches(planId string) ([]*shared.Branch, *shared.ApiError)
 	DeleteBranch(planId, branch string) *shared.ApiError
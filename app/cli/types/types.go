@@ -23,6 +23,28 @@ type LoadContextParams struct {
 	Recursive       bool
 	NamesOnly       bool
 	ForceSkipIgnore bool
+	GitDiff         string
+	GitCommit       string
+	GitChangedSince string
+	ExecCommands    []string
+	Priority        shared.ContextPriority
+	Pinned          bool
+	Outline         bool
+	RepoMap         bool
+	MapTokens       int
+	WithDeps        bool
+	DepsDepth       int
+	DepsTokens      int
+	CrawlDepth      int
+	SamePrefix      bool
+}
+
+type ApplyPlanParams struct {
+	AutoConfirm bool
+	// only apply the changes to these files, leaving the rest pending
+	FilePaths []string
+	// write and commit the changes on this git branch, then switch back
+	GitBranch string
 }
 
 type ContextOutdatedResult struct {
@@ -33,6 +55,8 @@ type ContextOutdatedResult struct {
 	NumFiles        int
 	NumUrls         int
 	NumTrees        int
+	NumGit          int
+	NumCommands     int
 	NumFilesRemoved int
 	NumTreesRemoved int
 }
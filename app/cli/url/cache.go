@@ -0,0 +1,109 @@
+package url
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"plandex/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cachedPage is a converted page stored with the validators needed to revalidate it
+type cachedPage struct {
+	Url          string    `json:"url"`
+	FinalUrl     string    `json:"finalUrl,omitempty"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	Body         string    `json:"body"`
+	Links        []string  `json:"links,omitempty"`
+}
+
+func (c *cachedPage) page() *Page {
+	finalUrl := c.FinalUrl
+	if finalUrl == "" {
+		// cached before final URLs were stored
+		finalUrl = c.Url
+	}
+	return &Page{Url: c.Url, FinalUrl: finalUrl, Body: c.Body, Links: c.Links}
+}
+
+func cachePath(rawUrl string) string {
+	hash := sha256.Sum256([]byte(rawUrl))
+	return filepath.Join(fs.CacheDir, "urls", hex.EncodeToString(hash[:])+".json")
+}
+
+// the cache is only an optimization, so a page that can't be read from or written to it is just fetched again
+func readCachedPage(rawUrl string) *cachedPage {
+	if fs.CacheDir == "" {
+		return nil
+	}
+
+	bytes, err := os.ReadFile(cachePath(rawUrl))
+	if err != nil {
+		return nil
+	}
+
+	var cached cachedPage
+	err = json.Unmarshal(bytes, &cached)
+	if err != nil || cached.Url != rawUrl {
+		return nil
+	}
+
+	return &cached
+}
+
+func writeCachedPage(cached *cachedPage) {
+	if fs.CacheDir == "" {
+		return
+	}
+
+	bytes, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+
+	path := cachePath(cached.Url)
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	os.WriteFile(path, bytes, 0644)
+}
+
+// a page is only worth caching if it can be revalidated or the server says how long it stays fresh
+func cacheable(header http.Header) bool {
+	if strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != "" || !cacheExpiresAt(header).IsZero()
+}
+
+// cacheExpiresAt returns when a page stops being fresh per its Cache-Control max-age. Pages without one, or with no-cache, are revalidated every time.
+func cacheExpiresAt(header http.Header) time.Time {
+	var maxAge int
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-cache" {
+			return time.Time{}
+		}
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			n, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil {
+				maxAge = n
+			}
+		}
+	}
+
+	if maxAge <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(time.Duration(maxAge) * time.Second)
+}
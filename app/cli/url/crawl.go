@@ -0,0 +1,129 @@
+package url
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	// MaxCrawlPages caps how many pages a crawl loads so a broad site can't flood context
+	MaxCrawlPages = 50
+
+	crawlConcurrency = 5
+)
+
+// links to these are downloads or assets rather than pages
+var nonPageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".ico": true,
+	".pdf": true, ".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".dmg": true, ".exe": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".css": true, ".js": true, ".woff": true, ".woff2": true, ".ttf": true,
+}
+
+type CrawlParams struct {
+	// levels of links to follow from the start page
+	Depth int
+	// only follow links under the start page's path, like the other pages of a docs section
+	SamePrefix bool
+}
+
+type CrawlResult struct {
+	Pages []*Page
+	// pages that were linked but failed to load
+	NumFailed int
+	// whether MaxCrawlPages was reached before every link was followed
+	Truncated bool
+}
+
+// Crawl fetches startUrl and then follows its links breadth-first on the same host, up to params.Depth levels and MaxCrawlPages pages. If startUrl redirects, the host and path it redirects to set the scope, since that's what its links are relative to. Only an error fetching startUrl itself is returned; linked pages that fail are counted and skipped.
+func Crawl(startUrl string, params CrawlParams) (*CrawlResult, error) {
+	startPage, err := FetchPage(startUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := url.Parse(startPage.FinalUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := crawlPrefix(start)
+
+	inScope := func(link string) bool {
+		u, err := url.Parse(link)
+		if err != nil || u.Host != start.Host {
+			return false
+		}
+		if nonPageExts[strings.ToLower(path.Ext(u.Path))] {
+			return false
+		}
+		if params.SamePrefix {
+			p := strings.TrimSuffix(u.Path, "/")
+			return p == prefix || strings.HasPrefix(p, prefix+"/")
+		}
+		return true
+	}
+
+	res := &CrawlResult{Pages: []*Page{startPage}}
+	seen := map[string]bool{startUrl: true, strings.SplitN(startUrl, "#", 2)[0]: true, startPage.FinalUrl: true}
+	level := []*Page{startPage}
+
+	for depth := 1; depth <= params.Depth && len(level) > 0; depth++ {
+		var links []string
+		for _, page := range level {
+			for _, link := range page.Links {
+				if seen[link] || !inScope(link) {
+					continue
+				}
+				seen[link] = true
+
+				if len(res.Pages)+len(links) >= MaxCrawlPages {
+					res.Truncated = true
+					break
+				}
+				links = append(links, link)
+			}
+		}
+
+		pages := make([]*Page, len(links))
+		sem := make(chan struct{}, crawlConcurrency)
+		var wg sync.WaitGroup
+
+		for i, link := range links {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, link string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				page, err := FetchPage(link)
+				if err == nil {
+					pages[i] = page
+				}
+			}(i, link)
+		}
+		wg.Wait()
+
+		level = nil
+		for _, page := range pages {
+			if page == nil {
+				res.NumFailed++
+				continue
+			}
+			res.Pages = append(res.Pages, page)
+			level = append(level, page)
+		}
+	}
+
+	return res, nil
+}
+
+// crawlPrefix is the start page's path without a trailing slash. For a page that looks like a file (e.g. /docs/guide/index.html), it's the page's directory.
+func crawlPrefix(start *url.URL) string {
+	p := start.Path
+	if !strings.HasSuffix(p, "/") && path.Ext(p) != "" {
+		p = path.Dir(p)
+	}
+	return strings.TrimSuffix(p, "/")
+}
@@ -0,0 +1,375 @@
+package url
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+var (
+	// page chrome and non-content elements that are left out of the markdown
+	skippedTags = map[string]bool{
+		"script": true, "style": true, "noscript": true, "template": true, "svg": true, "canvas": true, "iframe": true,
+		"nav": true, "aside": true, "form": true, "button": true, "select": true,
+		"head": true, "img": true, "picture": true, "video": true, "audio": true,
+	}
+
+	blockTags = map[string]bool{
+		"html": true, "body": true, "main": true, "article": true, "section": true, "div": true,
+		"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"pre": true, "ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+		"table": true, "blockquote": true, "hr": true, "figure": true, "figcaption": true, "details": true, "summary": true,
+	}
+
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+	codeLangRegex   = regexp.MustCompile(`(?:^|\s)(?:language|lang)-([\w+#-]+)`)
+)
+
+// ExtractReadableContent converts an HTML page to markdown, keeping headings, paragraphs, lists, tables, code blocks, and links. The main content element is used when the page has one, and navigation, scripts, and other page chrome are dropped. It also returns every http(s) link on the page, including those in navigation, resolved against base with fragments removed.
+func ExtractReadableContent(htmlContent string, base *url.URL) (string, []string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse HTML: %v", err)
+	}
+
+	var links []string
+	seen := map[string]bool{}
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link := resolveLink(base, href)
+		if link == "" {
+			return
+		}
+		link = strings.SplitN(link, "#", 2)[0]
+		if !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	})
+
+	// site-wide banners and footers, unlike an article's own header
+	doc.Find("body > header, body > footer, [role=banner], [role=contentinfo]").Remove()
+
+	content := doc.Find("main, [role=main], article").First()
+	if content.Length() == 0 {
+		content = doc.Find("body")
+	}
+	if content.Length() == 0 {
+		content = doc.Selection
+	}
+
+	w := &markdownWriter{base: base}
+	for _, node := range content.Nodes {
+		w.writeBlock(node)
+	}
+
+	body := strings.Join(w.blocks, "\n\n")
+
+	title := strings.TrimSpace(doc.Find("title").First().Text())
+	if title != "" && !strings.HasPrefix(body, "# ") {
+		body = "# " + title + "\n\n" + body
+	}
+
+	return body, links, nil
+}
+
+func resolveLink(base *url.URL, href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return ""
+	}
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	return u.String()
+}
+
+type markdownWriter struct {
+	base   *url.URL
+	blocks []string
+	inline strings.Builder
+}
+
+func (w *markdownWriter) addBlock(block string) {
+	block = strings.TrimSpace(block)
+	if block != "" {
+		w.blocks = append(w.blocks, block)
+	}
+}
+
+func (w *markdownWriter) flushInline() {
+	lines := strings.Split(w.inline.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	w.addBlock(strings.Join(lines, "\n"))
+	w.inline.Reset()
+}
+
+// writeBlock renders a node in block context. Runs of inline content between block elements become paragraphs.
+func (w *markdownWriter) writeBlock(n *html.Node) {
+	if n.Type == html.DocumentNode {
+		w.writeChildBlocks(n)
+		return
+	}
+
+	if n.Type == html.TextNode {
+		w.inline.WriteString(w.renderInline(n))
+		return
+	}
+
+	if n.Type != html.ElementNode || skippedTags[n.Data] {
+		return
+	}
+
+	if !blockTags[n.Data] {
+		w.inline.WriteString(w.renderInline(n))
+		return
+	}
+
+	w.flushInline()
+
+	switch n.Data {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		text := strings.TrimSpace(w.renderInlineChildren(n))
+		if text != "" {
+			w.addBlock(strings.Repeat("#", level) + " " + text)
+		}
+
+	case "pre":
+		code := strings.Trim(nodeText(n), "\n")
+		if strings.TrimSpace(code) != "" {
+			w.addBlock("```" + codeLang(n) + "\n" + code + "\n```")
+		}
+
+	case "ul", "ol":
+		w.addBlock(strings.Join(w.renderList(n, 0), "\n"))
+
+	case "table":
+		w.addBlock(w.renderTable(n))
+
+	case "blockquote":
+		sub := &markdownWriter{base: w.base}
+		sub.writeChildBlocks(n)
+		lines := strings.Split(strings.Join(sub.blocks, "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		w.addBlock(strings.Join(lines, "\n"))
+
+	case "hr":
+		w.addBlock("---")
+
+	default:
+		w.writeChildBlocks(n)
+	}
+}
+
+func (w *markdownWriter) writeChildBlocks(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.writeBlock(c)
+	}
+	w.flushInline()
+}
+
+func (w *markdownWriter) renderInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return whitespaceRegex.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	if skippedTags[n.Data] {
+		return ""
+	}
+
+	switch n.Data {
+	case "br":
+		return "\n"
+
+	case "code", "kbd", "samp":
+		text := strings.TrimSpace(whitespaceRegex.ReplaceAllString(nodeText(n), " "))
+		if text == "" {
+			return ""
+		}
+		return "`" + text + "`"
+
+	case "strong", "b":
+		return wrapInline(w.renderInlineChildren(n), "**")
+
+	case "em", "i":
+		return wrapInline(w.renderInlineChildren(n), "*")
+
+	case "a":
+		text := w.renderInlineChildren(n)
+		link := resolveLink(w.base, attr(n, "href"))
+		if link == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + link + ")"
+	}
+
+	return w.renderInlineChildren(n)
+}
+
+func (w *markdownWriter) renderInlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(w.renderInline(c))
+	}
+	return sb.String()
+}
+
+// renderList renders each item on its own line, indenting nested lists under their item
+func (w *markdownWriter) renderList(n *html.Node, depth int) []string {
+	var lines []string
+	indent := strings.Repeat("  ", depth)
+	num := 0
+
+	for item := n.FirstChild; item != nil; item = item.NextSibling {
+		if item.Type != html.ElementNode || item.Data != "li" {
+			continue
+		}
+		num++
+
+		marker := "-"
+		if n.Data == "ol" {
+			marker = strconv.Itoa(num) + "."
+		}
+
+		var text strings.Builder
+		var nested []string
+		for c := item.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.Data == "ul" || c.Data == "ol") {
+				nested = append(nested, w.renderList(c, depth+1)...)
+				continue
+			}
+			if c.Type == html.ElementNode && c.Data == "pre" {
+				text.WriteString(" `" + strings.TrimSpace(whitespaceRegex.ReplaceAllString(nodeText(c), " ")) + "`")
+				continue
+			}
+			text.WriteString(w.renderInline(c))
+		}
+
+		lines = append(lines, indent+marker+" "+strings.TrimSpace(whitespaceRegex.ReplaceAllString(text.String(), " ")))
+		lines = append(lines, nested...)
+	}
+
+	return lines
+}
+
+func (w *markdownWriter) renderTable(n *html.Node) string {
+	var rows [][]string
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.Data == "tr" {
+				var row []string
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+						text := strings.TrimSpace(whitespaceRegex.ReplaceAllString(w.renderInlineChildren(cell), " "))
+						row = append(row, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	numCols := 0
+	for _, row := range rows {
+		numCols = max(numCols, len(row))
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < numCols {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", numCols))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func wrapInline(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	// keep surrounding spaces outside the markers so they still separate words
+	leading := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailing := text[len(strings.TrimRight(text, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "br" {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// codeLang finds a language-* or lang-* class on a pre element or the code element inside it
+func codeLang(pre *html.Node) string {
+	classes := attr(pre, "class")
+	for c := pre.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == "code" {
+			classes += " " + attr(c, "class")
+		}
+	}
+	if m := codeLangRegex.FindStringSubmatch(classes); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -5,12 +5,9 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"plandex/term"
 	"regexp"
 	"strings"
 	"time"
-
-	"github.com/PuerkitoBio/goquery"
 )
 
 const (
@@ -20,7 +17,30 @@ const (
 	maxContentSizeInMB = 10
 )
 
+// Page is the readable content of a fetched URL along with the absolute URLs it links to
+type Page struct {
+	Url string
+	// the URL the page was served from after redirects, which its links are resolved against
+	FinalUrl string
+	Body     string
+	Links    []string
+}
+
 func FetchURLContent(url string) (string, error) {
+	page, err := FetchPage(url)
+	if err != nil {
+		return "", err
+	}
+	return page.Body, nil
+}
+
+// FetchPage fetches a URL, converting HTML to markdown. Responses are cached locally: a cached page that's still fresh per the server's Cache-Control max-age is used without a request, and otherwise the page is revalidated with its ETag or Last-Modified date so an unchanged page isn't downloaded and converted again.
+func FetchPage(rawUrl string) (*Page, error) {
+	cached := readCachedPage(rawUrl)
+	if cached != nil && time.Now().Before(cached.ExpiresAt) {
+		return cached.page(), nil
+	}
+
 	client := &http.Client{
 		Timeout: httpTimeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -31,14 +51,34 @@ func FetchURLContent(url string) (string, error) {
 		},
 	}
 
-	resp, err := client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, rawUrl, nil)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cached.ExpiresAt = cacheExpiresAt(resp.Header)
+		writeCachedPage(cached)
+		return cached.page(), nil
+	}
+
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return "", errors.New("non-2xx HTTP response status: " + resp.Status)
+		return nil, errors.New("non-2xx HTTP response status: " + resp.Status)
 	}
 
 	// Limit the response reader to a maximum amount
@@ -46,25 +86,33 @@ func FetchURLContent(url string) (string, error) {
 
 	content, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
+	page := &Page{Url: rawUrl, FinalUrl: resp.Request.URL.String(), Body: string(content)}
+
 	contentType := resp.Header.Get("Content-Type")
 	if strings.Contains(contentType, "text/html") {
-		return ExtractTextualContent(string(content)), nil
-	} else {
-		return string(content), nil
+		// links are resolved against the final URL after redirects
+		page.Body, page.Links, err = ExtractReadableContent(string(content), resp.Request.URL)
+		if err != nil {
+			return nil, err
+		}
 	}
-}
 
-func ExtractTextualContent(htmlContent string) string {
-	r := strings.NewReader(htmlContent)
-	doc, err := goquery.NewDocumentFromReader(r)
-	if err != nil {
-		term.OutputErrorAndExit("Failed to parse HTML: %v", err)
+	if cacheable(resp.Header) {
+		writeCachedPage(&cachedPage{
+			Url:          rawUrl,
+			FinalUrl:     page.FinalUrl,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			ExpiresAt:    cacheExpiresAt(resp.Header),
+			Body:         page.Body,
+			Links:        page.Links,
+		})
 	}
 
-	return doc.Text()
+	return page, nil
 }
 
 func SanitizeURL(url string) string {
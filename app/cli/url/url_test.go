@@ -0,0 +1,340 @@
+package url
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"plandex/fs"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExtractReadableContent(t *testing.T) {
+	html := `<html><head><title>Guide</title><script>var x = 1</script></head>
+<body>
+<header>Site banner</header>
+<nav><a href="/docs/other">Other</a></nav>
+<main>
+<h1>Getting started</h1>
+<p>Install the <strong>CLI</strong> with <code>go install</code>, then see <a href="setup#step-2">setup</a> or <em>the FAQ</em>.</p>
+<ul><li>First</li><li>Second<ul><li>Nested</li></ul></li></ul>
+<ol><li>One</li><li>Two</li></ol>
+<pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+<table><tr><th>Flag</th><th>Meaning</th></tr><tr><td>-v</td><td>verbose</td></tr></table>
+<blockquote><p>Note this.</p></blockquote>
+<img src="x.png">
+</main>
+<footer>Copyright</footer>
+</body></html>`
+
+	base, _ := url.Parse("https://example.com/docs/guide")
+	body, links, err := ExtractReadableContent(html, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Getting started\n\n" +
+		"Install the **CLI** with `go install`, then see [setup](https://example.com/docs/setup#step-2) or *the FAQ*.\n\n" +
+		"- First\n- Second\n  - Nested\n\n" +
+		"1. One\n2. Two\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"| Flag | Meaning |\n| --- | --- |\n| -v | verbose |\n\n" +
+		"> Note this."
+	if body != want {
+		t.Errorf("got:\n%s\n\nwant:\n%s", body, want)
+	}
+
+	// navigation links are kept for crawling even though nav is left out of the body
+	wantLinks := []string{"https://example.com/docs/other", "https://example.com/docs/setup"}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Errorf("links = %v, want %v", links, wantLinks)
+	}
+}
+
+func TestExtractReadableContentAddsTitle(t *testing.T) {
+	base, _ := url.Parse("https://example.com/")
+	body, _, err := ExtractReadableContent("<html><head><title>Home</title></head><body><p>Welcome</p></body></html>", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "# Home\n\nWelcome" {
+		t.Errorf("got %q", body)
+	}
+}
+
+// useTempCache points the page cache at a temp dir, or disables it
+func useTempCache(t *testing.T, enabled bool) {
+	cacheDir := fs.CacheDir
+	fs.CacheDir = ""
+	if enabled {
+		fs.CacheDir = t.TempDir()
+	}
+	t.Cleanup(func() { fs.CacheDir = cacheDir })
+}
+
+func TestFetchPageRevalidatesCachedPage(t *testing.T) {
+	useTempCache(t, true)
+
+	var mu sync.Mutex
+	var numFull, numNotModified int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			numNotModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		numFull++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><main><h1>Docs</h1><p>Body</p><a href="/next">next</a></main></body></html>`)
+	}))
+	defer server.Close()
+
+	first, err := FetchPage(server.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := FetchPage(server.URL + "/docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if numFull != 1 || numNotModified != 1 {
+		t.Errorf("got %d full responses and %d 304s, want 1 of each", numFull, numNotModified)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("the revalidated page doesn't match the cached one: %+v vs %+v", first, second)
+	}
+	if second.Body != "# Docs\n\nBody\n\n[next]("+server.URL+"/next)" {
+		t.Errorf("unexpected body: %q", second.Body)
+	}
+}
+
+func TestFetchPageUsesFreshCacheWithoutRequest(t *testing.T) {
+	useTempCache(t, true)
+
+	var mu sync.Mutex
+	numRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		numRequests++
+		mu.Unlock()
+
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		fmt.Fprint(w, "plain text")
+	}))
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		page, err := FetchPage(server.URL + "/notes.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Body != "plain text" {
+			t.Errorf("body = %q", page.Body)
+		}
+	}
+
+	if numRequests != 1 {
+		t.Errorf("got %d requests, want 1", numRequests)
+	}
+}
+
+func TestFetchPageDoesNotCacheNoStore(t *testing.T) {
+	useTempCache(t, true)
+
+	numRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+		w.Header().Set("Cache-Control", "no-store, max-age=3600")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "secret")
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err := FetchPage(server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if numRequests != 2 {
+		t.Errorf("got %d requests, want 2", numRequests)
+	}
+}
+
+// newTestSite serves pages that link to each other by path
+func newTestSite(t *testing.T, linksByPath map[string][]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		links, ok := linksByPath[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><body><main><h1>%s</h1>", r.URL.Path)
+		for _, link := range links {
+			fmt.Fprintf(w, `<a href="%s">%s</a> `, link, link)
+		}
+		fmt.Fprint(w, "</main></body></html>")
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func crawledPaths(t *testing.T, server *httptest.Server, res *CrawlResult) []string {
+	var paths []string
+	for _, page := range res.Pages {
+		paths = append(paths, strings.TrimPrefix(page.Url, server.URL))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestCrawlDepth(t *testing.T) {
+	useTempCache(t, false)
+
+	server := newTestSite(t, map[string][]string{
+		"/docs/":       {"/docs/a", "/docs/b", "/docs/logo.png", "https://elsewhere.example.com/"},
+		"/docs/a":      {"/docs/a/deep", "/docs/"},
+		"/docs/b":      {"/docs/missing"},
+		"/docs/a/deep": {"/docs/a/deeper"},
+	})
+
+	tests := []struct {
+		depth     int
+		want      []string
+		numFailed int
+	}{
+		{0, []string{"/docs/"}, 0},
+		{1, []string{"/docs/", "/docs/a", "/docs/b"}, 0},
+		{2, []string{"/docs/", "/docs/a", "/docs/a/deep", "/docs/b"}, 1},
+	}
+
+	for _, tt := range tests {
+		res, err := Crawl(server.URL+"/docs/", CrawlParams{Depth: tt.depth})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := crawledPaths(t, server, res); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("depth %d: got %v, want %v", tt.depth, got, tt.want)
+		}
+		if res.NumFailed != tt.numFailed {
+			t.Errorf("depth %d: NumFailed = %d, want %d", tt.depth, res.NumFailed, tt.numFailed)
+		}
+		if res.Truncated {
+			t.Errorf("depth %d: shouldn't be truncated", tt.depth)
+		}
+	}
+}
+
+func TestCrawlSamePrefix(t *testing.T) {
+	useTempCache(t, false)
+
+	server := newTestSite(t, map[string][]string{
+		"/docs/guide/index.html": {"/docs/guide/install", "/docs/guide", "/docs/api", "/blog/post", "/docs/guidebook"},
+		"/docs/guide/install":    {},
+		"/docs/guide":            {},
+		"/docs/api":              {},
+		"/blog/post":             {},
+		"/docs/guidebook":        {},
+	})
+
+	res, err := Crawl(server.URL+"/docs/guide/index.html", CrawlParams{Depth: 1, SamePrefix: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"/docs/guide", "/docs/guide/index.html", "/docs/guide/install"}
+	if got := crawledPaths(t, server, res); !reflect.DeepEqual(got, want) {
+		t.Errorf("with --same-prefix got %v, want %v", got, want)
+	}
+
+	res, err = Crawl(server.URL+"/docs/guide/index.html", CrawlParams{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pages) != 6 {
+		t.Errorf("without --same-prefix got %d pages, want 6", len(res.Pages))
+	}
+}
+
+func TestCrawlMaxPages(t *testing.T) {
+	useTempCache(t, false)
+
+	linksByPath := map[string][]string{"/": nil}
+	for i := 0; i < MaxCrawlPages+10; i++ {
+		path := fmt.Sprintf("/page-%d", i)
+		linksByPath["/"] = append(linksByPath["/"], path)
+		linksByPath[path] = nil
+	}
+	server := newTestSite(t, linksByPath)
+
+	res, err := Crawl(server.URL+"/", CrawlParams{Depth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Pages) != MaxCrawlPages {
+		t.Errorf("got %d pages, want %d", len(res.Pages), MaxCrawlPages)
+	}
+	if !res.Truncated {
+		t.Errorf("expected the crawl to be truncated")
+	}
+}
+
+func TestCrawlStartPageError(t *testing.T) {
+	useTempCache(t, false)
+
+	server := newTestSite(t, map[string][]string{})
+
+	if _, err := Crawl(server.URL+"/missing", CrawlParams{Depth: 1}); err == nil {
+		t.Errorf("expected an error when the start page fails")
+	}
+}
+
+func TestCrawlFollowsStartRedirect(t *testing.T) {
+	useTempCache(t, false)
+
+	site := newTestSite(t, map[string][]string{
+		"/docs/":     {"/docs/a", "/blog/post"},
+		"/docs/a":    {},
+		"/blog/post": {},
+	})
+
+	// like example.com redirecting to www.example.com
+	redirector := httptest.NewServer(http.RedirectHandler(site.URL+"/docs/", http.StatusMovedPermanently))
+	t.Cleanup(redirector.Close)
+
+	res, err := Crawl(redirector.URL+"/", CrawlParams{Depth: 1, SamePrefix: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Pages) != 2 || res.Pages[1].Url != site.URL+"/docs/a" {
+		var urls []string
+		for _, page := range res.Pages {
+			urls = append(urls, page.Url)
+		}
+		t.Errorf("got pages %v, want the start page and %s/docs/a", urls, site.URL)
+	}
+	if res.Pages[0].FinalUrl != site.URL+"/docs/" {
+		t.Errorf("FinalUrl = %s, want %s/docs/", res.Pages[0].FinalUrl, site.URL)
+	}
+}
@@ -0,0 +1,522 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/plandex/plandex/shared"
+)
+
+// Context bodies are stored once per org under their content hash, so identical files loaded into different plans or branches share storage. Plan context dirs reference blobs by hash from the meta file. A blob is garbage collected once no plan references it, whether in a branch's current context or anywhere in the history a plan can be rewound to.
+
+var contextBlobHashRegex = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// matches blob references in context meta files, including in git diffs of them
+var contextBlobRefRegex = regexp.MustCompile(`"(?:bodyHash|fileBodyHash)": "([a-f0-9]{64})"`)
+
+// blobs newer than this are never collected, so a blob stored for a load or update that hasn't been committed yet isn't removed out from under it
+const contextBlobGCGracePeriod = time.Hour
+
+func getOrgContextBlobsDir(orgId string) string {
+	return filepath.Join(BaseDir, "orgs", orgId, "context_blobs")
+}
+
+func getContextBlobPath(orgId, hash string) string {
+	return filepath.Join(getOrgContextBlobsDir(orgId), hash[:2], hash)
+}
+
+// StoreContextBlob stores body under its content hash if it isn't stored already and returns the hash
+func StoreContextBlob(orgId, body string) (string, error) {
+	hash := shared.GetContextBodyHash(body)
+	path := getContextBlobPath(orgId, hash)
+
+	if _, err := os.Stat(path); err == nil {
+		// a reused blob is as good as new for garbage collection
+		now := time.Now()
+		if err = os.Chtimes(path, now, now); err != nil {
+			return "", fmt.Errorf("error touching context blob: %v", err)
+		}
+		return hash, nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("error creating context blob dir: %v", err)
+	}
+
+	// write to a temp file and rename so concurrent loads of the same content never see a partial blob
+	tmpPath := path + "." + uuid.New().String() + ".tmp"
+	if err = os.WriteFile(tmpPath, []byte(body), 0644); err != nil {
+		return "", fmt.Errorf("error writing context blob: %v", err)
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("error renaming context blob: %v", err)
+	}
+
+	return hash, nil
+}
+
+func GetContextBlob(orgId, hash string) (string, error) {
+	if !contextBlobHashRegex.MatchString(hash) {
+		return "", fmt.Errorf("invalid context blob hash: %s", hash)
+	}
+
+	bytes, err := os.ReadFile(getContextBlobPath(orgId, hash))
+	if err != nil {
+		return "", fmt.Errorf("error reading context blob %s: %v", hash, err)
+	}
+
+	return string(bytes), nil
+}
+
+// GetContextBlobPlanIds returns the plans whose context blobs the user can reference by hash alone: every plan in the org they can access, along with planId. Content from these plans could be read anyway, so a hash-only reference can't be used to read or probe for content from plans the user can't access.
+func GetContextBlobPlanIds(orgId, userId, planId string) ([]string, error) {
+	planIds := []string{planId}
+	if userId == "" {
+		return planIds, nil
+	}
+
+	accessible, err := ListAccessiblePlanIds(orgId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range accessible {
+		if id != planId {
+			planIds = append(planIds, id)
+		}
+	}
+
+	return planIds, nil
+}
+
+// getKnownContextBlobHashes returns the blobs referenced by any of the plans
+func getKnownContextBlobHashes(orgId string, planIds []string) (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, planId := range planIds {
+		if err := addIndexedContextBlobRefs(orgId, planId, known); err != nil {
+			return nil, err
+		}
+	}
+	return known, nil
+}
+
+// GetMissingContextBlobs returns the hashes that the client needs to upload bodies for: any that aren't referenced by one of the plans from GetContextBlobPlanIds.
+func GetMissingContextBlobs(orgId string, planIds []string, hashes []string) ([]string, error) {
+	known, err := getKnownContextBlobHashes(orgId, planIds)
+	if err != nil {
+		return nil, fmt.Errorf("error getting context blob hashes: %v", err)
+	}
+
+	missing := []string{}
+
+	for _, hash := range hashes {
+		if !contextBlobHashRegex.MatchString(hash) {
+			return nil, fmt.Errorf("invalid context blob hash: %s", hash)
+		}
+
+		if known[hash] {
+			// a blob can still be missing if it was stored before an upgrade or removed by hand
+			_, err := os.Stat(getContextBlobPath(orgId, hash))
+			if err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("error checking context blob %s: %v", hash, err)
+			}
+		}
+
+		missing = append(missing, hash)
+	}
+
+	return missing, nil
+}
+
+// resolveContextBody fills in a body that the client left out because one of the plans it can reference already does. A body that was sent must match its hash if one was given.
+func resolveContextBody(orgId string, known map[string]bool, body *string, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	if *body == "" {
+		if !known[hash] {
+			return fmt.Errorf("context body for hash %s wasn't sent and isn't referenced by an accessible plan", hash)
+		}
+
+		blob, err := GetContextBlob(orgId, hash)
+		if err != nil {
+			return err
+		}
+		*body = blob
+		return nil
+	}
+
+	if shared.GetContextBodyHash(*body) != hash {
+		return fmt.Errorf("context body doesn't match its hash %s", hash)
+	}
+
+	return nil
+}
+
+func resolveLoadContextBlobs(orgId string, planIds []string, req *shared.LoadContextRequest) error {
+	known, err := getKnownContextBlobHashes(orgId, planIds)
+	if err != nil {
+		return fmt.Errorf("error getting context blob hashes: %v", err)
+	}
+
+	for _, params := range *req {
+		if err := resolveContextBody(orgId, known, &params.Body, params.BodyHash); err != nil {
+			return err
+		}
+		if err := resolveContextBody(orgId, known, &params.FileBody, params.FileBodyHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resolveUpdateContextBlobs(orgId string, planIds []string, req *shared.UpdateContextRequest) error {
+	known, err := getKnownContextBlobHashes(orgId, planIds)
+	if err != nil {
+		return fmt.Errorf("error getting context blob hashes: %v", err)
+	}
+
+	for _, params := range *req {
+		if err := resolveContextBody(orgId, known, &params.Body, params.BodyHash); err != nil {
+			return err
+		}
+		if err := resolveContextBody(orgId, known, &params.FileBody, params.FileBodyHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var contextBlobGCMu sync.Mutex
+
+// orgs with a collection running, and whether another was requested while it ran
+var contextBlobGCPending = map[string]bool{}
+
+// ScheduleContextBlobGC collects the org's unreferenced context blobs in the background. It's called whenever references can go away: when a plan or branch is deleted, or a plan is rewound past the loads that referenced them. Requests made while a collection is running are coalesced into one more run.
+func ScheduleContextBlobGC(orgId string) {
+	contextBlobGCMu.Lock()
+	defer contextBlobGCMu.Unlock()
+
+	if _, running := contextBlobGCPending[orgId]; running {
+		contextBlobGCPending[orgId] = true
+		return
+	}
+	contextBlobGCPending[orgId] = false
+
+	go func() {
+		for {
+			if err := GCContextBlobs(orgId); err != nil {
+				log.Printf("Error collecting context blobs for org %s: %v\n", orgId, err)
+			}
+
+			contextBlobGCMu.Lock()
+			if !contextBlobGCPending[orgId] {
+				delete(contextBlobGCPending, orgId)
+				contextBlobGCMu.Unlock()
+				return
+			}
+			contextBlobGCPending[orgId] = false
+			contextBlobGCMu.Unlock()
+		}
+	}()
+}
+
+// GCContextBlobs removes the org's context blobs that no plan references, in any branch's current context or history, and that are older than the grace period. References come from each plan's index rather than its history, so the cost doesn't grow with the org's history.
+func GCContextBlobs(orgId string) error {
+	referenced := map[string]bool{}
+
+	plansDir := filepath.Join(BaseDir, "orgs", orgId, "plans")
+	planDirs, err := os.ReadDir(plansDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading plans dir: %v", err)
+	}
+
+	planIds := map[string]bool{}
+	for _, planDir := range planDirs {
+		if !planDir.IsDir() {
+			continue
+		}
+		planIds[planDir.Name()] = true
+		// if any plan can't be read, nothing is collected rather than risk removing blobs it references
+		if err := addIndexedContextBlobRefs(orgId, planDir.Name(), referenced); err != nil {
+			return err
+		}
+	}
+
+	// indexes left by plans that are gone would otherwise accumulate
+	indexFiles, err := os.ReadDir(getOrgContextBlobRefsDir(orgId))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading context blob refs dir: %v", err)
+	}
+	for _, file := range indexFiles {
+		if !planIds[file.Name()] && !strings.HasSuffix(file.Name(), ".tmp") {
+			if err := removeDeletedPlanContextBlobRefs(orgId, file.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	cutoff := time.Now().Add(-contextBlobGCGracePeriod)
+	numRemoved := 0
+
+	err = filepath.WalkDir(getOrgContextBlobsDir(orgId), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		// temp files left by interrupted writes are collected too
+		hash := strings.SplitN(d.Name(), ".", 2)[0]
+		if referenced[hash] && !strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		numRemoved++
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error removing unreferenced context blobs: %v", err)
+	}
+
+	if numRemoved > 0 {
+		log.Printf("Removed %d unreferenced context blobs for org %s\n", numRemoved, orgId)
+	}
+
+	return nil
+}
+
+// Each plan keeps an index of every blob it references, in its working tree or any commit reachable from its branches, so collection and hash-only references don't need to walk plan history. Storing context only adds to the index. It's rebuilt from the plan's own history when references can go away, on a rewind or branch delete.
+
+// guards index files, so an append can't be lost to a concurrent rebuild
+var contextBlobRefsMu sync.Mutex
+
+func getOrgContextBlobRefsDir(orgId string) string {
+	return filepath.Join(BaseDir, "orgs", orgId, "context_blob_refs")
+}
+
+func getPlanContextBlobRefsPath(orgId, planId string) string {
+	return filepath.Join(getOrgContextBlobRefsDir(orgId), planId)
+}
+
+// addPlanContextBlobRefs adds hashes to the plan's index
+func addPlanContextBlobRefs(orgId, planId string, hashes ...string) error {
+	contextBlobRefsMu.Lock()
+	defer contextBlobRefsMu.Unlock()
+
+	// plans from before indexes existed are indexed first so their earlier references aren't lost
+	if err := ensurePlanContextBlobRefs(orgId, planId); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, hash := range hashes {
+		if hash != "" {
+			sb.WriteString(hash + "\n")
+		}
+	}
+
+	f, err := os.OpenFile(getPlanContextBlobRefsPath(orgId, planId), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening context blob refs: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(sb.String()); err != nil {
+		return fmt.Errorf("error writing context blob refs: %v", err)
+	}
+
+	return nil
+}
+
+// addIndexedContextBlobRefs adds the hashes in the plan's index to referenced
+func addIndexedContextBlobRefs(orgId, planId string, referenced map[string]bool) error {
+	contextBlobRefsMu.Lock()
+	err := ensurePlanContextBlobRefs(orgId, planId)
+	contextBlobRefsMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(getPlanContextBlobRefsPath(orgId, planId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// the plan was deleted
+			return nil
+		}
+		return fmt.Errorf("error reading context blob refs: %v", err)
+	}
+
+	for _, hash := range strings.Split(string(data), "\n") {
+		if contextBlobHashRegex.MatchString(hash) {
+			referenced[hash] = true
+		}
+	}
+
+	return nil
+}
+
+// RebuildPlanContextBlobRefs re-indexes the plan's references from its working tree and history, dropping ones that are no longer reachable. It only reads the one plan's history.
+func RebuildPlanContextBlobRefs(orgId, planId string) error {
+	contextBlobRefsMu.Lock()
+	defer contextBlobRefsMu.Unlock()
+
+	return rebuildPlanContextBlobRefs(orgId, planId)
+}
+
+// ensurePlanContextBlobRefs builds the plan's index if it doesn't have one yet. contextBlobRefsMu must be held.
+func ensurePlanContextBlobRefs(orgId, planId string) error {
+	_, err := os.Stat(getPlanContextBlobRefsPath(orgId, planId))
+	if err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error checking context blob refs: %v", err)
+	}
+
+	if _, err := os.Stat(getPlanDir(orgId, planId)); os.IsNotExist(err) {
+		return nil
+	}
+
+	return rebuildPlanContextBlobRefs(orgId, planId)
+}
+
+// contextBlobRefsMu must be held
+func rebuildPlanContextBlobRefs(orgId, planId string) error {
+	referenced := map[string]bool{}
+	if err := scanPlanContextBlobRefs(getPlanDir(orgId, planId), referenced); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for hash := range referenced {
+		sb.WriteString(hash + "\n")
+	}
+
+	path := getPlanContextBlobRefsPath(orgId, planId)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating context blob refs dir: %v", err)
+	}
+
+	tmpPath := path + "." + uuid.New().String() + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("error writing context blob refs: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("error renaming context blob refs: %v", err)
+	}
+
+	return nil
+}
+
+func removePlanContextBlobRefs(orgId, planId string) error {
+	contextBlobRefsMu.Lock()
+	defer contextBlobRefsMu.Unlock()
+
+	err := os.Remove(getPlanContextBlobRefsPath(orgId, planId))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing context blob refs: %v", err)
+	}
+
+	return nil
+}
+
+// removeDeletedPlanContextBlobRefs removes the plan's index if the plan is gone. The plan dir is checked again under the lock in case the plan was created since its dir was listed.
+func removeDeletedPlanContextBlobRefs(orgId, planId string) error {
+	contextBlobRefsMu.Lock()
+	defer contextBlobRefsMu.Unlock()
+
+	if _, err := os.Stat(getPlanDir(orgId, planId)); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error checking plan dir: %v", err)
+	}
+
+	err := os.Remove(getPlanContextBlobRefsPath(orgId, planId))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing context blob refs for deleted plan: %v", err)
+	}
+
+	return nil
+}
+
+// scanPlanContextBlobRefs adds the blobs referenced by the plan's working tree and by every commit reachable from its branches. Rewinding only moves a branch back, so this covers everything a plan can be rewound to.
+func scanPlanContextBlobRefs(planDir string, referenced map[string]bool) error {
+	contextDir := filepath.Join(planDir, "context")
+	files, err := os.ReadDir(contextDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading context dir: %v", err)
+	}
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".meta") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(contextDir, file.Name()))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("error reading context meta file: %v", err)
+		}
+		for _, match := range contextBlobRefRegex.FindAllSubmatch(data, -1) {
+			referenced[string(match[1])] = true
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(planDir, ".git")); os.IsNotExist(err) {
+		return nil
+	}
+
+	// every reference in a reachable commit was added by some reachable commit, so the added lines of each commit's diff cover them all
+	var out bytes.Buffer
+	cmd := exec.Command("git", "-C", planDir, "log", "--all", "-p", "--format=", "--", "context")
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error getting context history for dir: %s, err: %v", planDir, err)
+	}
+
+	for _, match := range contextBlobRefRegex.FindAllSubmatch(out.Bytes(), -1) {
+		referenced[string(match[1])] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func storeTestContext(t *testing.T, orgId, planId, body string) *Context {
+	t.Helper()
+
+	context := &Context{
+		OrgId:       orgId,
+		PlanId:      planId,
+		ContextType: shared.ContextFileType,
+		Name:        "main.go",
+		FilePath:    "main.go",
+		Body:        body,
+	}
+	if err := StoreContext(context); err != nil {
+		t.Fatal(err)
+	}
+
+	return context
+}
+
+// ageContextBlobs moves every blob past the grace period
+func ageContextBlobs(t *testing.T, orgId string) {
+	t.Helper()
+
+	old := time.Now().Add(-2 * contextBlobGCGracePeriod)
+	err := filepath.Walk(getOrgContextBlobsDir(orgId), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return os.Chtimes(path, old, old)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func contextBlobExists(orgId, body string) bool {
+	_, err := os.Stat(getContextBlobPath(orgId, shared.GetContextBodyHash(body)))
+	return err == nil
+}
+
+func TestGCContextBlobs(t *testing.T) {
+	useTempBaseDir(t)
+
+	dir := initTestPlan(t, "org", "plan")
+	initialSha, _, err := getLatestCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := storeTestContext(t, "org", "plan", "package main // loaded")
+	if err := GitAddAndCommit("org", "plan", "main", "load"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := StoreContextBlob("org", "unreferenced"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StoreContextBlob("org", "recent"); err != nil {
+		t.Fatal(err)
+	}
+
+	ageContextBlobs(t, "org")
+
+	// touched by a load in progress
+	if _, err := StoreContextBlob("org", "recent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !contextBlobExists("org", "package main // loaded") {
+		t.Errorf("a blob in the current context was removed")
+	}
+	if contextBlobExists("org", "unreferenced") {
+		t.Errorf("an unreferenced blob wasn't removed")
+	}
+	if !contextBlobExists("org", "recent") {
+		t.Errorf("a blob within the grace period was removed")
+	}
+
+	// removed context can still be rewound to
+	if err := os.Remove(filepath.Join(getPlanContextDir("org", "plan"), context.Id+".meta")); err != nil {
+		t.Fatal(err)
+	}
+	if err := GitAddAndCommit("org", "plan", "main", "remove"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+	if !contextBlobExists("org", "package main // loaded") {
+		t.Errorf("a blob referenced by plan history was removed")
+	}
+
+	// once the plan is rewound past the load and re-indexed, nothing references it
+	if err := gitRewindToSha(dir, initialSha); err != nil {
+		t.Fatal(err)
+	}
+	if err := RebuildPlanContextBlobRefs("org", "plan"); err != nil {
+		t.Fatal(err)
+	}
+
+	ageContextBlobs(t, "org")
+
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+	if contextBlobExists("org", "package main // loaded") {
+		t.Errorf("a blob that's no longer referenced wasn't removed")
+	}
+}
+
+func TestGCContextBlobsKeepsOtherPlansBlobs(t *testing.T) {
+	useTempBaseDir(t)
+
+	initTestPlan(t, "org", "plan1")
+	initTestPlan(t, "org", "plan2")
+
+	storeTestContext(t, "org", "plan1", "shared body")
+	storeTestContext(t, "org", "plan2", "shared body")
+	if err := GitAddAndCommit("org", "plan1", "main", "load"); err != nil {
+		t.Fatal(err)
+	}
+	if err := GitAddAndCommit("org", "plan2", "main", "load"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.RemoveAll(getPlanDir("org", "plan1")); err != nil {
+		t.Fatal(err)
+	}
+
+	ageContextBlobs(t, "org")
+
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+	if !contextBlobExists("org", "shared body") {
+		t.Errorf("a blob still referenced by another plan was removed")
+	}
+
+	if err := os.RemoveAll(getPlanDir("org", "plan2")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+	if contextBlobExists("org", "shared body") {
+		t.Errorf("a blob wasn't removed after every plan referencing it was deleted")
+	}
+}
+
+func TestHashOnlyReferencesRequireAccessiblePlan(t *testing.T) {
+	useTempBaseDir(t)
+
+	initTestPlan(t, "org", "plan1")
+	initTestPlan(t, "org", "plan2")
+	initTestPlan(t, "org", "fresh")
+
+	storeTestContext(t, "org", "plan1", "secret")
+	storeTestContext(t, "org", "plan2", "public")
+
+	secretHash := shared.GetContextBodyHash("secret")
+	publicHash := shared.GetContextBodyHash("public")
+
+	// the blob is stored for the org, but no plan the user can access references it, so it can't be probed for
+	missing, err := GetMissingContextBlobs("org", []string{"fresh", "plan2"}, []string{secretHash, publicHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(missing, []string{secretHash}) {
+		t.Errorf("missing = %v, want only the hash that no accessible plan references", missing)
+	}
+
+	req := shared.LoadContextRequest{{BodyHash: secretHash}}
+	if err := resolveLoadContextBlobs("org", []string{"fresh", "plan2"}, &req); err == nil {
+		t.Errorf("a hash-only reference to a blob outside the accessible plans was accepted")
+	}
+
+	// a fresh plan can reference what another accessible plan already loaded without uploading it
+	req = shared.LoadContextRequest{{BodyHash: publicHash}}
+	if err := resolveLoadContextBlobs("org", []string{"fresh", "plan2"}, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req[0].Body != "public" {
+		t.Errorf("body = %q, want it filled in from the blob", req[0].Body)
+	}
+
+	// an uploaded body is always accepted if it matches its hash
+	req = shared.LoadContextRequest{{Body: "secret", BodyHash: secretHash}}
+	if err := resolveLoadContextBlobs("org", []string{"fresh"}, &req); err != nil {
+		t.Errorf("an uploaded body was rejected: %v", err)
+	}
+
+	req = shared.LoadContextRequest{{Body: "tampered", BodyHash: secretHash}}
+	if err := resolveLoadContextBlobs("org", []string{"fresh"}, &req); err == nil {
+		t.Errorf("a body that doesn't match its hash was accepted")
+	}
+}
+
+func TestContextBlobRefsIndexesUnindexedPlans(t *testing.T) {
+	useTempBaseDir(t)
+
+	initTestPlan(t, "org", "plan")
+	storeTestContext(t, "org", "plan", "package main // loaded")
+	if err := GitAddAndCommit("org", "plan", "main", "load"); err != nil {
+		t.Fatal(err)
+	}
+
+	// like a plan from before indexes existed
+	if err := os.Remove(getPlanContextBlobRefsPath("org", "plan")); err != nil {
+		t.Fatal(err)
+	}
+
+	storeTestContext(t, "org", "plan", "package main // later")
+
+	known, err := getKnownContextBlobHashes("org", []string{"plan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, body := range []string{"package main // loaded", "package main // later"} {
+		if !known[shared.GetContextBodyHash(body)] {
+			t.Errorf("%q isn't indexed", body)
+		}
+	}
+
+	// collection removes indexes of plans that are gone
+	if err := os.RemoveAll(getPlanDir("org", "plan")); err != nil {
+		t.Fatal(err)
+	}
+	if err := GCContextBlobs("org"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(getPlanContextBlobRefsPath("org", "plan")); !os.IsNotExist(err) {
+		t.Errorf("the deleted plan's index wasn't removed")
+	}
+}
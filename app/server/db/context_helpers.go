@@ -2,6 +2,7 @@ package db
 
 import (
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -30,8 +31,16 @@ func GetPlanContexts(orgId, planId string, includeBody bool) ([]*Context, error)
 		return nil, fmt.Errorf("error reading context dir: %v", err)
 	}
 
-	errCh := make(chan error, len(files)/2)
-	contextCh := make(chan *Context, len(files)/2)
+	// partial file contexts also have a .file, so count the .meta files rather than halving
+	numContexts := 0
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".meta") {
+			numContexts++
+		}
+	}
+
+	errCh := make(chan error, numContexts)
+	contextCh := make(chan *Context, numContexts)
 
 	// read each context file
 	for _, file := range files {
@@ -49,7 +58,7 @@ func GetPlanContexts(orgId, planId string, includeBody bool) ([]*Context, error)
 		}
 	}
 
-	for i := 0; i < len(files)/2; i++ {
+	for i := 0; i < numContexts; i++ {
 		select {
 		case err := <-errCh:
 			return nil, fmt.Errorf("error reading context files: %v", err)
@@ -84,6 +93,31 @@ func GetContext(orgId, planId, contextId string, includeBody bool) (*Context, er
 	}
 
 	if includeBody {
+		if context.BodyHash != "" {
+			body, err := GetContextBlob(orgId, context.BodyHash)
+			if err != nil {
+				return nil, fmt.Errorf("error reading context body: %v", err)
+			}
+
+			if context.ContextType == shared.ContextImageType {
+				// image blobs are stored base64-encoded, as they're sent
+				context.Body = body
+			} else {
+				context.Body = escapeContextBody(body)
+			}
+
+			if context.IsPartialFile() {
+				fileBody, err := GetContextBlob(orgId, context.FileBodyHash)
+				if err != nil {
+					return nil, fmt.Errorf("error reading context file body: %v", err)
+				}
+
+				context.FileBody = escapeContextBody(fileBody)
+			}
+
+			return &context, nil
+		}
+
 		// read the body file
 		bodyPath := filepath.Join(contextDir, strings.TrimSuffix(contextId, ".meta")+".body")
 		bodyBytes, err := os.ReadFile(bodyPath)
@@ -92,7 +126,22 @@ func GetContext(orgId, planId, contextId string, includeBody bool) (*Context, er
 			return nil, fmt.Errorf("error reading context body file: %v", err)
 		}
 
-		context.Body = string(bodyBytes)
+		if context.ContextType == shared.ContextImageType {
+			// images are stored as raw bytes and passed around base64-encoded
+			context.Body = base64.StdEncoding.EncodeToString(bodyBytes)
+		} else {
+			context.Body = string(bodyBytes)
+		}
+
+		if context.IsPartialFile() {
+			fileBytes, err := os.ReadFile(filepath.Join(contextDir, contextId+".file"))
+
+			if err != nil {
+				return nil, fmt.Errorf("error reading context file body: %v", err)
+			}
+
+			context.FileBody = string(fileBytes)
+		}
 	}
 
 	return &context, nil
@@ -100,17 +149,27 @@ func GetContext(orgId, planId, contextId string, includeBody bool) (*Context, er
 
 func ContextRemove(orgId, planId string, contexts []*Context) error {
 	// remove files
-	numFiles := len(contexts) * 2
+	numFiles := 0
 
 	filesToUpdate := make(map[string]string)
 
-	errCh := make(chan error, numFiles)
+	errCh := make(chan error, len(contexts)*3)
 	for _, context := range contexts {
 		filesToUpdate[context.FilePath] = ""
 		contextDir := getPlanContextDir(orgId, planId)
-		for _, ext := range []string{".meta", ".body"} {
+		exts := []string{".meta", ".body"}
+		if context.IsPartialFile() {
+			exts = append(exts, ".file")
+		}
+		for _, ext := range exts {
+			numFiles++
 			go func(context *Context, dir, ext string) {
-				errCh <- os.Remove(filepath.Join(dir, context.Id+ext))
+				err := os.Remove(filepath.Join(dir, context.Id+ext))
+				// contexts stored in the blob store only have a .meta file
+				if os.IsNotExist(err) && ext != ".meta" {
+					err = nil
+				}
+				errCh <- err
 			}(context, contextDir, ext)
 		}
 	}
@@ -149,13 +208,40 @@ func StoreContext(context *Context) error {
 	metaPath := filepath.Join(contextDir, metaFilename)
 
 	originalBody := context.Body
-	originalBody = strings.ReplaceAll(originalBody, "\\`\\`\\`", "\\\\`\\\\`\\\\`")
-	originalBody = strings.ReplaceAll(originalBody, "```", "\\`\\`\\`")
+	originalFileBody := context.FileBody
+
+	if context.ContextType == shared.ContextImageType {
+		if _, err = base64.StdEncoding.DecodeString(originalBody); err != nil {
+			return fmt.Errorf("failed to decode image body: %v", err)
+		}
+	}
+
+	context.BodyHash, err = StoreContextBlob(context.OrgId, originalBody)
+	if err != nil {
+		return fmt.Errorf("failed to store context body: %v", err)
+	}
+
+	if context.ContextType != shared.ContextImageType {
+		originalBody = escapeContextBody(originalBody)
+	}
+
+	context.FileBodyHash = ""
+	if context.IsPartialFile() {
+		// symbol, line range, and outline contexts keep the full file alongside so it can be used as a build target
+		context.FileBodyHash, err = StoreContextBlob(context.OrgId, originalFileBody)
+		if err != nil {
+			return fmt.Errorf("failed to store context file body: %v", err)
+		}
+		originalFileBody = escapeContextBody(originalFileBody)
+	}
+
+	err = addPlanContextBlobRefs(context.OrgId, context.PlanId, context.BodyHash, context.FileBodyHash)
+	if err != nil {
+		return fmt.Errorf("failed to index context blobs: %v", err)
+	}
 
-	bodyFilename := context.Id + ".body"
-	bodyPath := filepath.Join(contextDir, bodyFilename)
-	body := []byte(originalBody)
 	context.Body = ""
+	context.FileBody = ""
 
 	// Convert the ModelContextPart to JSON
 	data, err := json.MarshalIndent(context, "", "  ")
@@ -163,21 +249,114 @@ func StoreContext(context *Context) error {
 		return fmt.Errorf("failed to marshal context context: %v", err)
 	}
 
-	// Write the body to the file
-	if err = os.WriteFile(bodyPath, body, 0644); err != nil {
-		return fmt.Errorf("failed to write context body to file %s: %v", bodyPath, err)
-	}
-
 	// Write the meta data to the file
 	if err = os.WriteFile(metaPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write context meta to file %s: %v", metaPath, err)
 	}
 
+	// bodies stored before the blob store are replaced by the blob reference
+	for _, ext := range []string{".body", ".file"} {
+		if err = os.Remove(filepath.Join(contextDir, context.Id+ext)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove context body file: %v", err)
+		}
+	}
+
 	context.Body = originalBody
+	context.FileBody = originalFileBody
+
+	return nil
+}
+
+// StoreContextMeta rewrites only a context's meta file, for changes like priority that don't touch its body
+func StoreContextMeta(context *Context) error {
+	meta := *context
+	meta.Body = ""
+	meta.FileBody = ""
+	meta.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal context meta: %v", err)
+	}
+
+	metaPath := filepath.Join(getPlanContextDir(context.OrgId, context.PlanId), context.Id+".meta")
+	if err = os.WriteFile(metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write context meta to file %s: %v", metaPath, err)
+	}
+
+	context.UpdatedAt = meta.UpdatedAt
 
 	return nil
 }
 
+// StoreContextBudgetReport records how context was fit into the planner's budget for the latest reply. It's stored in the plan repo, so it's committed with the reply and follows branches and rewinds.
+func StoreContextBudgetReport(orgId, planId string, report *shared.ContextBudgetReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal context budget report: %v", err)
+	}
+
+	path := filepath.Join(getPlanDir(orgId, planId), "context_budget.json")
+	if err = os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write context budget report to file %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// GetContextBudgetReport returns nil if there hasn't been a reply yet
+func GetContextBudgetReport(orgId, planId string) (*shared.ContextBudgetReport, error) {
+	data, err := os.ReadFile(filepath.Join(getPlanDir(orgId, planId), "context_budget.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading context budget report: %v", err)
+	}
+
+	var report shared.ContextBudgetReport
+	err = json.Unmarshal(data, &report)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling context budget report: %v", err)
+	}
+
+	return &report, nil
+}
+
+func escapeContextBody(body string) string {
+	body = strings.ReplaceAll(body, "\\`\\`\\`", "\\\\`\\\\`\\\\`")
+	return strings.ReplaceAll(body, "```", "\\`\\`\\`")
+}
+
+// UnescapeContextBody reverses escapeContextBody for bodies read back from storage
+func UnescapeContextBody(body string) string {
+	body = strings.ReplaceAll(body, "\\`\\`\\`", "```")
+	return strings.ReplaceAll(body, "\\\\`\\\\`\\\\`", "\\`\\`\\`")
+}
+
+// GetPinnedContextTokens sums the tokens of the plan's pinned context, taking the token counts in updatedTokensById over the stored ones
+func GetPinnedContextTokens(orgId, planId string, updatedTokensById map[string]int) (int, error) {
+	contexts, err := GetPlanContexts(orgId, planId, false)
+	if err != nil {
+		return 0, err
+	}
+
+	pinnedTokens := 0
+	for _, context := range contexts {
+		if !context.Pinned {
+			continue
+		}
+
+		if numTokens, ok := updatedTokensById[context.Id]; ok {
+			pinnedTokens += numTokens
+		} else {
+			pinnedTokens += context.NumTokens
+		}
+	}
+
+	return pinnedTokens, nil
+}
+
 type LoadContextsParams struct {
 	Req                      *shared.LoadContextRequest
 	OrgId                    string
@@ -195,15 +374,27 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 	branchName := params.BranchName
 	userId := params.UserId
 
+	blobPlanIds, err := GetContextBlobPlanIds(orgId, userId, planId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting accessible plans: %v", err)
+	}
+
+	err = resolveLoadContextBlobs(orgId, blobPlanIds, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolving context bodies: %v", err)
+	}
+
 	filesToLoad := map[string]string{}
 	for _, context := range *req {
-		if context.ContextType == shared.ContextFileType {
+		if context.FileBody != "" {
+			filesToLoad[context.FilePath] = context.FileBody
+		} else if context.ContextType == shared.ContextFileType {
 			filesToLoad[context.FilePath] = context.Body
 		}
 	}
 
 	if !params.SkipConflictInvalidation {
-		err := invalidateConflictedResults(orgId, planId, filesToLoad)
+		err = invalidateConflictedResults(orgId, planId, filesToLoad)
 		if err != nil {
 			return nil, nil, fmt.Errorf("error invalidating conflicted results: %v", err)
 		}
@@ -227,9 +418,14 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 
 	maxTokens := settings.GetPlannerEffectiveMaxTokens()
 
+	pinnedTokens, err := GetPinnedContextTokens(orgId, planId, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting pinned context tokens: %v", err)
+	}
+
 	for _, context := range *req {
 		tempId := uuid.New().String()
-		numTokens, err := shared.GetNumTokens(context.Body)
+		numTokens, err := getContextNumTokens(context.ContextType, context.Body)
 
 		if err != nil {
 			return nil, nil, fmt.Errorf("error getting num tokens: %v", err)
@@ -240,13 +436,19 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 
 		tokensAdded += numTokens
 		totalTokens += numTokens
+
+		if context.Pinned {
+			pinnedTokens += numTokens
+		}
 	}
 
-	if totalTokens > maxTokens {
+	// unpinned context can go over the limit since it's trimmed to fit when prompting, but pinned context never is
+	if pinnedTokens > maxTokens {
 		return &shared.LoadContextResponse{
 			TokensAdded:       tokensAdded,
 			TotalTokens:       totalTokens,
 			MaxTokens:         maxTokens,
+			PinnedTokens:      pinnedTokens,
 			MaxTokensExceeded: true,
 		}, nil, nil
 	}
@@ -256,8 +458,11 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 	for tempId, params := range paramsByTempId {
 
 		go func(tempId string, params *shared.LoadContextParams) {
-			hash := sha256.Sum256([]byte(params.Body))
-			sha := hex.EncodeToString(hash[:])
+			sha, err := getContextSha(params.ContextType, params.Body, params.FileBody)
+			if err != nil {
+				errCh <- err
+				return
+			}
 
 			context := Context{
 				// Id generated by db layer
@@ -268,13 +473,24 @@ func LoadContexts(params LoadContextsParams) (*shared.LoadContextResponse, []*Co
 				Name:            params.Name,
 				Url:             params.Url,
 				FilePath:        params.FilePath,
+				Symbol:          params.Symbol,
+				StartLine:       params.StartLine,
+				EndLine:         params.EndLine,
+				Outline:         params.Outline,
+				MapTokens:       params.MapTokens,
+				GitRef:          params.GitRef,
+				Command:         params.Command,
+				Priority:        params.Priority,
+				Pinned:          params.Pinned,
 				NumTokens:       numTokensByTempId[tempId],
 				Sha:             sha,
 				Body:            params.Body,
+				FileBody:        params.FileBody,
 				ForceSkipIgnore: params.ForceSkipIgnore,
+				Redacted:        params.Redacted,
 			}
 
-			err := StoreContext(&context)
+			err = StoreContext(&context)
 
 			if err != nil {
 				errCh <- err
@@ -324,6 +540,7 @@ type UpdateContextsParams struct {
 	OrgId                    string
 	Plan                     *Plan
 	BranchName               string
+	UserId                   string
 	ContextsById             map[string]*Context
 	SkipConflictInvalidation bool
 }
@@ -335,6 +552,16 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 	planId := plan.Id
 	branchName := params.BranchName
 
+	blobPlanIds, err := GetContextBlobPlanIds(orgId, params.UserId, planId)
+	if err != nil {
+		return nil, fmt.Errorf("error getting accessible plans: %v", err)
+	}
+
+	err = resolveUpdateContextBlobs(orgId, blobPlanIds, req)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving context bodies: %v", err)
+	}
+
 	branch, err := GetDbBranch(planId, branchName)
 	if err != nil {
 		return nil, fmt.Errorf("error getting branch: %v", err)
@@ -367,6 +594,8 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 	numFiles := 0
 	numUrls := 0
 	numTrees := 0
+	numGit := 0
+	numCommands := 0
 
 	var mu sync.Mutex
 	errCh := make(chan error)
@@ -392,7 +621,7 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 
 			contextsById[id] = context
 			updatedContexts = append(updatedContexts, context.ToApi())
-			updateNumTokens, err := shared.GetNumTokens(params.Body)
+			updateNumTokens, err := getContextNumTokens(context.ContextType, params.Body)
 
 			if err != nil {
 				errCh <- fmt.Errorf("error getting num tokens: %v", err)
@@ -407,12 +636,16 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 			context.NumTokens = updateNumTokens
 
 			switch context.ContextType {
-			case shared.ContextFileType:
+			case shared.ContextFileType, shared.ContextImageType, shared.ContextSymbolType:
 				numFiles++
 			case shared.ContextURLType:
 				numUrls++
-			case shared.ContextDirectoryTreeType:
+			case shared.ContextDirectoryTreeType, shared.ContextRepoMapType:
 				numTrees++
+			case shared.ContextGitDiffType, shared.ContextGitCommitType, shared.ContextGitChangesType:
+				numGit++
+			case shared.ContextExecType:
+				numCommands++
 			}
 
 			errCh <- nil
@@ -434,21 +667,36 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 		NumFiles:        numFiles,
 		NumUrls:         numUrls,
 		NumTrees:        numTrees,
+		NumGit:          numGit,
+		NumCommands:     numCommands,
 		MaxTokens:       maxTokens,
 	}
 
-	if totalTokens > maxTokens {
+	updatedTokensById := make(map[string]int, len(*req))
+	for id := range *req {
+		updatedTokensById[id] = contextsById[id].NumTokens
+	}
+
+	pinnedTokens, err := GetPinnedContextTokens(orgId, planId, updatedTokensById)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pinned context tokens: %v", err)
+	}
+
+	if pinnedTokens > maxTokens {
 		return &shared.UpdateContextResponse{
 			TokensAdded:       tokensDiff,
 			TotalTokens:       totalTokens,
 			MaxTokens:         maxTokens,
+			PinnedTokens:      pinnedTokens,
 			MaxTokensExceeded: true,
 		}, nil
 	}
 
 	filesToLoad := map[string]string{}
 	for _, context := range updatedContexts {
-		if context.ContextType == shared.ContextFileType {
+		if context.IsPartialFile() {
+			filesToLoad[context.FilePath] = (*req)[context.Id].FileBody
+		} else if context.ContextType == shared.ContextFileType {
 			filesToLoad[context.FilePath] = (*req)[context.Id].Body
 		}
 	}
@@ -467,13 +715,17 @@ func UpdateContexts(params UpdateContextsParams) (*shared.UpdateContextResponse,
 
 			context := contextsById[id]
 
-			hash := sha256.Sum256([]byte(params.Body))
-			sha := hex.EncodeToString(hash[:])
+			sha, err := getContextSha(context.ContextType, params.Body, params.FileBody)
+			if err != nil {
+				errCh <- fmt.Errorf("error getting context sha: %v", err)
+				return
+			}
 
 			context.Body = params.Body
+			context.FileBody = params.FileBody
 			context.Sha = sha
 
-			err := StoreContext(context)
+			err = StoreContext(context)
 
 			if err != nil {
 				errCh <- fmt.Errorf("error storing context: %v", err)
@@ -581,3 +833,64 @@ func invalidateConflictedResults(orgId, planId string, filesToUpdate map[string]
 
 	return nil
 }
+
+func getContextNumTokens(contextType shared.ContextType, body string) (int, error) {
+	if contextType == shared.ContextImageType {
+		return shared.GetBase64ImageNumTokens(body)
+	}
+
+	return shared.GetNumTokens(body)
+}
+
+// for images, the sha is of the decoded bytes so it matches the sha of the file on the client
+// for symbols, the sha is of the full file so that any change to the file marks the context outdated
+func getContextSha(contextType shared.ContextType, body, fileBody string) (string, error) {
+	bytes := []byte(body)
+
+	if contextType == shared.ContextSymbolType {
+		bytes = []byte(fileBody)
+	}
+
+	if contextType == shared.ContextImageType {
+		var err error
+		bytes, err = base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return "", fmt.Errorf("error decoding image body: %v", err)
+		}
+	}
+
+	hash := sha256.Sum256(bytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// GetContextsByPath maps file paths to the context holding each file's full content. Images are skipped, and a symbol, line range, or outline context stands in for its whole file unless the whole file is also loaded.
+func GetContextsByPath(contexts []*Context) map[string]*Context {
+	res := map[string]*Context{}
+
+	for _, context := range contexts {
+		if context.FilePath == "" || context.ContextType == shared.ContextImageType {
+			continue
+		}
+
+		if context.IsPartialFile() {
+			if existing, ok := res[context.FilePath]; ok {
+				if existing.IsPartialFile() {
+					// more than one part of the file is loaded, so builds use the whole file
+					existing.StartLine = 0
+					existing.EndLine = 0
+					existing.Outline = existing.Outline && context.Outline
+				}
+				continue
+			}
+
+			fileContext := *context
+			fileContext.Body = context.FileBody
+			res[context.FilePath] = &fileContext
+			continue
+		}
+
+		res[context.FilePath] = context
+	}
+
+	return res
+}
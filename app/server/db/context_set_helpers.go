@@ -0,0 +1,192 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func GetContextSetByName(orgId, projectId, name string) (*ContextSet, error) {
+	var set ContextSet
+	err := Conn.Get(&set, "SELECT * FROM context_sets WHERE org_id = $1 AND project_id = $2 AND name = $3", orgId, projectId, name)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting context set: %v", err)
+	}
+
+	return &set, nil
+}
+
+func ListContextSets(orgId, projectId string) ([]*ContextSet, error) {
+	var sets []*ContextSet
+	err := Conn.Select(&sets, "SELECT * FROM context_sets WHERE org_id = $1 AND project_id = $2 ORDER BY name", orgId, projectId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing context sets: %v", err)
+	}
+
+	return sets, nil
+}
+
+// GetContextSetRefs returns the refs in the latest version of a context set, along with the id of the user who saved that version
+func GetContextSetRefs(set *ContextSet) ([]*shared.ContextSetRef, string, error) {
+	var version ContextSetVersion
+	err := Conn.Get(&version, "SELECT * FROM context_set_versions WHERE context_set_id = $1 AND version = $2", set.Id, set.Version)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting context set version: %v", err)
+	}
+
+	var refs []*shared.ContextSetRef
+	err = json.Unmarshal([]byte(version.Refs), &refs)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("error unmarshalling context set refs: %v", err)
+	}
+
+	return refs, version.OwnerId, nil
+}
+
+func ListContextSetVersions(setId string) ([]*shared.ContextSetVersion, error) {
+	var versions []*ContextSetVersion
+	err := Conn.Select(&versions, "SELECT * FROM context_set_versions WHERE context_set_id = $1 ORDER BY version DESC", setId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing context set versions: %v", err)
+	}
+
+	var res []*shared.ContextSetVersion
+	for _, version := range versions {
+		var refs []*shared.ContextSetRef
+		err = json.Unmarshal([]byte(version.Refs), &refs)
+
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling context set refs: %v", err)
+		}
+
+		res = append(res, &shared.ContextSetVersion{
+			Version:   version.Version,
+			OwnerId:   version.OwnerId,
+			NumRefs:   len(refs),
+			Summary:   version.Summary,
+			CreatedAt: version.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+type SaveContextSetParams struct {
+	OrgId     string
+	ProjectId string
+	OwnerId   string
+	Name      string
+	Refs      []*shared.ContextSetRef
+}
+
+// SaveContextSet creates a context set or adds a new version to an existing one. If the refs are the same as the latest version's, no version is added and changed is false.
+func SaveContextSet(params SaveContextSetParams) (set *ContextSet, summary string, changed bool, err error) {
+	tx, err := Conn.Beginx()
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error starting transaction: %v", err)
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil || !changed {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			}
+		}
+	}()
+
+	_, err = tx.Exec(
+		"INSERT INTO context_sets (org_id, project_id, owner_id, name) VALUES ($1, $2, $3, $4) ON CONFLICT (project_id, name) DO NOTHING",
+		params.OrgId, params.ProjectId, params.OwnerId, params.Name,
+	)
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error creating context set: %v", err)
+	}
+
+	// lock the set so concurrent saves get sequential versions
+	set = &ContextSet{}
+	err = tx.Get(set, "SELECT * FROM context_sets WHERE org_id = $1 AND project_id = $2 AND name = $3 FOR UPDATE", params.OrgId, params.ProjectId, params.Name)
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error getting context set: %v", err)
+	}
+
+	var prevRefs []*shared.ContextSetRef
+	if set.Version > 0 {
+		var prevJson string
+		err = tx.Get(&prevJson, "SELECT refs FROM context_set_versions WHERE context_set_id = $1 AND version = $2", set.Id, set.Version)
+
+		if err != nil {
+			return nil, "", false, fmt.Errorf("error getting context set version: %v", err)
+		}
+
+		err = json.Unmarshal([]byte(prevJson), &prevRefs)
+
+		if err != nil {
+			return nil, "", false, fmt.Errorf("error unmarshalling context set refs: %v", err)
+		}
+
+		if prevRefs == nil {
+			prevRefs = []*shared.ContextSetRef{}
+		}
+	}
+
+	summary, changed = shared.SummaryForContextSetChanges(prevRefs, params.Refs)
+
+	if !changed {
+		return set, "", false, nil
+	}
+
+	refsJson, err := json.Marshal(params.Refs)
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error marshalling context set refs: %v", err)
+	}
+
+	set.Version++
+
+	_, err = tx.Exec(
+		"INSERT INTO context_set_versions (context_set_id, owner_id, version, refs, summary) VALUES ($1, $2, $3, $4, $5)",
+		set.Id, params.OwnerId, set.Version, string(refsJson), summary,
+	)
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error creating context set version: %v", err)
+	}
+
+	err = tx.QueryRow("UPDATE context_sets SET version = $1 WHERE id = $2 RETURNING updated_at", set.Version, set.Id).Scan(&set.UpdatedAt)
+
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error updating context set: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error committing transaction: %v", err)
+	}
+
+	return set, summary, true, nil
+}
+
+func DeleteContextSet(orgId, id string) error {
+	_, err := Conn.Exec("DELETE FROM context_sets WHERE org_id = $1 AND id = $2", orgId, id)
+
+	if err != nil {
+		return fmt.Errorf("error deleting context set: %v", err)
+	}
+
+	return nil
+}
@@ -60,6 +60,63 @@ func (user *User) ToApi() *shared.User {
 	}
 }
 
+type PromptTemplate struct {
+	Id          string    `db:"id"`
+	OrgId       string    `db:"org_id"`
+	OwnerId     string    `db:"owner_id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	Body        string    `db:"body"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+func (template *PromptTemplate) ToApi() *shared.PromptTemplate {
+	return &shared.PromptTemplate{
+		Id:          template.Id,
+		OrgId:       template.OrgId,
+		OwnerId:     template.OwnerId,
+		Name:        template.Name,
+		Description: template.Description,
+		Body:        template.Body,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+type ContextSet struct {
+	Id        string    `db:"id"`
+	OrgId     string    `db:"org_id"`
+	ProjectId string    `db:"project_id"`
+	OwnerId   string    `db:"owner_id"`
+	Name      string    `db:"name"`
+	Version   int       `db:"version"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (set *ContextSet) ToApi() *shared.ContextSet {
+	return &shared.ContextSet{
+		Id:        set.Id,
+		ProjectId: set.ProjectId,
+		OwnerId:   set.OwnerId,
+		Name:      set.Name,
+		Version:   set.Version,
+		CreatedAt: set.CreatedAt,
+		UpdatedAt: set.UpdatedAt,
+	}
+}
+
+type ContextSetVersion struct {
+	Id           string    `db:"id"`
+	ContextSetId string    `db:"context_set_id"`
+	OwnerId      string    `db:"owner_id"`
+	Version      int       `db:"version"`
+	Refs         string    `db:"refs"`
+	Summary      string    `db:"summary"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
 type Invite struct {
 	Id         string     `db:"id"`
 	OrgId      string     `db:"org_id"`
@@ -293,20 +350,38 @@ type repoLock struct {
 // This allows us to store them in a git repo and use git to manage history.
 
 type Context struct {
-	Id              string             `json:"id"`
-	OrgId           string             `json:"orgId"`
-	OwnerId         string             `json:"ownerId"`
-	PlanId          string             `json:"planId"`
-	ContextType     shared.ContextType `json:"contextType"`
-	Name            string             `json:"name"`
-	Url             string             `json:"url"`
-	FilePath        string             `json:"filePath"`
-	Sha             string             `json:"sha"`
-	NumTokens       int                `json:"numTokens"`
-	Body            string             `json:"body,omitempty"`
-	ForceSkipIgnore bool               `json:"forceSkipIgnore"`
-	CreatedAt       time.Time          `json:"createdAt"`
-	UpdatedAt       time.Time          `json:"updatedAt"`
+	Id              string                 `json:"id"`
+	OrgId           string                 `json:"orgId"`
+	OwnerId         string                 `json:"ownerId"`
+	PlanId          string                 `json:"planId"`
+	ContextType     shared.ContextType     `json:"contextType"`
+	Name            string                 `json:"name"`
+	Url             string                 `json:"url"`
+	FilePath        string                 `json:"filePath"`
+	Symbol          string                 `json:"symbol,omitempty"`
+	StartLine       int                    `json:"startLine,omitempty"`
+	EndLine         int                    `json:"endLine,omitempty"`
+	Outline         bool                   `json:"outline,omitempty"`
+	MapTokens       int                    `json:"mapTokens,omitempty"`
+	GitRef          string                 `json:"gitRef,omitempty"`
+	Command         string                 `json:"command,omitempty"`
+	Priority        shared.ContextPriority `json:"priority,omitempty"`
+	Pinned          bool                   `json:"pinned,omitempty"`
+	Sha             string                 `json:"sha"`
+	NumTokens       int                    `json:"numTokens"`
+	Body            string                 `json:"body,omitempty"`
+	FileBody        string                 `json:"fileBody,omitempty"`
+	ForceSkipIgnore bool                   `json:"forceSkipIgnore"`
+	Redacted        bool                   `json:"redacted,omitempty"`
+	CreatedAt       time.Time              `json:"createdAt"`
+	UpdatedAt       time.Time              `json:"updatedAt"`
+
+	// content hashes of the body and file body in the org's context blob store. Contexts stored before the blob store have a .body file in the plan instead.
+	BodyHash     string `json:"bodyHash,omitempty"`
+	FileBodyHash string `json:"fileBodyHash,omitempty"`
+
+	// set on the copy sent to the planner in place of a context that didn't fit in the token budget
+	Outlined bool `json:"-"`
 }
 
 func (context *Context) ToApi() *shared.Context {
@@ -317,15 +392,30 @@ func (context *Context) ToApi() *shared.Context {
 		Name:            context.Name,
 		Url:             context.Url,
 		FilePath:        context.FilePath,
+		Symbol:          context.Symbol,
+		StartLine:       context.StartLine,
+		EndLine:         context.EndLine,
+		Outline:         context.Outline,
+		MapTokens:       context.MapTokens,
+		GitRef:          context.GitRef,
+		Command:         context.Command,
+		Priority:        context.Priority,
+		Pinned:          context.Pinned,
 		Sha:             context.Sha,
 		NumTokens:       context.NumTokens,
 		Body:            context.Body,
 		ForceSkipIgnore: context.ForceSkipIgnore,
+		Redacted:        context.Redacted,
 		CreatedAt:       context.CreatedAt,
 		UpdatedAt:       context.UpdatedAt,
 	}
 }
 
+// IsPartialFile is true for contexts that hold only part of a file: a symbol, a line range, or an outline. The full file is stored alongside for builds.
+func (context *Context) IsPartialFile() bool {
+	return context.ContextType == shared.ContextSymbolType || (context.ContextType == shared.ContextFileType && (context.StartLine > 0 || context.Outline))
+}
+
 type ConvoMessage struct {
 	Id        string    `json:"id"`
 	OrgId     string    `json:"orgId"`
@@ -61,6 +61,20 @@ func DeletePlanDir(orgId, planId string) error {
 		return fmt.Errorf("error deleting plan dir: %v", err)
 	}
 
+	err = os.RemoveAll(getPlanStreamLogsDir(orgId, planId))
+
+	if err != nil {
+		return fmt.Errorf("error deleting plan stream logs dir: %v", err)
+	}
+
+	err = removePlanContextBlobRefs(orgId, planId)
+
+	if err != nil {
+		return err
+	}
+
+	ScheduleContextBlobGC(orgId)
+
 	return nil
 }
 
@@ -83,3 +97,8 @@ func getPlanResultsDir(orgId, planId string) string {
 func getPlanDescriptionsDir(orgId, planId string) string {
 	return filepath.Join(getPlanDir(orgId, planId), "descriptions")
 }
+
+// stream logs are kept outside the plan dir so they aren't tracked (or cleaned) by the plan's git repo
+func getPlanStreamLogsDir(orgId, planId string) string {
+	return filepath.Join(BaseDir, "orgs", orgId, "stream_logs", planId)
+}
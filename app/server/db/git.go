@@ -91,6 +91,13 @@ func GitRewindToSha(orgId, planId, branch, sha string) error {
 		return fmt.Errorf("error rewinding git repository for dir: %s, err: %v", dir, err)
 	}
 
+	// context loaded after sha may no longer be referenced
+	err = RebuildPlanContextBlobRefs(orgId, planId)
+	if err != nil {
+		return fmt.Errorf("error re-indexing context blobs: %v", err)
+	}
+	ScheduleContextBlobGC(orgId)
+
 	return nil
 }
 
@@ -116,6 +123,42 @@ func GetLatestCommit(orgId, planId, branch string) (sha, body string, err error)
 	return sha, body, nil
 }
 
+// GetPromptParentSha returns the sha of the commit just before the user prompt with the given convo message id on branch, along with the sha of the commit that stored the prompt. The prompt commit is found by the convo message file it added rather than by its commit message.
+func GetPromptParentSha(orgId, planId, branch, convoMessageId string) (parentSha, promptSha string, err error) {
+	dir := getPlanDir(orgId, planId)
+
+	// convo message ids are generated server-side, but this keeps the pathspec from matching anything else
+	if convoMessageId == "" || strings.ContainsAny(convoMessageId, "/\\*?[") {
+		return "", "", fmt.Errorf("invalid convo message id: %s", convoMessageId)
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command("git", "log", "refs/heads/"+branch, "--diff-filter=A", "--format=%h", "--", filepath.Join("conversation", convoMessageId+".json"))
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	err = cmd.Run()
+	if err != nil {
+		return "", "", fmt.Errorf("error getting git history for dir: %s, err: %v", dir, err)
+	}
+
+	shas := strings.Fields(out.String())
+	if len(shas) == 0 {
+		return "", "", fmt.Errorf("no commit found for user prompt %s in dir: %s", convoMessageId, dir)
+	}
+	promptSha = shas[0]
+
+	out.Reset()
+	cmd = exec.Command("git", "rev-parse", "--short", "--verify", "--quiet", promptSha+"^")
+	cmd.Dir = dir
+	cmd.Stdout = &out
+	err = cmd.Run()
+	if err != nil {
+		return "", "", fmt.Errorf("no commit found before user prompt %s", promptSha)
+	}
+
+	return strings.TrimSpace(out.String()), promptSha, nil
+}
+
 func GitListBranches(orgId, planId string) ([]string, error) {
 	dir := getPlanDir(orgId, planId)
 
@@ -156,9 +199,19 @@ func GitDeleteBranch(orgId, planId, branchName string) error {
 		return fmt.Errorf("error deleting git branch for dir: %s, err: %v, output: %s", dir, err, string(res))
 	}
 
+	err = RebuildPlanContextBlobRefs(orgId, planId)
+	if err != nil {
+		return fmt.Errorf("error re-indexing context blobs: %v", err)
+	}
+	ScheduleContextBlobGC(orgId)
+
 	return nil
 }
 
+func GitCheckoutBranch(orgId, planId, branch string) error {
+	return gitCheckoutBranch(getPlanDir(orgId, planId), branch)
+}
+
 func GitClearUncommittedChanges(orgId, planId string) error {
 	dir := getPlanDir(orgId, planId)
 
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func useTempBaseDir(t *testing.T) {
+	baseDir := BaseDir
+	BaseDir = t.TempDir()
+	t.Cleanup(func() { BaseDir = baseDir })
+}
+
+func initTestPlan(t *testing.T, orgId, planId string) string {
+	t.Helper()
+
+	if err := InitPlan(orgId, planId); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := getPlanDir(orgId, planId)
+	if err := os.WriteFile(filepath.Join(dir, "plan.txt"), []byte("plan"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GitAddAndCommit(orgId, planId, "main", "init"); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestGetPromptParentSha(t *testing.T) {
+	useTempBaseDir(t)
+
+	dir := initTestPlan(t, "org", "plan")
+	initialSha, _, err := getLatestCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeConvoMessage := func(id, commitMsg string) string {
+		t.Helper()
+		convoDir := getPlanConversationDir("org", "plan")
+		if err := os.MkdirAll(convoDir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(convoDir, id+".json"), []byte(`{"id":"`+id+`"}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := GitAddAndCommit("org", "plan", "main", commitMsg); err != nil {
+			t.Fatal(err)
+		}
+		sha, _, err := getLatestCommit(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sha
+	}
+
+	promptSha := writeConvoMessage("prompt", "Message #1 | 💬 User prompt | 10 🪙")
+	// a later commit whose message mentions a user prompt, like a reply quoting it, isn't mistaken for the prompt
+	writeConvoMessage("reply", "Message #2 | 🤖 Plandex reply about the 💬 User prompt | 20 🪙")
+
+	parentSha, gotPromptSha, err := GetPromptParentSha("org", "plan", "main", "prompt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPromptSha != promptSha {
+		t.Errorf("prompt sha = %s, want %s", gotPromptSha, promptSha)
+	}
+	if parentSha != initialSha {
+		t.Errorf("parent sha = %s, want %s", parentSha, initialSha)
+	}
+
+	if _, _, err := GetPromptParentSha("org", "plan", "main", "missing"); err == nil {
+		t.Errorf("expected an error for a prompt that was never committed")
+	}
+
+	// prompts are looked up on the given branch, whichever one is checked out
+	if err := GitCreateBranch("org", "plan", "main", "other"); err != nil {
+		t.Fatal(err)
+	}
+	otherPromptSha := writeConvoMessage("otherPrompt", "Message #3 | 💬 User prompt | 10 🪙")
+
+	if _, gotPromptSha, err := GetPromptParentSha("org", "plan", "other", "otherPrompt"); err != nil {
+		t.Error(err)
+	} else if gotPromptSha != otherPromptSha {
+		t.Errorf("prompt sha on other = %s, want %s", gotPromptSha, otherPromptSha)
+	}
+	if _, _, err := GetPromptParentSha("org", "plan", "main", "otherPrompt"); err == nil {
+		t.Errorf("expected an error for a prompt that's only on another branch")
+	}
+	if _, gotPromptSha, err := GetPromptParentSha("org", "plan", "main", "prompt"); err != nil {
+		t.Error(err)
+	} else if gotPromptSha != promptSha {
+		t.Errorf("prompt sha on main = %s, want %s", gotPromptSha, promptSha)
+	}
+}
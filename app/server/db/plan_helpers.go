@@ -106,6 +106,17 @@ func ListOwnedPlans(projectIds []string, userId string, archived bool) ([]*Plan,
 	return plans, nil
 }
 
+func ListPlansSharedWithOrg(projectIds []string, orgId string) ([]*Plan, error) {
+	var plans []*Plan
+	err := Conn.Select(&plans, "SELECT * FROM plans WHERE project_id = ANY($1) AND org_id = $2 AND shared_with_org_at IS NOT NULL AND archived_at IS NULL ORDER BY updated_at DESC", pq.Array(projectIds), orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing shared plans: %v", err)
+	}
+
+	return plans, nil
+}
+
 func AddPlanContextTokens(planId, branch string, addTokens int) error {
 	_, err := Conn.Exec("UPDATE branches SET context_tokens = context_tokens + $1 WHERE plan_id = $2 AND name = $3", addTokens, planId, branch)
 	if err != nil {
@@ -399,6 +410,18 @@ func ValidatePlanAccess(planId, userId, orgId string) (*Plan, error) {
 	return nil, nil
 }
 
+// ListAccessiblePlanIds returns the ids of the org's plans that the user can access, matching the rules in ValidatePlanAccess
+func ListAccessiblePlanIds(orgId, userId string) ([]string, error) {
+	var planIds []string
+	err := Conn.Select(&planIds, "SELECT plans.id FROM plans JOIN projects ON projects.id = plans.project_id WHERE plans.org_id = $1 AND projects.org_id = $1 AND (plans.owner_id = $2 OR plans.shared_with_org_at IS NOT NULL)", orgId, userId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing accessible plans: %v", err)
+	}
+
+	return planIds, nil
+}
+
 func BumpPlanUpdatedAt(planId string, t time.Time) error {
 	_, err := Conn.Exec("UPDATE plans SET updated_at = $1 WHERE id = $2", t, planId)
 
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+func CreatePromptTemplate(template *PromptTemplate) error {
+	err := Conn.QueryRow(
+		"INSERT INTO prompt_templates (org_id, owner_id, name, description, body) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at",
+		template.OrgId, template.OwnerId, template.Name, template.Description, template.Body,
+	).Scan(&template.Id, &template.CreatedAt, &template.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("error creating prompt template: %v", err)
+	}
+
+	return nil
+}
+
+func GetPromptTemplate(orgId, id string) (*PromptTemplate, error) {
+	var template PromptTemplate
+	err := Conn.Get(&template, "SELECT * FROM prompt_templates WHERE org_id = $1 AND id = $2", orgId, id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting prompt template: %v", err)
+	}
+
+	return &template, nil
+}
+
+func GetPromptTemplateByName(orgId, name string) (*PromptTemplate, error) {
+	var template PromptTemplate
+	err := Conn.Get(&template, "SELECT * FROM prompt_templates WHERE org_id = $1 AND name = $2", orgId, name)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error getting prompt template: %v", err)
+	}
+
+	return &template, nil
+}
+
+func ListPromptTemplates(orgId string) ([]*PromptTemplate, error) {
+	var templates []*PromptTemplate
+	err := Conn.Select(&templates, "SELECT * FROM prompt_templates WHERE org_id = $1 ORDER BY name", orgId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing prompt templates: %v", err)
+	}
+
+	return templates, nil
+}
+
+func UpdatePromptTemplate(orgId, id, description, body string) error {
+	_, err := Conn.Exec("UPDATE prompt_templates SET description = $1, body = $2 WHERE org_id = $3 AND id = $4", description, body, orgId, id)
+
+	if err != nil {
+		return fmt.Errorf("error updating prompt template: %v", err)
+	}
+
+	return nil
+}
+
+func DeletePromptTemplate(orgId, id string) error {
+	_, err := Conn.Exec("DELETE FROM prompt_templates WHERE org_id = $1 AND id = $2", orgId, id)
+
+	if err != nil {
+		return fmt.Errorf("error deleting prompt template: %v", err)
+	}
+
+	return nil
+}
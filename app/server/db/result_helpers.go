@@ -115,11 +115,7 @@ func GetCurrentPlanState(params CurrentPlanStateParams) (*shared.CurrentPlanStat
 			contexts = params.Contexts
 		}
 
-		for _, context := range contexts {
-			if context.FilePath != "" {
-				contextsByPath[context.FilePath] = context
-			}
-		}
+		contextsByPath = GetContextsByPath(contexts)
 
 		errCh <- nil
 	}()
@@ -322,7 +318,8 @@ func GetPlanResult(planFileResults []*shared.PlanFileResult) *shared.PlanResult
 	}
 }
 
-func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
+// ApplyPlan marks pending results as applied and updates context to match. If filePaths is non-empty, only the results for those files are applied, and the rest stay pending. contentsByPath has what the client wrote for each file, which can include local edits merged with the plan's changes.
+func ApplyPlan(orgId, userId, branchName string, plan *Plan, filePaths []string, contentsByPath map[string]string) error {
 	planId := plan.Id
 
 	resultsDir := getPlanResultsDir(orgId, planId)
@@ -380,13 +377,41 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 
 	var pendingDbResults []*PlanFileResult
 
+	filePathsSet := make(map[string]bool)
+	for _, path := range filePaths {
+		filePathsSet[path] = true
+	}
+	numPendingLeft := 0
+
 	for _, result := range results {
 		apiResult := result.ToApi()
 		if apiResult.IsPending() {
+			if len(filePathsSet) > 0 && !filePathsSet[result.Path] {
+				numPendingLeft++
+				continue
+			}
 			pendingDbResults = append(pendingDbResults, result)
 		}
 	}
 
+	if len(filePathsSet) > 0 {
+		pendingPathsSet := make(map[string]bool)
+		for _, result := range pendingDbResults {
+			pendingPathsSet[result.Path] = true
+		}
+		for path := range filePathsSet {
+			if !pendingPathsSet[path] {
+				return fmt.Errorf("no pending changes for file: %s", path)
+			}
+		}
+	}
+
+	// descriptions are only marked applied once nothing they describe is still pending
+	descriptionsToApply := convoMessageDescriptions
+	if numPendingLeft > 0 {
+		descriptionsToApply = nil
+	}
+
 	pendingNewFilesSet := make(map[string]bool)
 	pendingUpdatedFilesSet := make(map[string]bool)
 	for _, result := range pendingDbResults {
@@ -442,7 +467,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 		}(result)
 	}
 
-	for _, description := range convoMessageDescriptions {
+	for _, description := range descriptionsToApply {
 		go func(description *ConvoMessageDescription) {
 			description.AppliedAt = &now
 
@@ -457,6 +482,14 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 		}(description)
 	}
 
+	// older clients don't send what they wrote, so context is set to the plan's version
+	getAppliedBody := func(path string) string {
+		if content, ok := contentsByPath[path]; ok {
+			return content
+		}
+		return currentPlanState.CurrentPlanFiles.Files[path]
+	}
+
 	if len(pendingNewFilesSet) > 0 {
 		go func() {
 			loadReq := shared.LoadContextRequest{}
@@ -465,7 +498,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 					ContextType: shared.ContextFileType,
 					Name:        path,
 					FilePath:    path,
-					Body:        currentPlanState.CurrentPlanFiles.Files[path],
+					Body:        getAppliedBody(path),
 				})
 			}
 
@@ -496,7 +529,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 			for path := range pendingUpdatedFilesSet {
 				context := contextsByPath[path]
 				updateReq[context.Id] = &shared.UpdateContextParams{
-					Body: currentPlanState.CurrentPlanFiles.Files[path],
+					Body: getAppliedBody(path),
 				}
 			}
 
@@ -523,7 +556,7 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 	}
 
 	numRoutines := len(pendingDbResults) +
-		len(convoMessageDescriptions)
+		len(descriptionsToApply)
 	if len(pendingNewFilesSet) > 0 {
 		numRoutines++
 	}
@@ -539,6 +572,17 @@ func ApplyPlan(orgId, userId, branchName string, plan *Plan) error {
 	}
 
 	msg := "✅ Marked pending results as applied"
+	if numPendingLeft > 0 {
+		var appliedPaths []string
+		for path := range pendingNewFilesSet {
+			appliedPaths = append(appliedPaths, path)
+		}
+		for path := range pendingUpdatedFilesSet {
+			appliedPaths = append(appliedPaths, path)
+		}
+		sort.Strings(appliedPaths)
+		msg = "✅ Marked pending results as applied for: " + strings.Join(appliedPaths, ", ")
+	}
 
 	if loadContextRes != nil && !loadContextRes.MaxTokensExceeded {
 		msg += "\n\n" + loadContextRes.Msg
@@ -659,6 +703,186 @@ func DeletePendingResultsForPaths(orgId, planId string, paths map[string]bool) e
 	return nil
 }
 
+// UnapplyPlan undoes ApplyPlan for the results in req.ResultIds. File contexts are put back to how they were before the apply, contexts the apply loaded for new files are removed, and the results are marked pending again so they can be applied later. Descriptions of the convo messages that made those results are marked unapplied too.
+func UnapplyPlan(orgId, branchName string, plan *Plan, req *shared.UnapplyPlanRequest) error {
+	planId := plan.Id
+	resultsDir := getPlanResultsDir(orgId, planId)
+
+	resultIdsSet := make(map[string]bool)
+	for _, id := range req.ResultIds {
+		resultIdsSet[id] = true
+	}
+
+	errCh := make(chan error)
+
+	var results []*PlanFileResult
+	var descriptions []*ConvoMessageDescription
+	contextsByPath := make(map[string]*Context)
+
+	go func() {
+		res, err := GetPlanFileResults(orgId, planId)
+		if err != nil {
+			errCh <- fmt.Errorf("error getting plan file results: %v", err)
+			return
+		}
+		results = res
+		errCh <- nil
+	}()
+
+	go func() {
+		res, err := GetConvoMessageDescriptions(orgId, planId)
+		if err != nil {
+			errCh <- fmt.Errorf("error getting convo message descriptions: %v", err)
+			return
+		}
+		descriptions = res
+		errCh <- nil
+	}()
+
+	go func() {
+		res, err := GetPlanContexts(orgId, planId, false)
+		if err != nil {
+			errCh <- fmt.Errorf("error getting contexts: %v", err)
+			return
+		}
+
+		for _, context := range res {
+			if context.ContextType == shared.ContextFileType {
+				contextsByPath[context.FilePath] = context
+			}
+		}
+
+		errCh <- nil
+	}()
+
+	for i := 0; i < 3; i++ {
+		err := <-errCh
+		if err != nil {
+			return err
+		}
+	}
+
+	var toUnapply []*PlanFileResult
+	convoMessageIds := make(map[string]bool)
+	for _, result := range results {
+		if resultIdsSet[result.Id] && result.AppliedAt != nil {
+			toUnapply = append(toUnapply, result)
+			convoMessageIds[result.ConvoMessageId] = true
+		}
+	}
+
+	if len(toUnapply) == 0 {
+		return fmt.Errorf("no applied results to unapply")
+	}
+
+	// newer pending changes were built on top of the applied ones, so they'd no longer apply cleanly
+	unapplyPaths := make(map[string]bool)
+	for _, result := range toUnapply {
+		unapplyPaths[result.Path] = true
+	}
+	for _, result := range results {
+		if unapplyPaths[result.Path] && result.ToApi().IsPending() {
+			return fmt.Errorf("there are newer pending changes to %s, so it can't be unapplied until they're applied or rejected", result.Path)
+		}
+	}
+
+	// contexts are reverted before the results are pending again so that the pending results aren't invalidated as conflicts
+	updateReq := shared.UpdateContextRequest{}
+	for path, body := range req.ContextBodiesByPath {
+		if context := contextsByPath[path]; context != nil && unapplyPaths[path] && !context.IsPartialFile() {
+			updateReq[context.Id] = &shared.UpdateContextParams{Body: body}
+		}
+	}
+
+	if len(updateReq) > 0 {
+		_, err := UpdateContexts(UpdateContextsParams{
+			OrgId:                    orgId,
+			Plan:                     plan,
+			BranchName:               branchName,
+			Req:                      &updateReq,
+			SkipConflictInvalidation: true,
+		})
+
+		if err != nil {
+			return fmt.Errorf("error reverting context: %v", err)
+		}
+	}
+
+	var toRemove []*Context
+	removeTokens := 0
+	for _, path := range req.RemoveContextPaths {
+		if context := contextsByPath[path]; context != nil && unapplyPaths[path] {
+			toRemove = append(toRemove, context)
+			removeTokens += context.NumTokens
+		}
+	}
+
+	if len(toRemove) > 0 {
+		err := ContextRemove(orgId, planId, toRemove)
+		if err != nil {
+			return fmt.Errorf("error removing context: %v", err)
+		}
+
+		err = AddPlanContextTokens(planId, branchName, -removeTokens)
+		if err != nil {
+			return fmt.Errorf("error updating plan tokens: %v", err)
+		}
+	}
+
+	var descriptionsToUnapply []*ConvoMessageDescription
+	for _, description := range descriptions {
+		if convoMessageIds[description.ConvoMessageId] && description.AppliedAt != nil {
+			descriptionsToUnapply = append(descriptionsToUnapply, description)
+		}
+	}
+
+	for _, result := range toUnapply {
+		go func(result *PlanFileResult) {
+			result.AppliedAt = nil
+
+			bytes, err := json.MarshalIndent(result, "", "  ")
+
+			if err != nil {
+				errCh <- fmt.Errorf("error marshalling result: %v", err)
+				return
+			}
+
+			err = os.WriteFile(filepath.Join(resultsDir, result.Id+".json"), bytes, 0644)
+
+			if err != nil {
+				errCh <- fmt.Errorf("error writing result file: %v", err)
+				return
+			}
+
+			errCh <- nil
+		}(result)
+	}
+
+	for _, description := range descriptionsToUnapply {
+		go func(description *ConvoMessageDescription) {
+			description.AppliedAt = nil
+
+			err := StoreDescription(description)
+
+			if err != nil {
+				errCh <- fmt.Errorf("error storing convo message description: %v", err)
+				return
+			}
+
+			errCh <- nil
+		}(description)
+	}
+
+	for i := 0; i < len(toUnapply)+len(descriptionsToUnapply); i++ {
+		err := <-errCh
+		if err != nil {
+			return fmt.Errorf("error unapplying results: %v", err)
+		}
+	}
+
+	return nil
+}
+
 func RejectPlanFile(orgId, planId, file string, now time.Time) error {
 	resultsDir := getPlanResultsDir(orgId, planId)
 	results, err := GetPlanFileResults(orgId, planId)
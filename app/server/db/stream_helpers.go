@@ -142,6 +142,26 @@ func GetActiveModelStreams(planIds []string) ([]*ModelStream, error) {
 	return streams, nil
 }
 
+// idOrPrefix can be a full stream id or a short prefix like the ones shown by 'plandex ps'
+func GetModelStream(orgId, idOrPrefix string) (*ModelStream, error) {
+	var streams []*ModelStream
+	err := Conn.Select(&streams, "SELECT * FROM model_streams WHERE org_id = $1 AND id::text LIKE $2 || '%' ORDER BY created_at DESC LIMIT 2", orgId, idOrPrefix)
+
+	if err != nil {
+		return nil, fmt.Errorf("error getting model stream: %v", err)
+	}
+
+	if len(streams) == 0 {
+		return nil, nil
+	}
+
+	if len(streams) > 1 {
+		return nil, fmt.Errorf("stream id prefix %s is ambiguous", idOrPrefix)
+	}
+
+	return streams[0], nil
+}
+
 // func StoreModelStreamSubscription(subscription *ModelStreamSubscription) error {
 // 	query := `INSERT INTO model_stream_subscriptions (model_stream_id, org_id, plan_id, user_id, user_ip) VALUES (:model_stream_id, :org_id, :plan_id, :user_id, :user_ip) RETURNING id, created_at`
 
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plandex/plandex/shared"
+)
+
+type StreamLogWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func CreateStreamLog(orgId, planId, modelStreamId string) (*StreamLogWriter, error) {
+	dir := getPlanStreamLogsDir(orgId, planId)
+	err := os.MkdirAll(dir, os.ModePerm)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating stream logs dir: %v", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, modelStreamId+".jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening stream log: %v", err)
+	}
+
+	return &StreamLogWriter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (lw *StreamLogWriter) Append(entry *shared.StreamLogEntry) error {
+	err := lw.enc.Encode(entry)
+
+	if err != nil {
+		return fmt.Errorf("error writing stream log entry: %v", err)
+	}
+
+	return nil
+}
+
+func (lw *StreamLogWriter) Close() error {
+	return lw.file.Close()
+}
+
+func GetStreamLogEntries(orgId, planId, modelStreamId string) ([]*shared.StreamLogEntry, error) {
+	file, err := os.Open(filepath.Join(getPlanStreamLogsDir(orgId, planId), modelStreamId+".jsonl"))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error opening stream log: %v", err)
+	}
+
+	defer file.Close()
+
+	var entries []*shared.StreamLogEntry
+
+	scanner := bufio.NewScanner(file)
+	// entries for large reply chunks or errors can exceed the default token size
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		var entry shared.StreamLogEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling stream log entry: %v", err)
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stream log: %v", err)
+	}
+
+	return entries, nil
+}
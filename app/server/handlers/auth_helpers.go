@@ -280,3 +280,50 @@ func authorizePlanArchive(w http.ResponseWriter, planId string, auth *types.Serv
 
 	return plan
 }
+
+// only the user who created an org template can change or remove it
+func authorizePromptTemplate(w http.ResponseWriter, templateId string, auth *types.ServerAuth) *db.PromptTemplate {
+	template, err := db.GetPromptTemplate(auth.OrgId, templateId)
+
+	if err != nil {
+		log.Printf("Error getting prompt template: %v\n", err)
+		http.Error(w, "Error getting prompt template: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	if template == nil {
+		log.Printf("Prompt template not found: %v\n", templateId)
+		http.Error(w, "Prompt template not found: "+templateId, http.StatusNotFound)
+		return nil
+	}
+
+	if template.OwnerId != auth.User.Id {
+		log.Printf("User %s is not the owner of prompt template %s\n", auth.User.Id, templateId)
+		http.Error(w, "Only the user who created a prompt template can change it", http.StatusForbidden)
+		return nil
+	}
+
+	return template
+}
+
+func authorizeContextSet(w http.ResponseWriter, projectId, name string, auth *types.ServerAuth) *db.ContextSet {
+	if !authorizeProject(w, projectId, auth) {
+		return nil
+	}
+
+	set, err := db.GetContextSetByName(auth.OrgId, projectId, name)
+
+	if err != nil {
+		log.Printf("Error getting context set: %v\n", err)
+		http.Error(w, "Error getting context set: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	if set == nil {
+		log.Printf("Context set not found: %v\n", name)
+		http.Error(w, "Context set not found: "+name, http.StatusNotFound)
+		return nil
+	}
+
+	return set
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func MissingContextBlobsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for MissingContextBlobsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	log.Println("planId: ", planId)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.MissingContextBlobsRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	blobPlanIds, err := db.GetContextBlobPlanIds(auth.OrgId, auth.User.Id, planId)
+
+	if err != nil {
+		log.Printf("Error getting accessible plans: %v\n", err)
+		http.Error(w, "Error getting accessible plans: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	missing, err := db.GetMissingContextBlobs(auth.OrgId, blobPlanIds, requestBody.Hashes)
+
+	if err != nil {
+		log.Printf("Error checking context blobs: %v\n", err)
+		http.Error(w, "Error checking context blobs: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bytes, err := json.Marshal(shared.MissingContextBlobsResponse{Missing: missing})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("%d of %d context blobs missing\n", len(missing), len(requestBody.Hashes))
+
+	w.Write(bytes)
+}
@@ -62,3 +62,57 @@ func loadContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth
 
 	return res, dbContexts
 }
+
+func updateContexts(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, updateReq *shared.UpdateContextRequest, plan *db.Plan, branchName string, contextsById map[string]*db.Context, skipConflictInvalidation bool) *shared.UpdateContextResponse {
+	var err error
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return nil
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	updateRes, err := db.UpdateContexts(db.UpdateContextsParams{
+		Req:                      updateReq,
+		OrgId:                    auth.OrgId,
+		Plan:                     plan,
+		BranchName:               branchName,
+		UserId:                   auth.User.Id,
+		ContextsById:             contextsById,
+		SkipConflictInvalidation: skipConflictInvalidation,
+	})
+
+	if err != nil {
+		log.Printf("Error error updating contexts: %v\n", err)
+		http.Error(w, "Error error updating contexts: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	if updateRes.MaxTokensExceeded {
+		log.Printf("The total number of tokens (%d) exceeds the maximum allowed (%d)", updateRes.TotalTokens, updateRes.MaxTokens)
+		bytes, err := json.Marshal(updateRes)
+
+		if err != nil {
+			log.Printf("Error marshalling response: %v\n", err)
+			http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+
+		w.Write(bytes)
+		return nil
+	}
+
+	err = db.GitAddAndCommit(auth.OrgId, plan.Id, branchName, updateRes.Msg)
+
+	if err != nil {
+		log.Printf("Error committing changes: %v\n", err)
+		http.Error(w, "Error committing changes: "+err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	return updateRes
+}
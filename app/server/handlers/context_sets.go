@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"plandex-server/db"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func SaveContextSetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SaveContextSetHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branchName := vars["branch"]
+	log.Println("planId: ", planId, "branch: ", branchName)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.SaveContextSetRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	if !shared.IsValidContextSetName(requestBody.Name) {
+		log.Printf("Invalid context set name: %v\n", requestBody.Name)
+		http.Error(w, "Invalid context set name: "+requestBody.Name, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeRead, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	dbContexts, err := db.GetPlanContexts(auth.OrgId, planId, true)
+
+	if err != nil {
+		log.Printf("Error getting contexts: %v\n", err)
+		http.Error(w, "Error getting contexts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if len(dbContexts) == 0 {
+		log.Println("No context to save")
+		http.Error(w, "No context to save", http.StatusBadRequest)
+		return
+	}
+
+	refs := []*shared.ContextSetRef{}
+	for _, dbContext := range dbContexts {
+		apiContext := dbContext.ToApi()
+		apiContext.Body = db.UnescapeContextBody(dbContext.Body)
+		refs = append(refs, shared.ContextSetRefFromContext(apiContext))
+	}
+
+	set, summary, changed, err := db.SaveContextSet(db.SaveContextSetParams{
+		OrgId:     auth.OrgId,
+		ProjectId: plan.ProjectId,
+		OwnerId:   auth.User.Id,
+		Name:      requestBody.Name,
+		Refs:      refs,
+	})
+
+	if err != nil {
+		log.Printf("Error saving context set: %v\n", err)
+		http.Error(w, "Error saving context set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var msg string
+	if changed {
+		msg = fmt.Sprintf("Saved context set %s (v%d) | %s", set.Name, set.Version, summary)
+	} else {
+		msg = fmt.Sprintf("Context set %s (v%d) already matches the current context", set.Name, set.Version)
+	}
+
+	apiSet := set.ToApi()
+	apiSet.Refs = refs
+	if changed {
+		apiSet.SavedBy = auth.User.Id
+	}
+
+	bytes, err := json.Marshal(shared.SaveContextSetResponse{
+		Set:     apiSet,
+		Changed: changed,
+		Msg:     msg,
+	})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed SaveContextSetHandler request")
+
+	w.Write(bytes)
+}
+
+func ListContextSetsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListContextSetsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectId := vars["projectId"]
+
+	if !authorizeProject(w, projectId, auth) {
+		return
+	}
+
+	sets, err := db.ListContextSets(auth.OrgId, projectId)
+
+	if err != nil {
+		log.Printf("Error listing context sets: %v\n", err)
+		http.Error(w, "Error listing context sets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiSets := []*shared.ContextSet{}
+	for _, set := range sets {
+		apiSets = append(apiSets, set.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiSets)
+
+	if err != nil {
+		log.Printf("Error marshalling context sets: %v\n", err)
+		http.Error(w, "Error marshalling context sets: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+	log.Println("Successfully processed request for ListContextSetsHandler")
+}
+
+func GetContextSetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetContextSetHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	set := authorizeContextSet(w, vars["projectId"], vars["name"], auth)
+	if set == nil {
+		return
+	}
+
+	refs, savedBy, err := db.GetContextSetRefs(set)
+
+	if err != nil {
+		log.Printf("Error getting context set refs: %v\n", err)
+		http.Error(w, "Error getting context set refs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiSet := set.ToApi()
+	apiSet.SavedBy = savedBy
+	apiSet.Refs = refs
+
+	bytes, err := json.Marshal(apiSet)
+
+	if err != nil {
+		log.Printf("Error marshalling context set: %v\n", err)
+		http.Error(w, "Error marshalling context set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+	log.Println("Successfully processed request for GetContextSetHandler")
+}
+
+func ListContextSetVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for ListContextSetVersionsHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	set := authorizeContextSet(w, vars["projectId"], vars["name"], auth)
+	if set == nil {
+		return
+	}
+
+	versions, err := db.ListContextSetVersions(set.Id)
+
+	if err != nil {
+		log.Printf("Error listing context set versions: %v\n", err)
+		http.Error(w, "Error listing context set versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(versions)
+
+	if err != nil {
+		log.Printf("Error marshalling context set versions: %v\n", err)
+		http.Error(w, "Error marshalling context set versions: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+	log.Println("Successfully processed request for ListContextSetVersionsHandler")
+}
+
+func DeleteContextSetHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for DeleteContextSetHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	set := authorizeContextSet(w, vars["projectId"], vars["name"], auth)
+	if set == nil {
+		return
+	}
+
+	if set.OwnerId != auth.User.Id {
+		log.Printf("User %s is not the owner of context set %s\n", auth.User.Id, set.Name)
+		http.Error(w, "Only the user who created a context set can delete it", http.StatusForbidden)
+		return
+	}
+
+	err := db.DeleteContextSet(auth.OrgId, set.Id)
+
+	if err != nil {
+		log.Printf("Error deleting context set: %v\n", err)
+		http.Error(w, "Error deleting context set: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully deleted context set")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"plandex-server/db"
@@ -84,7 +85,24 @@ func ApplyPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var err error
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// older clients send no body to apply every pending change
+	var req shared.ApplyPlanRequest
+	if len(body) > 0 {
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			log.Printf("Error unmarshalling request: %v\n", err)
+			http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
 	if unlockFn == nil {
@@ -95,7 +113,7 @@ func ApplyPlanHandler(w http.ResponseWriter, r *http.Request) {
 		}()
 	}
 
-	err = db.ApplyPlan(auth.OrgId, auth.User.Id, branch, plan)
+	err = db.ApplyPlan(auth.OrgId, auth.User.Id, branch, plan, req.FilePaths, req.ContentsByPath)
 
 	if err != nil {
 		log.Printf("Error applying plan: %v\n", err)
@@ -106,6 +124,61 @@ func ApplyPlanHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Successfully applied plan", planId)
 }
 
+func UnapplyPlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for UnapplyPlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	var req shared.UnapplyPlanRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error decoding request: %v\n", err)
+		http.Error(w, "Error decoding request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	err = db.UnapplyPlan(auth.OrgId, branch, plan, &req)
+
+	if err != nil {
+		log.Printf("Error unapplying plan: %v\n", err)
+		http.Error(w, "Error unapplying plan: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.GitAddAndCommit(auth.OrgId, planId, branch, "↩️ Marked applied results as pending again")
+
+	if err != nil {
+		log.Printf("Error committing unapplied results: %v\n", err)
+		http.Error(w, "Error committing unapplied results: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully unapplied plan", planId)
+}
+
 func RejectAllChangesHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("Received request for RejectAllChangesHandler")
 
@@ -47,10 +47,23 @@ func ListContextHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	budgetReport, err := db.GetContextBudgetReport(auth.OrgId, planId)
+
+	if err != nil {
+		log.Printf("Error getting context budget report: %v\n", err)
+		http.Error(w, "Error getting context budget report: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	var apiContexts []*shared.Context
 
 	for _, dbContext := range dbContexts {
-		apiContexts = append(apiContexts, dbContext.ToApi())
+		apiContext := dbContext.ToApi()
+		if budgetReport != nil {
+			// context loaded since the last reply has no status
+			apiContext.LastReplyStatus = budgetReport.StatusById[dbContext.Id]
+		}
+		apiContexts = append(apiContexts, apiContext)
 	}
 
 	bytes, err := json.Marshal(apiContexts)
@@ -151,48 +164,8 @@ func UpdateContextHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
-	if unlockFn == nil {
-		return
-	} else {
-		defer func() {
-			(*unlockFn)(err)
-		}()
-	}
-
-	updateRes, err := db.UpdateContexts(db.UpdateContextsParams{
-		Req:        &requestBody,
-		OrgId:      auth.OrgId,
-		Plan:       plan,
-		BranchName: branchName,
-	})
-
-	if err != nil {
-		log.Printf("Error error updating contexts: %v\n", err)
-		http.Error(w, "Error error updating contexts: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if updateRes.MaxTokensExceeded {
-		log.Printf("The total number of tokens (%d) exceeds the maximum allowed (%d)", updateRes.TotalTokens, updateRes.MaxTokens)
-		bytes, err := json.Marshal(updateRes)
-
-		if err != nil {
-			log.Printf("Error marshalling response: %v\n", err)
-			http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.Write(bytes)
-		return
-	}
-
-	err = db.GitAddAndCommit(auth.OrgId, planId, branchName, updateRes.Msg)
-
-	if err != nil {
-		log.Printf("Error committing changes: %v\n", err)
-		http.Error(w, "Error committing changes: "+err.Error(), http.StatusInternalServerError)
+	updateRes := updateContexts(w, r, auth, &requestBody, plan, branchName, nil, false)
+	if updateRes == nil {
 		return
 	}
 
@@ -326,3 +299,103 @@ func DeleteContextHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(bytes)
 }
+
+func SetContextPriorityHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for SetContextPriorityHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branchName := vars["branch"]
+	log.Println("planId: ", planId)
+
+	if authorizePlan(w, planId, auth) == nil {
+		return
+	}
+
+	// read the request body
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var requestBody shared.SetContextPriorityRequest
+	if err := json.Unmarshal(body, &requestBody); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	dbContexts, err := db.GetPlanContexts(auth.OrgId, planId, false)
+
+	if err != nil {
+		log.Printf("Error getting contexts: %v\n", err)
+		http.Error(w, "Error getting contexts: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var updated []*shared.Context
+	for _, dbContext := range dbContexts {
+		if !requestBody.Ids[dbContext.Id] {
+			continue
+		}
+
+		if requestBody.Priority != nil {
+			dbContext.Priority = *requestBody.Priority
+		}
+		if requestBody.Pinned != nil {
+			dbContext.Pinned = *requestBody.Pinned
+		}
+
+		err = db.StoreContextMeta(dbContext)
+
+		if err != nil {
+			log.Printf("Error storing context: %v\n", err)
+			http.Error(w, "Error storing context: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		updated = append(updated, dbContext.ToApi())
+	}
+
+	commitMsg := shared.SummaryForContextPriority(updated, requestBody.Priority, requestBody.Pinned)
+
+	if len(updated) > 0 {
+		err = db.GitAddAndCommit(auth.OrgId, planId, branchName, commitMsg)
+
+		if err != nil {
+			log.Printf("Error committing changes: %v\n", err)
+			http.Error(w, "Error committing changes: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	bytes, err := json.Marshal(shared.SetContextPriorityResponse{Msg: commitMsg})
+
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully processed SetContextPriorityHandler request")
+
+	w.Write(bytes)
+}
@@ -362,6 +362,7 @@ func ListPlansRunningHandler(w http.ResponseWriter, r *http.Request) {
 
 	projectIds := r.URL.Query()["projectId"]
 	includeRecent := r.URL.Query().Get("recent") == "true"
+	includeShared := r.URL.Query().Get("shared") == "true"
 
 	log.Println("projectIds: ", projectIds)
 
@@ -385,6 +386,23 @@ func ListPlansRunningHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// shared plans are included so that org members can watch them
+	if includeShared {
+		sharedPlans, err := db.ListPlansSharedWithOrg(projectIds, auth.OrgId)
+
+		if err != nil {
+			log.Printf("Error listing shared plans: %v\n", err)
+			http.Error(w, "Error listing shared plans: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, plan := range sharedPlans {
+			if plan.OwnerId != auth.User.Id {
+				plans = append(plans, plan)
+			}
+		}
+	}
+
 	var planIds []string
 	for _, plan := range plans {
 		planIds = append(planIds, plan.Id)
@@ -97,7 +97,7 @@ func TellPlanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if requestBody.ConnectStream {
-		startResponseStream(w, auth, planId, branch, false)
+		startResponseStream(w, r, auth, planId, branch, false, false)
 	}
 
 	log.Println("Successfully processed request for TellPlanHandler")
@@ -160,7 +160,7 @@ func BuildPlanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if requestBody.ConnectStream {
-		startResponseStream(w, auth, planId, branch, false)
+		startResponseStream(w, r, auth, planId, branch, false, false)
 	}
 
 	log.Println("Successfully processed request for BuildPlanHandler")
@@ -202,7 +202,11 @@ func ConnectPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startResponseStream(w, auth, planId, branch, true)
+	// any org member with access to a shared plan can watch its stream, but only users who can update the plan can control it
+	readOnly := r.URL.Query().Get("watch") == "true" ||
+		(plan.OwnerId != auth.User.Id && !auth.HasPermission(types.PermissionUpdateAnyPlan))
+
+	startResponseStream(w, r, auth, planId, branch, true, readOnly)
 
 	log.Println("Successfully processed request for ConnectPlanHandler")
 }
@@ -233,7 +237,7 @@ func StopPlanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if authorizePlan(w, planId, auth) == nil {
+	if authorizePlanExecUpdate(w, planId, auth) == nil {
 		return
 	}
 
@@ -297,7 +301,7 @@ func RespondMissingFileHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	plan := authorizePlan(w, planId, auth)
+	plan := authorizePlanExecUpdate(w, planId, auth)
 	if plan == nil {
 		return
 	}
@@ -320,7 +324,46 @@ func RespondMissingFileHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("missing file choice:", requestBody.Choice)
 
+	var outlineContext *db.Context
 	if requestBody.Choice == shared.RespondMissingFileChoiceLoad {
+		if contextPart := active.ContextsByPath[requestBody.FilePath]; contextPart != nil && contextPart.Outline {
+			for _, context := range active.Contexts {
+				if context.Id == contextPart.Id {
+					outlineContext = context
+					break
+				}
+			}
+		}
+	}
+
+	if outlineContext != nil {
+		log.Println("switching outline to full file")
+
+		// the outline context becomes a full file context rather than loading the file a second time
+		fullContext := *outlineContext
+		fullContext.Outline = false
+		fullContext.FileBody = ""
+
+		res := updateContexts(w, r, auth, &shared.UpdateContextRequest{
+			fullContext.Id: &shared.UpdateContextParams{
+				Body: requestBody.Body,
+			},
+		}, plan, branch, map[string]*db.Context{fullContext.Id: &fullContext}, true)
+		if res == nil {
+			return
+		}
+
+		log.Println("loaded full file:", fullContext.FilePath)
+
+		modelPlan.UpdateActivePlan(planId, branch, func(activePlan *types.ActivePlan) {
+			for i, context := range activePlan.Contexts {
+				if context.Id == fullContext.Id {
+					activePlan.Contexts[i] = &fullContext
+				}
+			}
+			activePlan.ContextsByPath[fullContext.FilePath] = &fullContext
+		})
+	} else if requestBody.Choice == shared.RespondMissingFileChoiceLoad {
 		log.Println("loading missing file")
 		res, dbContexts := loadContexts(w, r, auth, &shared.LoadContextRequest{
 			&shared.LoadContextParams{
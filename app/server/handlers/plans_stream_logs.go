@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"regexp"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+var streamIdPrefixRegex = regexp.MustCompile(`^[0-9a-f-]{4,36}$`)
+
+func GetStreamLogHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for GetStreamLogHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	streamId := vars["streamId"]
+
+	log.Println("streamId: ", streamId)
+
+	if !streamIdPrefixRegex.MatchString(streamId) {
+		log.Println("Invalid stream id")
+		http.Error(w, "Invalid stream id", http.StatusBadRequest)
+		return
+	}
+
+	modelStream, err := db.GetModelStream(auth.OrgId, streamId)
+
+	if err != nil {
+		log.Printf("Error getting model stream: %v\n", err)
+		http.Error(w, "Error getting model stream: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if modelStream == nil {
+		log.Println("Model stream not found")
+		http.Error(w, "Stream not found", http.StatusNotFound)
+		return
+	}
+
+	if authorizePlan(w, modelStream.PlanId, auth) == nil {
+		return
+	}
+
+	res := shared.StreamLog{
+		ModelStreamId: modelStream.Id,
+		PlanId:        modelStream.PlanId,
+		Branch:        modelStream.Branch,
+		CreatedAt:     modelStream.CreatedAt,
+		FinishedAt:    modelStream.FinishedAt,
+	}
+
+	// entries are only needed once the stream is finished -- active streams are followed with connect
+	if modelStream.FinishedAt != nil {
+		res.Entries, err = db.GetStreamLogEntries(auth.OrgId, modelStream.PlanId, modelStream.Id)
+
+		if err != nil {
+			log.Printf("Error getting stream log entries: %v\n", err)
+			http.Error(w, "Error getting stream log entries: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	bytes, err := json.Marshal(res)
+
+	if err != nil {
+		log.Printf("Error marshalling stream log: %v\n", err)
+		http.Error(w, "Error marshalling stream log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for GetStreamLogHandler")
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
 )
 
 func ListLogsHandler(w http.ResponseWriter, r *http.Request) {
@@ -152,3 +154,188 @@ func RewindPlanHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("Successfully processed request for RewindPlanHandler")
 }
+
+func RetryPlanHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received request for RetryPlanHandler")
+
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	planId := vars["planId"]
+	branch := vars["branch"]
+
+	log.Println("planId: ", planId, "branch: ", branch)
+
+	plan := authorizePlan(w, planId, auth)
+	if plan == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("Error reading request body: %v\n", err)
+		http.Error(w, "Error reading request body", http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+
+	var req shared.RetryPlanRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		log.Printf("Error parsing request body: %v\n", err)
+		http.Error(w, "Error parsing request body", http.StatusBadRequest)
+		return
+	}
+
+	parentBranch, err := db.GetDbBranch(planId, branch)
+	if err != nil {
+		log.Printf("Error getting parent branch: %v\n", err)
+		http.Error(w, "Error getting parent branch: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if parentBranch == nil {
+		log.Printf("Branch %s not found\n", branch)
+		http.Error(w, "Branch not found", http.StatusNotFound)
+		return
+	}
+
+	branches, err := db.ListPlanBranches(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error listing branches: %v\n", err)
+		http.Error(w, "Error listing branches: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	existing := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		existing[b.Name] = true
+	}
+
+	name := req.Name
+	if name == "" {
+		for i := 1; ; i++ {
+			name = fmt.Sprintf("%s-retry-%d", branch, i)
+			if !existing[name] {
+				break
+			}
+		}
+	} else if existing[name] {
+		log.Printf("Branch %s already exists\n", name)
+		http.Error(w, "Branch already exists: "+name, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	unlockFn := lockRepo(w, r, auth, db.LockScopeWrite, ctx, cancel, true)
+	if unlockFn == nil {
+		return
+	} else {
+		defer func() {
+			(*unlockFn)(err)
+		}()
+	}
+
+	// the prompt is read before the new branch is rewound, since rewinding removes it from the convo
+	convo, err := db.GetPlanConvo(auth.OrgId, planId)
+	if err != nil {
+		log.Printf("Error getting plan convo: %v\n", err)
+		http.Error(w, "Error getting plan convo: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var promptMsg *db.ConvoMessage
+	for i := len(convo) - 1; i >= 0; i-- {
+		if convo[i].Role == openai.ChatMessageRoleUser {
+			promptMsg = convo[i]
+			break
+		}
+	}
+
+	if promptMsg == nil || promptMsg.Message == "" {
+		log.Println("No user prompt found in convo")
+		http.Error(w, "No prompt to retry on this branch", http.StatusBadRequest)
+		err = fmt.Errorf("no user prompt found in convo")
+		return
+	}
+	prompt := promptMsg.Message
+
+	parentSha, _, err := db.GetPromptParentSha(auth.OrgId, planId, branch, promptMsg.Id)
+	if err != nil {
+		log.Printf("Error finding last prompt: %v\n", err)
+		http.Error(w, "No prompt to retry on this branch", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Conn.Begin()
+	if err != nil {
+		log.Printf("Error starting transaction: %v\n", err)
+		http.Error(w, "Error starting transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Ensure that rollback is attempted in case of failure
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("transaction rollback error: %v\n", rbErr)
+			} else {
+				log.Println("transaction rolled back")
+			}
+		}
+	}()
+
+	// creating the branch also checks it out in the plan repo
+	_, err = db.CreateBranch(plan, parentBranch, name, tx)
+	if err != nil {
+		log.Printf("Error creating branch: %v\n", err)
+		http.Error(w, "Error creating branch: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.GitRewindToSha(auth.OrgId, planId, name, parentSha)
+	if err != nil {
+		log.Printf("Error rewinding branch: %v\n", err)
+		http.Error(w, "Error rewinding branch: "+err.Error(), http.StatusInternalServerError)
+
+		// the branch row is rolled back with the transaction, but the git branch has to be removed separately
+		if checkoutErr := db.GitCheckoutBranch(auth.OrgId, planId, branch); checkoutErr != nil {
+			log.Printf("Error checking out parent branch: %v\n", checkoutErr)
+		} else if deleteErr := db.GitDeleteBranch(auth.OrgId, planId, name); deleteErr != nil {
+			log.Printf("Error deleting retry branch: %v\n", deleteErr)
+		}
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error committing transaction: %v\n", err)
+		http.Error(w, "Error committing transaction: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = db.SyncPlanTokens(auth.OrgId, planId, name)
+	if err != nil {
+		log.Printf("Error syncing plan tokens: %v\n", err)
+		http.Error(w, "Error syncing plan tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := shared.RetryPlanResponse{
+		Branch: name,
+		Prompt: prompt,
+		Sha:    parentSha,
+	}
+
+	bytes, err := json.Marshal(res)
+	if err != nil {
+		log.Printf("Error marshalling response: %v\n", err)
+		http.Error(w, "Error marshalling response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+
+	log.Println("Successfully processed request for RetryPlanHandler")
+}
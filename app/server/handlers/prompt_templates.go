@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"plandex-server/db"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/plandex/plandex/shared"
+)
+
+func ListPromptTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for ListPromptTemplatesHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	templates, err := db.ListPromptTemplates(auth.OrgId)
+
+	if err != nil {
+		log.Printf("Error listing prompt templates: %v\n", err)
+		http.Error(w, "Error listing prompt templates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	apiTemplates := []*shared.PromptTemplate{}
+	for _, template := range templates {
+		apiTemplates = append(apiTemplates, template.ToApi())
+	}
+
+	bytes, err := json.Marshal(apiTemplates)
+
+	if err != nil {
+		log.Printf("Error marshalling prompt templates: %v\n", err)
+		http.Error(w, "Error marshalling prompt templates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+	log.Println("Successfully processed request for ListPromptTemplatesHandler")
+}
+
+func CreatePromptTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for CreatePromptTemplateHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	if auth.User.IsTrial {
+		writeApiError(w, shared.ApiError{
+			Type:   shared.ApiErrorTypeTrialActionNotAllowed,
+			Status: http.StatusForbidden,
+			Msg:    "Anonymous trial user can't create org prompt templates",
+		})
+		return
+	}
+
+	var req shared.CreatePromptTemplateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !shared.IsValidPromptTemplateName(req.Name) {
+		log.Printf("Invalid prompt template name: %v\n", req.Name)
+		http.Error(w, "Invalid prompt template name: "+req.Name, http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Body) == "" {
+		log.Println("Prompt template body is empty")
+		http.Error(w, "Prompt template body is empty", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := db.GetPromptTemplateByName(auth.OrgId, req.Name)
+
+	if err != nil {
+		log.Printf("Error getting prompt template: %v\n", err)
+		http.Error(w, "Error getting prompt template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if existing != nil {
+		log.Printf("Prompt template already exists: %v\n", req.Name)
+		http.Error(w, "Prompt template already exists: "+req.Name, http.StatusBadRequest)
+		return
+	}
+
+	template := &db.PromptTemplate{
+		OrgId:       auth.OrgId,
+		OwnerId:     auth.User.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		Body:        req.Body,
+	}
+
+	err = db.CreatePromptTemplate(template)
+
+	if err != nil {
+		log.Printf("Error creating prompt template: %v\n", err)
+		http.Error(w, "Error creating prompt template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	bytes, err := json.Marshal(template.ToApi())
+
+	if err != nil {
+		log.Printf("Error marshalling prompt template: %v\n", err)
+		http.Error(w, "Error marshalling prompt template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(bytes)
+	log.Println("Successfully created prompt template")
+}
+
+func UpdatePromptTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for UpdatePromptTemplateHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	templateId := vars["templateId"]
+
+	template := authorizePromptTemplate(w, templateId, auth)
+	if template == nil {
+		return
+	}
+
+	var req shared.UpdatePromptTemplateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		log.Printf("Error unmarshalling request: %v\n", err)
+		http.Error(w, "Error unmarshalling request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.Body) == "" {
+		log.Println("Prompt template body is empty")
+		http.Error(w, "Prompt template body is empty", http.StatusBadRequest)
+		return
+	}
+
+	err = db.UpdatePromptTemplate(auth.OrgId, template.Id, req.Description, req.Body)
+
+	if err != nil {
+		log.Printf("Error updating prompt template: %v\n", err)
+		http.Error(w, "Error updating prompt template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully updated prompt template")
+}
+
+func DeletePromptTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	log.Println("Received a request for DeletePromptTemplateHandler")
+	auth := authenticate(w, r, true)
+	if auth == nil {
+		return
+	}
+
+	vars := mux.Vars(r)
+	templateId := vars["templateId"]
+
+	template := authorizePromptTemplate(w, templateId, auth)
+	if template == nil {
+		return
+	}
+
+	err := db.DeletePromptTemplate(auth.OrgId, template.Id)
+
+	if err != nil {
+		log.Printf("Error deleting prompt template: %v\n", err)
+		http.Error(w, "Error deleting prompt template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Println("Successfully deleted prompt template")
+}
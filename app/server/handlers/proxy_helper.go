@@ -47,6 +47,9 @@ func proxyActivePlanMethod(w http.ResponseWriter, r *http.Request, planId, branc
 		log.Printf("Forwarding request to %s\n", modelStream.InternalIp)
 		proxyUrl := fmt.Sprintf("http://%s:%s/plans/%s/%s/%s", modelStream.InternalIp, os.Getenv("PORT"), planId, branch, method)
 		proxyUrl += "?proxy=true"
+		if r.URL.RawQuery != "" {
+			proxyUrl += "&" + r.URL.RawQuery
+		}
 
 		log.Printf("Proxy url: %s\n", proxyUrl)
 		proxyRequest(w, r, proxyUrl)
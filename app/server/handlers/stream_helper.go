@@ -8,12 +8,43 @@ import (
 	"plandex-server/db"
 	modelPlan "plandex-server/model/plan"
 	"plandex-server/types"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/plandex/plandex/shared"
 )
 
-func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId, branch string, isConnect bool) {
+type streamWriter struct {
+	w   http.ResponseWriter
+	sse bool
+	mu  sync.Mutex
+}
+
+// clients that don't send the protocol header (older CLIs) get the legacy separator framing
+func newStreamWriter(w http.ResponseWriter, r *http.Request) *streamWriter {
+	version, _ := strconv.Atoi(r.Header.Get(shared.STREAM_PROTOCOL_HEADER))
+
+	sw := &streamWriter{
+		w:   w,
+		sse: version >= shared.STREAM_PROTOCOL_VERSION,
+	}
+
+	if sw.sse {
+		w.Header().Set(shared.STREAM_PROTOCOL_HEADER, strconv.Itoa(shared.STREAM_PROTOCOL_VERSION))
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+	} else {
+		w.Header().Set("Transfer-Encoding", "chunked")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	return sw
+}
+
+func startResponseStream(w http.ResponseWriter, r *http.Request, auth *types.ServerAuth, planId, branch string, isConnect, readOnly bool) {
 	log.Println("Response stream manager: starting plan stream")
 
 	active := modelPlan.GetActivePlan(planId, branch)
@@ -24,8 +55,7 @@ func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId,
 		return
 	}
 
-	w.Header().Set("Transfer-Encoding", "chunked")
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	sw := newStreamWriter(w, r)
 
 	// send initial message to client
 	msg := shared.StreamMessage{
@@ -40,26 +70,55 @@ func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId,
 	}
 
 	log.Println("Response stream manager: sending initial message")
-	err = sendStreamMessage(w, string(bytes))
+	err = sw.send(0, string(bytes))
 	if err != nil {
 		log.Println("Response stream manager: error sending initial message:", err)
 		return
 	}
 
-	if isConnect {
+	// a reconnecting client sends the seq of the last message it received so missed messages can be replayed
+	lastSeq, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+
+	var subscriptionId string
+	var ch chan types.StreamEvent
+	replaying := false
+
+	if isConnect && lastSeq > 0 {
+		subscriptionId, ch, replaying = modelPlan.SubscribePlanFrom(planId, branch, lastSeq)
+		if replaying {
+			log.Printf("Response stream manager: replaying stream from seq %d\n", lastSeq)
+		} else {
+			log.Printf("Response stream manager: can't replay from seq %d -- sending connect snapshot\n", lastSeq)
+		}
+	}
+
+	if isConnect && !replaying {
 		time.Sleep(100 * time.Millisecond)
-		err = initConnectActive(auth, planId, branch, w)
+		var snapshotSeq int
+		snapshotSeq, err = initConnectActive(auth, planId, branch, readOnly, sw)
 
 		if err != nil {
 			log.Println("Response stream manager: error initializing connection to active plan:", err)
 			return
 		}
+
+		// pick up live messages right after the snapshot
+		subscriptionId, ch, _ = modelPlan.SubscribePlanFrom(planId, branch, snapshotSeq)
+	} else if !isConnect {
+		subscriptionId, ch = modelPlan.SubscribePlan(planId, branch)
 	}
 
-	subscriptionId, ch := modelPlan.SubscribePlan(planId, branch)
+	active.SetWatcher(subscriptionId, &shared.StreamWatcher{
+		UserId:   auth.User.Id,
+		UserName: auth.User.Name,
+		ReadOnly: readOnly,
+	})
+	streamWatchers(active)
+
 	defer func() {
 		log.Println("Response stream manager: client stream closed")
 		modelPlan.UnsubscribePlan(planId, branch, subscriptionId)
+		streamWatchers(active)
 	}()
 
 	if isConnect {
@@ -68,14 +127,25 @@ func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId,
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	heartbeat := time.NewTicker(shared.STREAM_HEARTBEAT_INTERVAL)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case <-active.Ctx.Done():
 			log.Println("Response stream manager: context done")
 			return
-		case msg := <-ch:
-			// log.Println("Response stream manager: sending message:", msg)
-			err = sendStreamMessage(w, msg)
+		case <-r.Context().Done():
+			log.Println("Response stream manager: client disconnected")
+			return
+		case <-heartbeat.C:
+			err = sw.heartbeat()
+			if err != nil {
+				return
+			}
+		case evt := <-ch:
+			// log.Println("Response stream manager: sending message:", evt.Msg)
+			err = sw.send(evt.Seq, evt.Msg)
 			if err != nil {
 				return
 			}
@@ -84,32 +154,85 @@ func startResponseStream(w http.ResponseWriter, auth *types.ServerAuth, planId,
 
 }
 
-func sendStreamMessage(w http.ResponseWriter, msg string) error {
-	bytes := []byte(msg + shared.STREAM_MESSAGE_SEPARATOR)
+// lets every connected client know who else is following the stream
+func streamWatchers(active *types.ActivePlan) {
+	if active.Ctx.Err() != nil {
+		return
+	}
+
+	active.Stream(shared.StreamMessage{
+		Type:     shared.StreamMessageWatchers,
+		Watchers: active.Watchers(),
+	})
+}
+
+// seq is 0 for per-connection messages (start, connect snapshot) that aren't part of the plan's buffered stream
+func (sw *streamWriter) send(seq int, msg string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	var bytes []byte
+	if sw.sse {
+		bytes = []byte(formatSSEEvent(seq, "message", msg))
+	} else {
+		bytes = []byte(msg + shared.STREAM_MESSAGE_SEPARATOR)
+	}
 
 	// log.Printf("Response stream manager: writing message to client: %s\n", msg)
 
-	_, err := w.Write(bytes)
+	return sw.write(bytes)
+}
+
+// heartbeats are only sent on the SSE protocol -- legacy clients don't know how to handle them
+func (sw *streamWriter) heartbeat() error {
+	if !sw.sse {
+		return nil
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	return sw.write([]byte(formatSSEEvent(0, string(shared.StreamMessageHeartbeat), "{}")))
+}
+
+func (sw *streamWriter) write(bytes []byte) error {
+	_, err := sw.w.Write(bytes)
 	if err != nil {
 		log.Printf("Response stream manager: error writing to client: %v\n", err)
 		return err
-	} else if flusher, ok := w.(http.Flusher); ok {
+	} else if flusher, ok := sw.w.(http.Flusher); ok {
 		flusher.Flush()
 	}
 	return nil
 }
 
-func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.ResponseWriter) error {
+func formatSSEEvent(seq int, event, data string) string {
+	var sb strings.Builder
+	if seq > 0 {
+		sb.WriteString(fmt.Sprintf("id: %d\n", seq))
+	}
+	sb.WriteString(fmt.Sprintf("event: %s\n", event))
+	// data can't contain raw newlines in SSE, so each line gets its own data field
+	for _, line := range strings.Split(data, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// returns the seq of the last buffered stream message at the time of the snapshot
+func initConnectActive(auth *types.ServerAuth, planId, branch string, readOnly bool, sw *streamWriter) (int, error) {
 	log.Println("Response stream manager: initializing connection to active plan")
 
 	active := modelPlan.GetActivePlan(planId, branch)
 
 	if active == nil {
-		return fmt.Errorf("active plan not found for plan ID %s on branch %s", planId, branch)
+		return 0, fmt.Errorf("active plan not found for plan ID %s on branch %s", planId, branch)
 	}
 
 	msg := shared.StreamMessage{
-		Type: shared.StreamMessageConnectActive,
+		Type:     shared.StreamMessageConnectActive,
+		ReadOnly: readOnly,
 	}
 
 	if active.Prompt != "" && !active.BuildOnly {
@@ -123,7 +246,7 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 	if len(active.StoredReplyIds) > 0 {
 		convo, err := db.GetPlanConvo(auth.OrgId, active.Id)
 		if err != nil {
-			return fmt.Errorf("error getting plan convo: %v", err)
+			return 0, fmt.Errorf("error getting plan convo: %v", err)
 		}
 
 		convoMsgById := map[string]*db.ConvoMessage{}
@@ -138,25 +261,31 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 		}
 	}
 
-	if active.CurrentReplyContent != "" {
-		msg.InitReplies = append(msg.InitReplies, active.CurrentReplyContent)
+	// the seq is taken along with the reply content so that chunks already in the snapshot aren't sent again after it
+	replyContent, snapshotSeq := active.ReplySnapshot()
+	msg.Seq = snapshotSeq
+
+	if replyContent != "" {
+		msg.InitReplies = append(msg.InitReplies, replyContent)
 	}
 
 	if active.MissingFilePath != "" {
 		msg.MissingFilePath = active.MissingFilePath
 	}
 
+	msg.AutoContinue = active.AutoContinue
+
 	bytes, err := json.Marshal(msg)
 
 	if err != nil {
-		return fmt.Errorf("error marshalling message: %v", err)
+		return 0, fmt.Errorf("error marshalling message: %v", err)
 	}
 
 	log.Println("Response stream manager: sending connect message")
-	err = sendStreamMessage(w, string(bytes))
+	err = sw.send(snapshotSeq, string(bytes))
 
 	if err != nil {
-		return fmt.Errorf("error sending connect message: %v", err)
+		return 0, fmt.Errorf("error sending connect message: %v", err)
 	}
 
 	// if we're connecting to an active stream and there are active builds, send initial build info
@@ -184,18 +313,18 @@ func initConnectActive(auth *types.ServerAuth, planId, branch string, w http.Res
 			bytes, err := json.Marshal(msg)
 
 			if err != nil {
-				return fmt.Errorf("error marshalling message: %v", err)
+				return 0, fmt.Errorf("error marshalling message: %v", err)
 			}
 
-			err = sendStreamMessage(w, string(bytes))
+			err = sw.send(0, string(bytes))
 
 			if err != nil {
-				return fmt.Errorf("error sending message: %v", err)
+				return 0, fmt.Errorf("error sending message: %v", err)
 			}
 
 		}
 
 	}
 
-	return nil
+	return snapshotSeq, nil
 }
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+func TestFormatSSEEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		seq   int
+		event string
+		data  string
+		want  string
+	}{
+		{
+			name:  "message",
+			seq:   7,
+			event: "message",
+			data:  `{"type":"reply"}`,
+			want:  "id: 7\nevent: message\ndata: {\"type\":\"reply\"}\n\n",
+		},
+		{
+			// messages sent before any are buffered, like heartbeats, have no seq for a client to resume from
+			name:  "no seq",
+			seq:   0,
+			event: "heartbeat",
+			data:  "{}",
+			want:  "event: heartbeat\ndata: {}\n\n",
+		},
+		{
+			name:  "multiline data",
+			seq:   8,
+			event: "message",
+			data:  "line one\nline two",
+			want:  "id: 8\nevent: message\ndata: line one\ndata: line two\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := formatSSEEvent(tt.seq, tt.event, tt.data); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStreamWriterFraming(t *testing.T) {
+	msg := `{"type":"reply"}`
+
+	tests := []struct {
+		name            string
+		protocolVersion string
+		want            string
+		wantContentType string
+	}{
+		{
+			name:            "v2",
+			protocolVersion: strconv.Itoa(shared.STREAM_PROTOCOL_VERSION),
+			want:            "id: 3\nevent: message\ndata: " + msg + "\n\n",
+			wantContentType: "text/event-stream",
+		},
+		{
+			name:            "legacy",
+			want:            msg + shared.STREAM_MESSAGE_SEPARATOR,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.protocolVersion != "" {
+			r.Header.Set(shared.STREAM_PROTOCOL_HEADER, tt.protocolVersion)
+		}
+		w := httptest.NewRecorder()
+
+		sw := newStreamWriter(w, r)
+		if err := sw.send(3, msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := sw.heartbeat(); err != nil {
+			t.Fatal(err)
+		}
+
+		// heartbeats only go to v2 clients
+		want := tt.want
+		if sw.sse {
+			want += formatSSEEvent(0, string(shared.StreamMessageHeartbeat), "{}")
+		}
+
+		if got := w.Body.String(); got != want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, want)
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.wantContentType {
+			t.Errorf("%s: content type = %q, want %q", tt.name, got, tt.wantContentType)
+		}
+	}
+}
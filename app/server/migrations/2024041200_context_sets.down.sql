@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS context_set_versions;
+DROP TABLE IF EXISTS context_sets;
\ No newline at end of file
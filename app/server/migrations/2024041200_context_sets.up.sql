@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS context_sets (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  org_id UUID NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+  project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name VARCHAR(255) NOT NULL,
+  version INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+CREATE TRIGGER update_context_sets_modtime BEFORE UPDATE ON context_sets FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE UNIQUE INDEX context_sets_project_name_idx ON context_sets(project_id, name);
+
+CREATE TABLE IF NOT EXISTS context_set_versions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  context_set_id UUID NOT NULL REFERENCES context_sets(id) ON DELETE CASCADE,
+  owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  version INTEGER NOT NULL,
+  refs TEXT NOT NULL,
+  summary TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX context_set_versions_set_version_idx ON context_set_versions(context_set_id, version);
@@ -6,41 +6,135 @@ import (
 	"strings"
 
 	"github.com/plandex/plandex/shared"
+	"github.com/sashabaranov/go-openai"
 )
 
-func FormatModelContext(context []*db.Context) (string, int, error) {
+func FormatModelContext(context []*db.Context, imageSupport bool) (string, int, error) {
 	var contextMessages []string
 	var numTokens int
 	for _, part := range context {
-		var message string
-		var fmtStr string
-		var args []any
-
-		if part.ContextType == shared.ContextDirectoryTreeType {
-			fmtStr = "\n\n- %s | directory tree:\n\n```\n%s\n```"
-			args = append(args, part.FilePath, part.Body)
-		} else if part.ContextType == shared.ContextFileType {
-			fmtStr = "\n\n- %s:\n\n```\n%s\n```"
-			args = append(args, part.FilePath, part.Body)
-		} else if part.Url != "" {
-			fmtStr = "\n\n- %s:\n\n```\n%s\n```"
-			args = append(args, part.Url, part.Body)
+		message, numPartTokens, err := formatModelContextPart(part, imageSupport)
+		if err != nil {
+			return "", 0, err
+		}
+
+		numTokens += numPartTokens
+		contextMessages = append(contextMessages, message)
+	}
+	return strings.Join(contextMessages, "\n"), numTokens, nil
+}
+
+func formatModelContextPart(part *db.Context, imageSupport bool) (string, int, error) {
+	var fmtStr string
+	var args []any
+
+	if part.ContextType == shared.ContextImageType {
+		// image bodies are sent separately as image parts, so only a reference goes in the text
+		var note string
+		if imageSupport {
+			fmtStr = "\n\n- %s | image (attached below)%s"
 		} else {
-			fmtStr = "\n\n- content%s:\n\n```\n%s\n```"
-			args = append(args, part.Name, part.Body)
+			fmtStr = "\n\n- %s | image%s"
+			note = " — not shown because the current planner model doesn't support images"
 		}
 
-		numContextTokens, err := shared.GetNumTokens(fmt.Sprintf(fmtStr, ""))
+		message := fmt.Sprintf(fmtStr, part.Name, note)
+		numTokens, err := shared.GetNumTokens(message)
 		if err != nil {
 			err = fmt.Errorf("failed to get the number of tokens in the context: %v", err)
 			return "", 0, err
 		}
 
-		numTokens += part.NumTokens + numContextTokens
+		return message, numTokens, nil
+	} else if part.ContextType == shared.ContextFileType && part.Outline {
+		// the user loaded only the outline; they're prompted to load the full file if it's written to
+		fmtStr = "\n\n- %s | outline (function bodies aren't shown; the full file is loaded if you make changes to it):\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Body)
+	} else if part.Outlined {
+		fmtStr = "\n\n- %s | outline (function bodies aren't shown to fit the context budget):\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Body)
+	} else if part.ContextType == shared.ContextRepoMapType {
+		fmtStr = "\n\n- %s | repo map (exported functions, types, and methods by file; the most referenced are kept if the map is cut to fit its budget):\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Body)
+	} else if part.ContextType == shared.ContextDirectoryTreeType {
+		fmtStr = "\n\n- %s | directory tree:\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Body)
+	} else if part.ContextType == shared.ContextSymbolType {
+		// only the symbol is shown; the full file is kept for builds
+		fmtStr = "\n\n- %s | symbol %s (the rest of the file isn't shown):\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Symbol, part.Body)
+	} else if part.ContextType == shared.ContextGitDiffType {
+		fmtStr = "\n\n- git diff against %s:\n\n```\n%s\n```"
+		args = append(args, part.GitRef, part.Body)
+	} else if part.ContextType == shared.ContextGitCommitType {
+		fmtStr = "\n\n- git commit %s:\n\n```\n%s\n```"
+		args = append(args, part.GitRef, part.Body)
+	} else if part.ContextType == shared.ContextGitChangesType {
+		fmtStr = "\n\n- files changed since %s:\n\n```\n%s\n```"
+		args = append(args, part.GitRef, part.Body)
+	} else if part.ContextType == shared.ContextExecType {
+		fmtStr = "\n\n- output of command `%s`:\n\n```\n%s\n```"
+		args = append(args, part.Command, part.Body)
+	} else if part.ContextType == shared.ContextFileType && part.StartLine > 0 {
+		fmtStr = "\n\n- %s | lines %d-%d (the rest of the file isn't shown):\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.StartLine, part.EndLine, part.Body)
+	} else if part.ContextType == shared.ContextFileType {
+		fmtStr = "\n\n- %s:\n\n```\n%s\n```"
+		args = append(args, part.FilePath, part.Body)
+	} else if part.Url != "" {
+		fmtStr = "\n\n- %s:\n\n```\n%s\n```"
+		args = append(args, part.Url, part.Body)
+	} else {
+		fmtStr = "\n\n- content%s:\n\n```\n%s\n```"
+		args = append(args, part.Name, part.Body)
+	}
+
+	numContextTokens, err := shared.GetNumTokens(fmt.Sprintf(fmtStr, ""))
+	if err != nil {
+		err = fmt.Errorf("failed to get the number of tokens in the context: %v", err)
+		return "", 0, err
+	}
 
-		message = fmt.Sprintf(fmtStr, args...)
+	return fmt.Sprintf(fmtStr, args...), part.NumTokens + numContextTokens, nil
+}
 
-		contextMessages = append(contextMessages, message)
+// FormatModelContextImages builds a user message with any image contexts as image parts, along with the tokens it uses. It returns nil if there are no images in context.
+func FormatModelContextImages(context []*db.Context) (*openai.ChatCompletionMessage, int) {
+	parts := []openai.ChatMessagePart{
+		{
+			Type: openai.ChatMessagePartTypeText,
+			Text: "Images loaded into context:",
+		},
 	}
-	return strings.Join(contextMessages, "\n"), numTokens, nil
+	numTokens := 0
+
+	for _, part := range context {
+		if part.ContextType != shared.ContextImageType {
+			continue
+		}
+
+		parts = append(parts,
+			openai.ChatMessagePart{
+				Type: openai.ChatMessagePartTypeText,
+				Text: part.Name,
+			},
+			openai.ChatMessagePart{
+				Type: openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{
+					URL:    shared.ImageDataUrl(part.FilePath, part.Body),
+					Detail: openai.ImageURLDetailHigh,
+				},
+			},
+		)
+		numTokens += part.NumTokens
+	}
+
+	if len(parts) == 1 {
+		return nil, 0
+	}
+
+	return &openai.ChatCompletionMessage{
+		Role:         openai.ChatMessageRoleUser,
+		MultiContent: parts,
+	}, numTokens
 }
@@ -0,0 +1,154 @@
+package lib
+
+import (
+	"fmt"
+	"plandex-server/db"
+	"sort"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// FitContextToBudget returns the context to send to the planner so that it fits in budget tokens, along with a report of what was trimmed. Unpinned context is trimmed from the lowest priority up: within a priority, the largest files are outlined first, then context is left out until it fits. Pinned context is never trimmed, so it's an error if pinned context alone doesn't fit.
+func FitContextToBudget(contexts []*db.Context, budget int, imageSupport bool) ([]*db.Context, *shared.ContextBudgetReport, error) {
+	report := &shared.ContextBudgetReport{
+		Budget:     budget,
+		StatusById: map[string]shared.ContextBudgetStatus{},
+	}
+
+	tokensById := map[string]int{}
+	total := 0
+	for _, context := range contexts {
+		numTokens, err := getModelContextTokens(context, imageSupport)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tokensById[context.Id] = numTokens
+		total += numTokens
+		report.StatusById[context.Id] = shared.ContextBudgetIncluded
+	}
+
+	if total <= budget {
+		report.NumTokens = total
+		return contexts, report, nil
+	}
+
+	outlinedById := map[string]*db.Context{}
+
+	for _, priority := range shared.ContextPriorities {
+		if total <= budget {
+			break
+		}
+
+		var candidates []*db.Context
+		for _, context := range contexts {
+			if !context.Pinned && context.Priority == priority {
+				candidates = append(candidates, context)
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return tokensById[candidates[i].Id] > tokensById[candidates[j].Id]
+		})
+
+		for _, context := range candidates {
+			if total <= budget {
+				break
+			}
+
+			outlined, err := getOutlinedContext(context)
+			if err != nil {
+				return nil, nil, err
+			}
+			if outlined == nil {
+				continue
+			}
+
+			numTokens, err := getModelContextTokens(outlined, imageSupport)
+			if err != nil {
+				return nil, nil, err
+			}
+			if numTokens >= tokensById[context.Id] {
+				continue
+			}
+
+			total -= tokensById[context.Id] - numTokens
+			tokensById[context.Id] = numTokens
+			outlinedById[context.Id] = outlined
+			report.StatusById[context.Id] = shared.ContextBudgetOutlined
+		}
+
+		for _, context := range candidates {
+			if total <= budget {
+				break
+			}
+
+			total -= tokensById[context.Id]
+			delete(outlinedById, context.Id)
+			report.StatusById[context.Id] = shared.ContextBudgetDropped
+		}
+	}
+
+	if total > budget {
+		return nil, nil, fmt.Errorf("pinned context uses %d 🪙, which is over the planner's context budget of %d 🪙. Unpin or remove some context, or switch to a model with a larger context window", total, budget)
+	}
+
+	var res []*db.Context
+	for _, context := range contexts {
+		switch report.StatusById[context.Id] {
+		case shared.ContextBudgetIncluded:
+			res = append(res, context)
+		case shared.ContextBudgetOutlined:
+			res = append(res, outlinedById[context.Id])
+			report.Outlined = append(report.Outlined, context.Name)
+		case shared.ContextBudgetDropped:
+			report.Dropped = append(report.Dropped, context.Name)
+		}
+	}
+
+	report.NumTokens = total
+
+	return res, report, nil
+}
+
+func getModelContextTokens(context *db.Context, imageSupport bool) (int, error) {
+	_, numTokens, err := formatModelContextPart(context, imageSupport)
+	if err != nil {
+		return 0, err
+	}
+
+	// image bodies are sent in a separate message
+	if context.ContextType == shared.ContextImageType && imageSupport {
+		numTokens += context.NumTokens
+	}
+
+	return numTokens, nil
+}
+
+// getOutlinedContext returns a copy of a file or symbol context with its body reduced to an outline, or nil if it can't be outlined
+func getOutlinedContext(context *db.Context) (*db.Context, error) {
+	if context.ContextType != shared.ContextFileType && context.ContextType != shared.ContextSymbolType {
+		return nil, nil
+	}
+
+	if context.Outline {
+		// already an outline
+		return nil, nil
+	}
+
+	outline, ok := shared.GetOutline(context.FilePath, context.Body)
+	if !ok {
+		return nil, nil
+	}
+
+	numTokens, err := shared.GetNumTokens(outline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the number of tokens in the outline of %s: %v", context.Name, err)
+	}
+
+	outlined := *context
+	outlined.Body = outline
+	outlined.NumTokens = numTokens
+	outlined.Outlined = true
+
+	return &outlined, nil
+}
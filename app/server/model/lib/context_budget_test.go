@@ -0,0 +1,295 @@
+package lib
+
+import (
+	"plandex-server/db"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// the tokenizer downloads its encoding the first time it's used
+func requireTokenizer(t *testing.T) {
+	t.Helper()
+
+	if _, err := shared.GetNumTokens("test"); err != nil {
+		t.Skipf("tokenizer isn't available: %v", err)
+	}
+}
+
+func noteContext(t *testing.T, id string, words int, priority shared.ContextPriority, pinned bool) (*db.Context, int) {
+	t.Helper()
+	requireTokenizer(t)
+
+	body := strings.Repeat("word ", words)
+	bodyTokens, err := shared.GetNumTokens(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context := &db.Context{
+		Id:          id,
+		Name:        id,
+		ContextType: shared.ContextNoteType,
+		Body:        body,
+		NumTokens:   bodyTokens,
+		Priority:    priority,
+		Pinned:      pinned,
+	}
+
+	numTokens, err := getModelContextTokens(context, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return context, numTokens
+}
+
+func contextIds(contexts []*db.Context) []string {
+	var ids []string
+	for _, context := range contexts {
+		ids = append(ids, context.Id)
+	}
+	return ids
+}
+
+func TestFitContextToBudgetUnderBudget(t *testing.T) {
+	a, aTokens := noteContext(t, "a", 50, shared.ContextPriorityNormal, false)
+	b, bTokens := noteContext(t, "b", 50, shared.ContextPriorityLow, false)
+
+	res, report, err := FitContextToBudget([]*db.Context{a, b}, aTokens+bTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contextIds(res); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("got %v, want all context", got)
+	}
+	if report.Trimmed() {
+		t.Errorf("nothing should be trimmed: %+v", report)
+	}
+	if report.NumTokens != aTokens+bTokens {
+		t.Errorf("NumTokens = %d, want %d", report.NumTokens, aTokens+bTokens)
+	}
+}
+
+func TestFitContextToBudgetDropsLowestPriorityFirst(t *testing.T) {
+	high, highTokens := noteContext(t, "high", 100, shared.ContextPriorityHigh, false)
+	normal, normalTokens := noteContext(t, "normal", 100, shared.ContextPriorityNormal, false)
+	low, _ := noteContext(t, "low", 100, shared.ContextPriorityLow, false)
+
+	res, report, err := FitContextToBudget([]*db.Context{high, normal, low}, highTokens+normalTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contextIds(res); !reflect.DeepEqual(got, []string{"high", "normal"}) {
+		t.Errorf("got %v, want [high normal]", got)
+	}
+	if !reflect.DeepEqual(report.Dropped, []string{"low"}) {
+		t.Errorf("Dropped = %v, want [low]", report.Dropped)
+	}
+	if report.StatusById["low"] != shared.ContextBudgetDropped || report.StatusById["high"] != shared.ContextBudgetIncluded {
+		t.Errorf("unexpected statuses: %v", report.StatusById)
+	}
+}
+
+func TestFitContextToBudgetDropsLargestFirstWithinPriority(t *testing.T) {
+	small, smallTokens := noteContext(t, "small", 20, shared.ContextPriorityNormal, false)
+	large, _ := noteContext(t, "large", 200, shared.ContextPriorityNormal, false)
+	medium, mediumTokens := noteContext(t, "medium", 80, shared.ContextPriorityNormal, false)
+
+	res, report, err := FitContextToBudget([]*db.Context{small, large, medium}, smallTokens+mediumTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contextIds(res); !reflect.DeepEqual(got, []string{"small", "medium"}) {
+		t.Errorf("got %v, want [small medium]", got)
+	}
+	if !reflect.DeepEqual(report.Dropped, []string{"large"}) {
+		t.Errorf("Dropped = %v, want [large]", report.Dropped)
+	}
+}
+
+func TestFitContextToBudgetTieKeepsOrder(t *testing.T) {
+	// same priority and size: the sort is stable, so the earlier context is dropped first
+	first, _ := noteContext(t, "first", 100, shared.ContextPriorityNormal, false)
+	second, secondTokens := noteContext(t, "second", 100, shared.ContextPriorityNormal, false)
+
+	res, _, err := FitContextToBudget([]*db.Context{first, second}, secondTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := contextIds(res); !reflect.DeepEqual(got, []string{"second"}) {
+		t.Errorf("got %v, want [second]", got)
+	}
+}
+
+func TestFitContextToBudgetNeverDropsPinned(t *testing.T) {
+	pinned, pinnedTokens := noteContext(t, "pinned", 200, shared.ContextPriorityLow, true)
+	other, _ := noteContext(t, "other", 20, shared.ContextPriorityHigh, false)
+
+	res, _, err := FitContextToBudget([]*db.Context{pinned, other}, pinnedTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contextIds(res); !reflect.DeepEqual(got, []string{"pinned"}) {
+		t.Errorf("got %v, want [pinned]", got)
+	}
+
+	_, _, err = FitContextToBudget([]*db.Context{pinned, other}, pinnedTokens-1, false)
+	if err == nil {
+		t.Errorf("expected an error when pinned context is over budget")
+	}
+}
+
+func TestFitContextToBudgetOutlinesBeforeDropping(t *testing.T) {
+	requireTokenizer(t)
+
+	body := "package main\n\nfunc main() {\n" + strings.Repeat("\tprintln(\"hello world\")\n", 50) + "}\n"
+	bodyTokens, err := shared.GetNumTokens(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := &db.Context{
+		Id:          "file",
+		Name:        "main.go",
+		ContextType: shared.ContextFileType,
+		FilePath:    "main.go",
+		Body:        body,
+		NumTokens:   bodyTokens,
+	}
+	fileTokens, err := getModelContextTokens(file, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, report, err := FitContextToBudget([]*db.Context{file}, fileTokens-1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res) != 1 || !res[0].Outlined {
+		t.Fatalf("expected the file to be outlined, got %v", res)
+	}
+	if file.Outlined || file.Body != body {
+		t.Errorf("the original context was modified")
+	}
+	if !reflect.DeepEqual(report.Outlined, []string{"main.go"}) || report.StatusById["file"] != shared.ContextBudgetOutlined {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.NumTokens >= fileTokens {
+		t.Errorf("NumTokens = %d, should be less than %d", report.NumTokens, fileTokens)
+	}
+}
+
+func TestContextBudgetReportIsStored(t *testing.T) {
+	baseDir := db.BaseDir
+	db.BaseDir = t.TempDir()
+	defer func() { db.BaseDir = baseDir }()
+
+	if err := db.InitPlan("org", "plan"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := db.GetContextBudgetReport("org", "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report != nil {
+		t.Errorf("expected no report before the first reply, got %+v", report)
+	}
+
+	a, aTokens := noteContext(t, "a", 50, shared.ContextPriorityNormal, false)
+	b, _ := noteContext(t, "b", 50, shared.ContextPriorityLow, false)
+
+	_, report, err = FitContextToBudget([]*db.Context{a, b}, aTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.ReplyId = "reply"
+
+	if err := db.StoreContextBudgetReport("org", "plan", report); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := db.GetContextBudgetReport("org", "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, report) {
+		t.Errorf("stored report = %+v, want %+v", stored, report)
+	}
+}
+
+// loading only checks pinned context against the planner's limit, so unpinned context over the limit is stored and then trimmed when prompting
+func TestUnpinnedContextOverLimitIsLoadedThenTrimmed(t *testing.T) {
+	baseDir := db.BaseDir
+	db.BaseDir = t.TempDir()
+	defer func() { db.BaseDir = baseDir }()
+
+	if err := db.InitPlan("org", "plan"); err != nil {
+		t.Fatal(err)
+	}
+
+	pinned, pinnedTokens := noteContext(t, "pinned", 20, shared.ContextPriorityLow, true)
+	normal, normalTokens := noteContext(t, "normal", 100, shared.ContextPriorityNormal, false)
+	low, _ := noteContext(t, "low", 100, shared.ContextPriorityLow, false)
+
+	for _, context := range []*db.Context{pinned, normal, low} {
+		context.OrgId = "org"
+		context.PlanId = "plan"
+		if err := db.StoreContext(context); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	maxTokens := pinnedTokens + normalTokens
+	if total := pinned.NumTokens + normal.NumTokens + low.NumTokens; total <= maxTokens {
+		t.Fatalf("context (%d 🪙) should be over the limit (%d 🪙)", total, maxTokens)
+	}
+
+	loadedPinnedTokens, err := db.GetPinnedContextTokens("org", "plan", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedPinnedTokens != pinned.NumTokens {
+		t.Errorf("pinned tokens = %d, want %d", loadedPinnedTokens, pinned.NumTokens)
+	}
+	if loadedPinnedTokens > maxTokens {
+		t.Fatalf("unpinned context shouldn't count against the limit")
+	}
+
+	// growing the pinned context past the limit is still rejected
+	updatedPinnedTokens, err := db.GetPinnedContextTokens("org", "plan", map[string]int{"pinned": maxTokens + 1, "low": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedPinnedTokens <= maxTokens {
+		t.Errorf("updated pinned tokens = %d, want over the limit (%d)", updatedPinnedTokens, maxTokens)
+	}
+
+	contexts, err := db.GetPlanContexts("org", "plan", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, report, err := FitContextToBudget(contexts, maxTokens, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := contextIds(res)
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"normal", "pinned"}) {
+		t.Errorf("got %v, want the low priority context trimmed", ids)
+	}
+	if !report.Trimmed() || report.StatusById["low"] != shared.ContextBudgetDropped {
+		t.Errorf("expected the low priority context to be reported as dropped: %+v", report)
+	}
+}
@@ -50,6 +50,8 @@ func activatePlan(client *openai.Client, plan *db.Plan, branch string, auth *typ
 
 	active.ModelStreamId = modelStream.Id
 
+	startStreamLog(auth.OrgId, active)
+
 	log.Printf("Tell: Model stream stored with ID %s for plan ID %s on branch %s\n", modelStream.Id, plan.Id, branch) // Log successful storage of model stream
 	log.Println("Model stream id:", modelStream.Id)
 
@@ -177,6 +177,7 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 	contextPart := activePlan.ContextsByPath[filePath]
 
 	var currentState string
+	var rangeStart, rangeEnd int
 	currentPlanFile, fileInCurrentPlan := currentPlan.CurrentPlanFiles.Files[filePath]
 
 	if fileInCurrentPlan {
@@ -193,11 +194,24 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 			log.Println("Context state is empty. That's bad.")
 		}
 
+		if contextPart.ContextType == shared.ContextFileType && contextPart.StartLine > 0 {
+			log.Printf("Only lines %d-%d of %s are loaded. Building against that range.\n", contextPart.StartLine, contextPart.EndLine, filePath)
+			rangeStart = contextPart.StartLine
+			rangeEnd = contextPart.EndLine
+		}
+
 		// log.Println("\n\nCurrent state:\n", currentState, "\n\n")
 	}
 
 	fileState.currentState = currentState
 
+	// the builder only sees the loaded line range, and its changes are offset back onto the full file in getPlanResult
+	promptState := currentState
+	if rangeStart > 0 {
+		promptState, _ = shared.GetLineRange(currentState, rangeStart, rangeEnd)
+		fileState.lineOffset = rangeStart - 1
+	}
+
 	if currentState == "" {
 		log.Printf("File %s not found in model context or current plan. Creating new file.\n", filePath)
 
@@ -224,7 +238,7 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 		fileState.onFinishBuildFile(planRes)
 		return
 	} else {
-		currentNumTokens, err := shared.GetNumTokens(currentState)
+		currentNumTokens, err := shared.GetNumTokens(promptState)
 
 		if err != nil {
 			log.Printf("Error getting num tokens for current state: %v\n", err)
@@ -242,7 +256,7 @@ func (fileState *activeBuildStreamFileState) buildFile() {
 
 	// log.Println("currentState:", currentState)
 
-	sysPrompt := prompts.GetBuildSysPrompt(filePath, currentState, activeBuild.FileDescription, activeBuild.FileContent)
+	sysPrompt := prompts.GetBuildSysPrompt(filePath, promptState, activeBuild.FileDescription, activeBuild.FileContent)
 
 	fileMessages := []openai.ChatCompletionMessage{
 		{
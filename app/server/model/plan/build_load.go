@@ -105,10 +105,8 @@ func (state *activeBuildStreamState) loadPendingBuilds() (map[string][]*types.Ac
 
 	UpdateActivePlan(plan.Id, branch, func(ap *types.ActivePlan) {
 		ap.Contexts = modelContext
-		for _, context := range modelContext {
-			if context.FilePath != "" {
-				ap.ContextsByPath[context.FilePath] = context
-			}
+		for path, context := range db.GetContextsByPath(modelContext) {
+			ap.ContextsByPath[path] = context
 		}
 	})
 
@@ -17,6 +17,7 @@ type planResultParams struct {
 	convoMessageId  string
 	filePath        string
 	currentState    string
+	lineOffset      int // when only a line range was shown to the builder, its line numbers start after this offset
 	fileContent     string
 	streamedChanges []*shared.StreamedChange
 }
@@ -62,6 +63,9 @@ func getPlanResult(params planResultParams) (*db.PlanFileResult, bool) {
 			endLine = streamedChange.Old.MaybeEndLine
 		}
 
+		startLine += params.lineOffset
+		endLine += params.lineOffset
+
 		if startLine < 1 {
 			startLine = 1
 		}
@@ -38,6 +38,7 @@ type activeBuildStreamFileState struct {
 	currentPlanState *shared.CurrentPlanState
 	activeBuild      *types.ActiveBuild
 	currentState     string
+	lineOffset       int
 	numRetry         int
 }
 
@@ -161,6 +162,7 @@ func (fileState *activeBuildStreamFileState) listenStream(stream *openai.ChatCom
 						convoMessageId:  build.ConvoMessageId,
 						filePath:        filePath,
 						currentState:    currentState,
+						lineOffset:      fileState.lineOffset,
 						fileContent:     activeBuild.FileContent,
 						streamedChanges: streamed.Changes,
 					},
@@ -88,16 +88,27 @@ func UpdateActivePlan(planId, branch string, fn func(*types.ActivePlan)) {
 	activePlans.Update(strings.Join([]string{planId, branch}, "|"), fn)
 }
 
-func SubscribePlan(planId, branch string) (string, chan string) {
+func SubscribePlan(planId, branch string) (string, chan types.StreamEvent) {
 	log.Printf("Subscribing to plan %s\n", planId)
 	var id string
-	var ch chan string
+	var ch chan types.StreamEvent
 	UpdateActivePlan(planId, branch, func(activePlan *types.ActivePlan) {
 		id, ch = activePlan.Subscribe()
 	})
 	return id, ch
 }
 
+func SubscribePlanFrom(planId, branch string, lastSeq int) (string, chan types.StreamEvent, bool) {
+	log.Printf("Subscribing to plan %s from seq %d\n", planId, lastSeq)
+	var id string
+	var ch chan types.StreamEvent
+	var ok bool
+	UpdateActivePlan(planId, branch, func(activePlan *types.ActivePlan) {
+		id, ch, ok = activePlan.SubscribeFrom(lastSeq)
+	})
+	return id, ch, ok
+}
+
 func UnsubscribePlan(planId, branch, subscriptionId string) {
 	log.Printf("UnsubscribePlan %s - %s - %s\n", planId, branch, subscriptionId)
 
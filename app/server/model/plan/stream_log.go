@@ -0,0 +1,89 @@
+package plan
+
+import (
+	"encoding/json"
+	"log"
+	"plandex-server/db"
+	"plandex-server/types"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// how long to keep draining messages after the active plan's context is done
+const streamLogDrainTimeout = 500 * time.Millisecond
+
+// persists every message sent on the active plan's stream so the stream can be replayed after the plan finishes
+func startStreamLog(orgId string, active *types.ActivePlan) {
+	logWriter, err := db.CreateStreamLog(orgId, active.Id, active.ModelStreamId)
+	if err != nil {
+		log.Printf("Error creating stream log for model stream %s: %v\n", active.ModelStreamId, err)
+		return
+	}
+
+	// the first entry records the prompt so that a replay can show it the same way a live connect would
+	err = logWriter.Append(&shared.StreamLogEntry{
+		Ts: time.Now(),
+		Message: &shared.StreamMessage{
+			Type:          shared.StreamMessageConnectActive,
+			ModelStreamId: active.ModelStreamId,
+			InitPrompt:    active.Prompt,
+			InitBuildOnly: active.BuildOnly,
+		},
+	})
+	if err != nil {
+		log.Printf("Error writing stream log for model stream %s: %v\n", active.ModelStreamId, err)
+	}
+
+	subscriptionId, ch, _ := active.SubscribeFrom(0)
+
+	appendEvt := func(evt types.StreamEvent) {
+		var msg shared.StreamMessage
+		err := json.Unmarshal([]byte(evt.Msg), &msg)
+		if err != nil {
+			log.Printf("Error unmarshalling stream message for stream log: %v\n", err)
+			return
+		}
+
+		// who was connected at the time isn't useful in a replay
+		if msg.Type == shared.StreamMessageWatchers {
+			return
+		}
+
+		err = logWriter.Append(&shared.StreamLogEntry{
+			Ts:      time.Now(),
+			Message: &msg,
+		})
+		if err != nil {
+			log.Printf("Error writing stream log for model stream %s: %v\n", active.ModelStreamId, err)
+		}
+	}
+
+	go func() {
+		defer func() {
+			active.Unsubscribe(subscriptionId)
+			err := logWriter.Close()
+			if err != nil {
+				log.Printf("Error closing stream log for model stream %s: %v\n", active.ModelStreamId, err)
+			}
+		}()
+
+		for {
+			select {
+			case evt := <-ch:
+				appendEvt(evt)
+			case <-active.Ctx.Done():
+				// final messages (finished, error, aborted) are often sent right before the context is canceled
+				timeout := time.After(streamLogDrainTimeout)
+				for {
+					select {
+					case evt := <-ch:
+						appendEvt(evt)
+					case <-timeout:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
@@ -116,10 +116,8 @@ func execTellPlan(
 		UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 			ap.Contexts = state.modelContext
 
-			for _, context := range state.modelContext {
-				if context.FilePath != "" {
-					ap.ContextsByPath[context.FilePath] = context
-				}
+			for path, context := range db.GetContextsByPath(state.modelContext) {
+				ap.ContextsByPath[path] = context
 			}
 		})
 	} else if missingFileResponse == "" {
@@ -147,7 +145,50 @@ func execTellPlan(
 		}
 	}
 
-	modelContextText, modelContextTokens, err := lib.FormatModelContext(state.modelContext)
+	imageSupport := state.settings.ModelSet.Planner.BaseModelConfig.HasImageSupport
+
+	var (
+		numPromptTokens int
+		promptTokens    int
+	)
+	if iteration == 0 && missingFileResponse == "" {
+		numPromptTokens, err = shared.GetNumTokens(req.Prompt)
+		if err != nil {
+			err = fmt.Errorf("error getting number of tokens in prompt: %v", err)
+			log.Println(err)
+			active.StreamDoneCh <- &shared.ApiError{
+				Type:   shared.ApiErrorTypeOther,
+				Status: http.StatusInternalServerError,
+				Msg:    "Error getting number of tokens in prompt",
+			}
+			return
+		}
+		promptTokens = prompts.PromptWrapperTokens + numPromptTokens
+	}
+
+	// context that doesn't fit alongside the system message and prompt is outlined or left out, lowest priority first
+	contextBudget := state.settings.GetPlannerEffectiveMaxTokens() - prompts.CreateSysMsgNumTokens - promptTokens
+	modelContext, budgetReport, err := lib.FitContextToBudget(state.modelContext, contextBudget, imageSupport)
+	if err != nil {
+		log.Printf("Error fitting context to budget: %v\n", err)
+		active.StreamDoneCh <- &shared.ApiError{
+			Type:   shared.ApiErrorTypeOther,
+			Status: http.StatusBadRequest,
+			Msg:    "Context doesn't fit: " + err.Error(),
+		}
+		return
+	}
+	state.contextBudget = budgetReport
+
+	if budgetReport.Trimmed() {
+		log.Printf("Context trimmed to fit budget of %d tokens | outlined: %v | dropped: %v\n", contextBudget, budgetReport.Outlined, budgetReport.Dropped)
+		active.Stream(shared.StreamMessage{
+			Type:          shared.StreamMessageContextBudget,
+			ContextBudget: budgetReport,
+		})
+	}
+
+	modelContextText, modelContextTokens, err := lib.FormatModelContext(modelContext, imageSupport)
 	if err != nil {
 		err = fmt.Errorf("error formatting model modelContext: %v", err)
 		log.Println(err)
@@ -177,23 +218,12 @@ func execTellPlan(
 		systemMessage,
 	}
 
-	var (
-		numPromptTokens int
-		promptTokens    int
-	)
-	if iteration == 0 && missingFileResponse == "" {
-		numPromptTokens, err = shared.GetNumTokens(req.Prompt)
-		if err != nil {
-			err = fmt.Errorf("error getting number of tokens in prompt: %v", err)
-			log.Println(err)
-			active.StreamDoneCh <- &shared.ApiError{
-				Type:   shared.ApiErrorTypeOther,
-				Status: http.StatusInternalServerError,
-				Msg:    "Error getting number of tokens in prompt",
-			}
-			return
+	if imageSupport {
+		imagesMessage, imageTokens := lib.FormatModelContextImages(modelContext)
+		if imagesMessage != nil {
+			state.messages = append(state.messages, *imagesMessage)
+			modelContextTokens += imageTokens
 		}
-		promptTokens = prompts.PromptWrapperTokens + numPromptTokens
 	}
 
 	state.tokensBeforeConvo = prompts.CreateSysMsgNumTokens + modelContextTokens + promptTokens
@@ -27,6 +27,7 @@ type activeTellStreamState struct {
 	iteration             int
 	replyId               string
 	modelContext          []*db.Context
+	contextBudget         *shared.ContextBudgetReport
 	convo                 []*db.ConvoMessage
 	missingFileResponse   shared.RespondMissingFileChoice
 	summaries             []*db.ConvoSummary
@@ -306,6 +307,26 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 					ap.CurrentReplyDoneCh = nil
 				})
 
+				if req.AutoContinue {
+					decision := shared.AutoContinueDecision{
+						ShouldContinue: shouldContinue && iteration < MaxAutoContinueIterations,
+						Iteration:      iteration,
+					}
+					if shouldContinue && iteration >= MaxAutoContinueIterations {
+						decision.Reason = fmt.Sprintf("reached max auto-continue iterations (%d)", MaxAutoContinueIterations)
+					} else if !shouldContinue {
+						decision.Reason = "plan is complete or can't be continued"
+					}
+					// kept so that clients connecting later get the latest decision
+					UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
+						ap.AutoContinue = &decision
+					})
+					active.Stream(shared.StreamMessage{
+						Type:         shared.StreamMessageAutoContinue,
+						AutoContinue: &decision,
+					})
+				}
+
 				if req.AutoContinue && shouldContinue && iteration < MaxAutoContinueIterations {
 					log.Println("Auto continue plan")
 					// continue plan
@@ -369,16 +390,8 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 				}
 			}
 
-			UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
-				ap.CurrentReplyContent += content
-				ap.NumTokens++
-			})
-
 			// log.Printf("Sending stream msg: %s", content)
-			active.Stream(shared.StreamMessage{
-				Type:       shared.StreamMessageReply,
-				ReplyChunk: content,
-			})
+			active.StreamReplyChunk(content)
 
 			replyParser.AddChunk(content, true)
 			parserRes := replyParser.Read()
@@ -392,10 +405,14 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 			// log.Println("files:")
 			// spew.Dump(files)
 
+			// a file loaded only as an outline needs its full body before it's changed, so it's handled like a missing file
+			contextPart := active.ContextsByPath[currentFile]
+			missingFileOutline := contextPart != nil && contextPart.Outline
+
 			// Handle file that is present in project paths but not in context
 			// Prompt user for what to do on the client side, stop the stream, and wait for user response before proceeding
 			if currentFile != "" &&
-				active.ContextsByPath[currentFile] == nil &&
+				(contextPart == nil || missingFileOutline) &&
 				req.ProjectPaths[currentFile] && !active.AllowOverwritePaths[currentFile] {
 				log.Printf("Attempting to overwrite a file that isn't in context: %s\n", currentFile)
 
@@ -420,8 +437,9 @@ func (state *activeTellStreamState) listenStream(stream *openai.ChatCompletionSt
 				log.Printf("Prompting user for missing file: %s\n", currentFile)
 
 				active.Stream(shared.StreamMessage{
-					Type:            shared.StreamMessagePromptMissingFile,
-					MissingFilePath: currentFile,
+					Type:               shared.StreamMessagePromptMissingFile,
+					MissingFilePath:    currentFile,
+					MissingFileOutline: missingFileOutline,
 				})
 
 				log.Printf("Stopping stream for missing file: %s\n", currentFile)
@@ -561,6 +579,17 @@ func (state *activeTellStreamState) storeAssistantReply() (*db.ConvoMessage, str
 		return nil, "", err
 	}
 
+	if state.contextBudget != nil {
+		// committed along with the reply so 'plandex ls' can show what the planner saw
+		state.contextBudget.ReplyId = replyId
+		err = db.StoreContextBudgetReport(currentOrgId, planId, state.contextBudget)
+
+		if err != nil {
+			log.Printf("Error storing context budget report: %v\n", err)
+			return nil, "", err
+		}
+	}
+
 	UpdateActivePlan(planId, branch, func(ap *types.ActivePlan) {
 		ap.MessageNum = num
 		ap.StoredReplyIds = append(ap.StoredReplyIds, replyId)
@@ -49,10 +49,19 @@ func routes() *mux.Router {
 	r.HandleFunc("/invites/all", handlers.ListAllInvitesHandler).Methods("GET")
 	r.HandleFunc("/invites/{inviteId}", handlers.DeleteInviteHandler).Methods("DELETE")
 
+	r.HandleFunc("/prompt_templates", handlers.ListPromptTemplatesHandler).Methods("GET")
+	r.HandleFunc("/prompt_templates", handlers.CreatePromptTemplateHandler).Methods("POST")
+	r.HandleFunc("/prompt_templates/{templateId}", handlers.UpdatePromptTemplateHandler).Methods("PUT")
+	r.HandleFunc("/prompt_templates/{templateId}", handlers.DeletePromptTemplateHandler).Methods("DELETE")
+
 	r.HandleFunc("/projects", handlers.CreateProjectHandler).Methods("POST")
 	r.HandleFunc("/projects", handlers.ListProjectsHandler).Methods("GET")
 	r.HandleFunc("/projects/{projectId}/set_plan", handlers.ProjectSetPlanHandler).Methods("PUT")
 	r.HandleFunc("/projects/{projectId}/rename", handlers.RenameProjectHandler).Methods("PUT")
+	r.HandleFunc("/projects/{projectId}/context_sets", handlers.ListContextSetsHandler).Methods("GET")
+	r.HandleFunc("/projects/{projectId}/context_sets/{name}", handlers.GetContextSetHandler).Methods("GET")
+	r.HandleFunc("/projects/{projectId}/context_sets/{name}", handlers.DeleteContextSetHandler).Methods("DELETE")
+	r.HandleFunc("/projects/{projectId}/context_sets/{name}/versions", handlers.ListContextSetVersionsHandler).Methods("GET")
 
 	r.HandleFunc("/projects/{projectId}/plans/current_branches", handlers.GetCurrentBranchByPlanIdHandler).Methods("POST")
 
@@ -75,8 +84,11 @@ func routes() *mux.Router {
 	r.HandleFunc("/plans/{planId}/{branch}/connect", handlers.ConnectPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/stop", handlers.StopPlanHandler).Methods("DELETE")
 
+	r.HandleFunc("/streams/{streamId}/log", handlers.GetStreamLogHandler).Methods("GET")
+
 	r.HandleFunc("/plans/{planId}/{branch}/current_plan", handlers.CurrentPlanHandler).Methods("GET")
 	r.HandleFunc("/plans/{planId}/{branch}/apply", handlers.ApplyPlanHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/unapply", handlers.UnapplyPlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/archive", handlers.ArchivePlanHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/reject_all", handlers.RejectAllChangesHandler).Methods("PATCH")
 	r.HandleFunc("/plans/{planId}/{branch}/reject_file", handlers.RejectFileHandler).Methods("PATCH")
@@ -85,9 +97,13 @@ func routes() *mux.Router {
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.LoadContextHandler).Methods("POST")
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.UpdateContextHandler).Methods("PUT")
 	r.HandleFunc("/plans/{planId}/{branch}/context", handlers.DeleteContextHandler).Methods("DELETE")
+	r.HandleFunc("/plans/{planId}/{branch}/context/priority", handlers.SetContextPriorityHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/context_blobs/missing", handlers.MissingContextBlobsHandler).Methods("POST")
+	r.HandleFunc("/plans/{planId}/{branch}/context_sets", handlers.SaveContextSetHandler).Methods("POST")
 
 	r.HandleFunc("/plans/{planId}/{branch}/convo", handlers.ListConvoHandler).Methods("GET")
 	r.HandleFunc("/plans/{planId}/{branch}/rewind", handlers.RewindPlanHandler).Methods("PATCH")
+	r.HandleFunc("/plans/{planId}/{branch}/retry", handlers.RetryPlanHandler).Methods("POST")
 	r.HandleFunc("/plans/{planId}/{branch}/logs", handlers.ListLogsHandler).Methods("GET")
 
 	r.HandleFunc("/plans/{planId}/branches", handlers.ListBranchesHandler).Methods("GET")
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"plandex-server/db"
+	"sort"
 	"sync"
 	"time"
 
@@ -27,13 +28,24 @@ type ActiveBuild struct {
 	Error             error
 }
 
+// max number of stream messages kept per active plan for replay on reconnect
+const MaxStreamBufferSize = 10000
+
+type StreamEvent struct {
+	Seq int
+	Msg string
+}
+
 type subscription struct {
-	ch           chan string
+	ch           chan StreamEvent
 	ctx          context.Context
 	cancelFn     context.CancelFunc
 	mu           sync.Mutex // Protects the messageQueue
-	messageQueue []string
+	messageQueue []StreamEvent
 	cond         *sync.Cond // Used to wait for and signal new messages
+	watcher      *shared.StreamWatcher
+	// messages up to this seq were already sent to the subscriber, so they're skipped if they're fanned out late
+	afterSeq int
 }
 
 type ActivePlan struct {
@@ -66,7 +78,11 @@ type ActivePlan struct {
 	AllowOverwritePaths     map[string]bool
 	SkippedPaths            map[string]bool
 	StoredReplyIds          []string
-	streamCh                chan string
+	AutoContinue            *shared.AutoContinueDecision
+	streamCh                chan StreamEvent
+	streamMu                sync.Mutex
+	streamSeq               int
+	streamBuffer            []StreamEvent
 	subscriptions           map[string]*subscription
 	subscriptionMu          sync.Mutex
 }
@@ -100,7 +116,7 @@ func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
 		MissingFileResponseCh: make(chan shared.RespondMissingFileChoice),
 		AllowOverwritePaths:   map[string]bool{},
 		SkippedPaths:          map[string]bool{},
-		streamCh:              make(chan string),
+		streamCh:              make(chan StreamEvent),
 		subscriptions:         map[string]*subscription{},
 		subscriptionMu:        sync.Mutex{},
 	}
@@ -116,15 +132,19 @@ func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
 			select {
 			case <-active.Ctx.Done():
 				return
-			case msg := <-active.streamCh:
-				var subscriptions map[string]*subscription
+			case evt := <-active.streamCh:
+				// buffer and fan out under the same lock so that SubscribeFrom never misses or duplicates a message
 				active.subscriptionMu.Lock()
-				subscriptions = active.subscriptions
-				active.subscriptionMu.Unlock()
-				for _, sub := range subscriptions {
-					sub.enqueueMessage(msg)
+				active.streamBuffer = append(active.streamBuffer, evt)
+				if len(active.streamBuffer) > MaxStreamBufferSize {
+					active.streamBuffer = active.streamBuffer[len(active.streamBuffer)-MaxStreamBufferSize:]
 				}
-
+				for _, sub := range active.subscriptions {
+					if evt.Seq > sub.afterSeq {
+						sub.enqueueMessage(evt)
+					}
+				}
+				active.subscriptionMu.Unlock()
 			}
 		}
 	}()
@@ -133,8 +153,37 @@ func NewActivePlan(planId, branch, prompt string, buildOnly bool) *ActivePlan {
 }
 
 func (ap *ActivePlan) Stream(msg shared.StreamMessage) {
+	ap.stream(msg, nil)
+}
+
+// StreamReplyChunk adds a chunk to the current reply and streams it. Both happen under streamMu so that the content from ReplySnapshot always matches its seq.
+func (ap *ActivePlan) StreamReplyChunk(chunk string) {
+	ap.stream(shared.StreamMessage{
+		Type:       shared.StreamMessageReply,
+		ReplyChunk: chunk,
+	}, func() {
+		ap.CurrentReplyContent += chunk
+		ap.NumTokens++
+	})
+}
+
+// ReplySnapshot returns the current reply content along with the seq of the last message streamed, which includes every chunk in the content
+func (ap *ActivePlan) ReplySnapshot() (string, int) {
+	ap.streamMu.Lock()
+	defer ap.streamMu.Unlock()
+	return ap.CurrentReplyContent, ap.streamSeq
+}
+
+func (ap *ActivePlan) stream(msg shared.StreamMessage, onSeq func()) {
+	// seq is assigned and the message is handed off under streamMu so that messages are buffered in seq order
+	ap.streamMu.Lock()
+	ap.streamSeq++
+	msg.Seq = ap.streamSeq
+
 	msgJson, err := json.Marshal(msg)
 	if err != nil {
+		ap.streamSeq--
+		ap.streamMu.Unlock()
 		ap.StreamDoneCh <- &shared.ApiError{
 			Type:   shared.ApiErrorTypeOther,
 			Status: http.StatusInternalServerError,
@@ -143,9 +192,18 @@ func (ap *ActivePlan) Stream(msg shared.StreamMessage) {
 		return
 	}
 
+	if onSeq != nil {
+		onSeq()
+	}
+
 	// log.Printf("ActivePlan: sending stream message: %s\n", string(msgJson))
 
-	ap.streamCh <- string(msgJson)
+	select {
+	case ap.streamCh <- StreamEvent{Seq: msg.Seq, Msg: string(msgJson)}:
+	case <-ap.Ctx.Done():
+		// stream manager has returned -- nothing left to deliver to
+	}
+	ap.streamMu.Unlock()
 
 	if msg.Type == shared.StreamMessageFinished {
 		// Wait briefly allow last stream message to be sent
@@ -176,7 +234,7 @@ func (ap *ActivePlan) PathFinished(path string) bool {
 	return true
 }
 
-func (ap *ActivePlan) Subscribe() (string, chan string) {
+func (ap *ActivePlan) Subscribe() (string, chan StreamEvent) {
 	ap.subscriptionMu.Lock()
 	defer ap.subscriptionMu.Unlock()
 	id := uuid.New().String()
@@ -185,6 +243,27 @@ func (ap *ActivePlan) Subscribe() (string, chan string) {
 	return id, sub.ch
 }
 
+// SubscribeFrom replays buffered messages after lastSeq before switching to live messages. Returns false (without subscribing) if messages after lastSeq have already been dropped from the buffer.
+func (ap *ActivePlan) SubscribeFrom(lastSeq int) (string, chan StreamEvent, bool) {
+	ap.subscriptionMu.Lock()
+	defer ap.subscriptionMu.Unlock()
+
+	if len(ap.streamBuffer) > 0 && ap.streamBuffer[0].Seq > lastSeq+1 {
+		return "", nil, false
+	}
+
+	id := uuid.New().String()
+	sub := newSubscription()
+	sub.afterSeq = lastSeq
+	for _, evt := range ap.streamBuffer {
+		if evt.Seq > lastSeq {
+			sub.enqueueMessage(evt)
+		}
+	}
+	ap.subscriptions[id] = sub
+	return id, sub.ch, true
+}
+
 func (ap *ActivePlan) Unsubscribe(id string) {
 	ap.subscriptionMu.Lock()
 	defer ap.subscriptionMu.Unlock()
@@ -198,6 +277,34 @@ func (ap *ActivePlan) Unsubscribe(id string) {
 	}
 }
 
+// SetWatcher attaches the connected user to a subscription so that it shows up in Watchers()
+func (ap *ActivePlan) SetWatcher(subscriptionId string, watcher *shared.StreamWatcher) {
+	ap.subscriptionMu.Lock()
+	defer ap.subscriptionMu.Unlock()
+
+	if sub, ok := ap.subscriptions[subscriptionId]; ok {
+		sub.watcher = watcher
+	}
+}
+
+func (ap *ActivePlan) Watchers() []*shared.StreamWatcher {
+	ap.subscriptionMu.Lock()
+	defer ap.subscriptionMu.Unlock()
+
+	watchers := []*shared.StreamWatcher{}
+	for _, sub := range ap.subscriptions {
+		if sub.watcher != nil {
+			watchers = append(watchers, sub.watcher)
+		}
+	}
+
+	sort.Slice(watchers, func(i, j int) bool {
+		return watchers[i].UserName < watchers[j].UserName
+	})
+
+	return watchers
+}
+
 func (ap *ActivePlan) NumSubscribers() int {
 	ap.subscriptionMu.Lock()
 	defer ap.subscriptionMu.Unlock()
@@ -211,10 +318,10 @@ func (b *ActiveBuild) BuildFinished() bool {
 func newSubscription() *subscription {
 	ctx, cancel := context.WithCancel(context.Background())
 	sub := &subscription{
-		ch:           make(chan string),
+		ch:           make(chan StreamEvent),
 		ctx:          ctx,
 		cancelFn:     cancel,
-		messageQueue: make([]string, 0),
+		messageQueue: make([]StreamEvent, 0),
 	}
 	sub.mu = sync.Mutex{}
 	sub.cond = sync.NewCond(&sub.mu)
@@ -248,7 +355,7 @@ func (sub *subscription) processMessages() {
 }
 
 // Adding a message to the subscription's queue
-func (sub *subscription) enqueueMessage(msg string) {
+func (sub *subscription) enqueueMessage(msg StreamEvent) {
 	// log.Printf("ActivePlan: enqueueing message: %s\n", msg)
 	sub.mu.Lock()
 	sub.messageQueue = append(sub.messageQueue, msg)
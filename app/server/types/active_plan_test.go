@@ -0,0 +1,130 @@
+package types
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/plandex/plandex/shared"
+)
+
+// streamTestMessages streams n reply chunks numbered from 1 and waits until they're buffered, which is once a live subscriber has received them
+func streamTestMessages(t *testing.T, ap *ActivePlan, n int) {
+	t.Helper()
+
+	id, ch := ap.Subscribe()
+	defer ap.Unsubscribe(id)
+
+	go func() {
+		for i := 1; i <= n; i++ {
+			ap.StreamReplyChunk(strconv.Itoa(i))
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		receiveTestEvent(t, ch)
+	}
+}
+
+func receiveTestEvent(t *testing.T, ch chan StreamEvent) StreamEvent {
+	t.Helper()
+
+	select {
+	case evt := <-ch:
+		return evt
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream message")
+	}
+	return StreamEvent{}
+}
+
+func TestStreamAssignsSeqs(t *testing.T) {
+	ap := NewActivePlan("plan", "main", "prompt", false)
+	defer ap.CancelFn()
+
+	id, ch := ap.Subscribe()
+	defer ap.Unsubscribe(id)
+
+	go func() {
+		ap.StreamReplyChunk("a")
+		ap.StreamReplyChunk("b")
+	}()
+
+	for i, chunk := range []string{"a", "b"} {
+		evt := receiveTestEvent(t, ch)
+
+		var msg shared.StreamMessage
+		if err := json.Unmarshal([]byte(evt.Msg), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if evt.Seq != i+1 || msg.Seq != i+1 || msg.ReplyChunk != chunk {
+			t.Errorf("message %d: got seq %d (%d in the message) with chunk %q, want seq %d with chunk %q", i, evt.Seq, msg.Seq, msg.ReplyChunk, i+1, chunk)
+		}
+	}
+
+	content, seq := ap.ReplySnapshot()
+	if content != "ab" || seq != 2 {
+		t.Errorf("snapshot = %q at seq %d, want \"ab\" at seq 2", content, seq)
+	}
+}
+
+func TestSubscribeFromReplaysAfterLastSeq(t *testing.T) {
+	ap := NewActivePlan("plan", "main", "prompt", false)
+	defer ap.CancelFn()
+
+	streamTestMessages(t, ap, 5)
+
+	id, ch, ok := ap.SubscribeFrom(3)
+	if !ok {
+		t.Fatal("expected to replay from a buffered seq")
+	}
+	defer ap.Unsubscribe(id)
+
+	// live messages pick up right after the replayed ones
+	go ap.StreamReplyChunk("6")
+
+	for _, want := range []int{4, 5, 6} {
+		if evt := receiveTestEvent(t, ch); evt.Seq != want {
+			t.Errorf("got seq %d, want %d", evt.Seq, want)
+		}
+	}
+
+	select {
+	case evt := <-ch:
+		t.Errorf("unexpected message with seq %d", evt.Seq)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSubscribeFromEvictedSeq(t *testing.T) {
+	ap := NewActivePlan("plan", "main", "prompt", false)
+	defer ap.CancelFn()
+
+	numEvicted := 5
+	streamTestMessages(t, ap, MaxStreamBufferSize+numEvicted)
+
+	ap.subscriptionMu.Lock()
+	bufferSize, firstSeq := len(ap.streamBuffer), ap.streamBuffer[0].Seq
+	ap.subscriptionMu.Unlock()
+
+	if bufferSize != MaxStreamBufferSize || firstSeq != numEvicted+1 {
+		t.Fatalf("buffer has %d messages from seq %d, want %d from seq %d", bufferSize, firstSeq, MaxStreamBufferSize, numEvicted+1)
+	}
+
+	// seq 5 was evicted, so replaying after seq 3 would skip seq 4 and 5
+	if _, _, ok := ap.SubscribeFrom(3); ok {
+		t.Error("expected replay to fail once messages after lastSeq were evicted")
+	}
+
+	// replaying after the last evicted seq only needs what's still buffered
+	id, ch, ok := ap.SubscribeFrom(numEvicted)
+	if !ok {
+		t.Fatal("expected to replay from the last evicted seq")
+	}
+	defer ap.Unsubscribe(id)
+
+	if evt := receiveTestEvent(t, ch); evt.Seq != numEvicted+1 {
+		t.Errorf("got seq %d, want %d", evt.Seq, numEvicted+1)
+	}
+}
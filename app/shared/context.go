@@ -1,6 +1,8 @@
 package shared
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"strconv"
@@ -17,6 +19,8 @@ type ContextUpdateResult struct {
 	NumFiles        int
 	NumUrls         int
 	NumTrees        int
+	NumGit          int
+	NumCommands     int
 	MaxTokens       int
 }
 
@@ -27,6 +31,9 @@ func (c *Context) TypeAndIcon() (string, string) {
 	case ContextFileType:
 		icon = "📄"
 		t = "file"
+		if c.Outline {
+			t = "outline"
+		}
 	case ContextURLType:
 		icon = "🌎"
 		t = "url"
@@ -39,11 +46,37 @@ func (c *Context) TypeAndIcon() (string, string) {
 	case ContextPipedDataType:
 		icon = "↔️ "
 		t = "piped"
+	case ContextImageType:
+		icon = "🖼️ "
+		t = "image"
+	case ContextSymbolType:
+		icon = "🔣"
+		t = "symbol"
+	case ContextGitDiffType:
+		icon = "🔀"
+		t = "diff"
+	case ContextGitCommitType:
+		icon = "🔖"
+		t = "commit"
+	case ContextGitChangesType:
+		icon = "🌿"
+		t = "changes"
+	case ContextExecType:
+		icon = "💻"
+		t = "command"
+	case ContextRepoMapType:
+		icon = "🗺️ "
+		t = "map"
 	}
 
 	return t, icon
 }
 
+// IsPartialFile is true for contexts that hold only part of a file: a symbol, a line range, or an outline
+func (c *Context) IsPartialFile() bool {
+	return c.ContextType == ContextSymbolType || (c.ContextType == ContextFileType && (c.StartLine > 0 || c.Outline))
+}
+
 func TableForLoadContext(contexts []*Context) string {
 	tableString := &strings.Builder{}
 	table := tablewriter.NewWriter(tableString)
@@ -78,6 +111,13 @@ func SummaryForLoadContext(contexts []*Context, tokensAdded, totalTokens int) st
 	var numFiles int
 	var numTrees int
 	var numUrls int
+	var numImages int
+	var numSymbols int
+	var numDiffs int
+	var numCommits int
+	var numChanges int
+	var numCommands int
+	var numMaps int
 
 	for _, context := range contexts {
 		switch context.ContextType {
@@ -91,6 +131,20 @@ func SummaryForLoadContext(contexts []*Context, tokensAdded, totalTokens int) st
 			hasNote = true
 		case ContextPipedDataType:
 			hasPiped = true
+		case ContextImageType:
+			numImages++
+		case ContextSymbolType:
+			numSymbols++
+		case ContextGitDiffType:
+			numDiffs++
+		case ContextGitCommitType:
+			numCommits++
+		case ContextGitChangesType:
+			numChanges++
+		case ContextExecType:
+			numCommands++
+		case ContextRepoMapType:
+			numMaps++
 		}
 	}
 
@@ -123,6 +177,55 @@ func SummaryForLoadContext(contexts []*Context, tokensAdded, totalTokens int) st
 		}
 		added = append(added, fmt.Sprintf("%d %s", numUrls, label))
 	}
+	if numImages > 0 {
+		label := "image"
+		if numImages > 1 {
+			label = "images"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numImages, label))
+	}
+	if numSymbols > 0 {
+		label := "symbol"
+		if numSymbols > 1 {
+			label = "symbols"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numSymbols, label))
+	}
+	if numDiffs > 0 {
+		label := "git diff"
+		if numDiffs > 1 {
+			label = "git diffs"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numDiffs, label))
+	}
+	if numCommits > 0 {
+		label := "commit"
+		if numCommits > 1 {
+			label = "commits"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numCommits, label))
+	}
+	if numChanges > 0 {
+		label := "set of changed files"
+		if numChanges > 1 {
+			label = "sets of changed files"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numChanges, label))
+	}
+	if numCommands > 0 {
+		label := "command output"
+		if numCommands > 1 {
+			label = "command outputs"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numCommands, label))
+	}
+	if numMaps > 0 {
+		label := "repo map"
+		if numMaps > 1 {
+			label = "repo maps"
+		}
+		added = append(added, fmt.Sprintf("%d %s", numMaps, label))
+	}
 
 	msg := "Loaded "
 
@@ -186,10 +289,50 @@ func SummaryForRemoveContext(contexts []*Context, previousTotalTokens int) strin
 	return fmt.Sprintf("Removed %d piece%s of context | removed → %d 🪙 | total → %d 🪙", len(contexts), suffix, removedTokens, totalTokens)
 }
 
+func SummaryForContextPriority(contexts []*Context, priority *ContextPriority, pinned *bool) string {
+	if len(contexts) == 0 {
+		return "No context changed"
+	}
+
+	var names []string
+	for _, context := range contexts {
+		names = append(names, context.Name)
+	}
+
+	suffix := ""
+	if len(contexts) > 1 {
+		suffix = "s"
+	}
+	pieces := fmt.Sprintf("%d piece%s of context", len(contexts), suffix)
+
+	var msg string
+	if pinned != nil {
+		if *pinned {
+			msg = "Pinned 📌 "
+		} else {
+			msg = "Unpinned "
+		}
+	}
+	if priority != nil {
+		if msg == "" {
+			msg = "Set "
+		} else {
+			msg += "and set "
+		}
+		msg += pieces + " to " + priority.String() + " priority"
+	} else {
+		msg += pieces
+	}
+
+	return msg + " | " + strings.Join(names, ", ")
+}
+
 func SummaryForUpdateContext(updateRes *ContextUpdateResult) string {
 	numFiles := updateRes.NumFiles
 	numTrees := updateRes.NumTrees
 	numUrls := updateRes.NumUrls
+	numGit := updateRes.NumGit
+	numCommands := updateRes.NumCommands
 	tokensDiff := updateRes.TokensDiff
 	totalTokens := updateRes.TotalTokens
 
@@ -216,6 +359,20 @@ func SummaryForUpdateContext(updateRes *ContextUpdateResult) string {
 		}
 		toAdd = append(toAdd, fmt.Sprintf("%d url%s", numUrls, postfix))
 	}
+	if numGit > 0 {
+		postfix := "s"
+		if numGit == 1 {
+			postfix = ""
+		}
+		toAdd = append(toAdd, fmt.Sprintf("%d git context%s", numGit, postfix))
+	}
+	if numCommands > 0 {
+		postfix := "s"
+		if numCommands == 1 {
+			postfix = ""
+		}
+		toAdd = append(toAdd, fmt.Sprintf("%d command output%s", numCommands, postfix))
+	}
 
 	if len(toAdd) <= 2 {
 		msg += " " + strings.Join(toAdd, " and ")
@@ -283,3 +440,26 @@ func TableForContextUpdate(updateRes *ContextUpdateResult) string {
 
 	return tableString.String()
 }
+
+// GetLineRange returns lines startLine through endLine (1-indexed, inclusive) of body, clamped to the lines that exist. ok is false if startLine is past the end of body.
+func GetLineRange(body string, startLine, endLine int) (string, bool) {
+	lines := strings.Split(body, "\n")
+
+	if startLine < 1 {
+		startLine = 1
+	}
+	if startLine > len(lines) {
+		return "", false
+	}
+	if endLine > len(lines) || endLine < startLine {
+		endLine = len(lines)
+	}
+
+	return strings.Join(lines[startLine-1:endLine], "\n"), true
+}
+
+// GetContextBodyHash is the content hash that context bodies are stored under on the server
+func GetContextBodyHash(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(hash[:])
+}
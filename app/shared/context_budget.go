@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContextPriority decides which context is outlined or left out first when context doesn't fit in the planner's token budget. The zero value is normal priority.
+type ContextPriority int
+
+const (
+	ContextPriorityLow    ContextPriority = -1
+	ContextPriorityNormal ContextPriority = 0
+	ContextPriorityHigh   ContextPriority = 1
+)
+
+var ContextPriorities = []ContextPriority{ContextPriorityLow, ContextPriorityNormal, ContextPriorityHigh}
+
+func (p ContextPriority) String() string {
+	switch p {
+	case ContextPriorityLow:
+		return "low"
+	case ContextPriorityHigh:
+		return "high"
+	}
+	return "normal"
+}
+
+func ParseContextPriority(s string) (ContextPriority, error) {
+	for _, p := range ContextPriorities {
+		if strings.EqualFold(s, p.String()) {
+			return p, nil
+		}
+	}
+	return ContextPriorityNormal, fmt.Errorf("invalid priority '%s' (should be low, normal, or high)", s)
+}
+
+// ContextBudgetStatus is how a context was sent to the planner in a reply
+type ContextBudgetStatus string
+
+const (
+	ContextBudgetIncluded ContextBudgetStatus = "included"
+	ContextBudgetOutlined ContextBudgetStatus = "outlined"
+	ContextBudgetDropped  ContextBudgetStatus = "dropped"
+)
+
+// ContextBudgetReport records how context was fit into the planner's token budget for a reply
+type ContextBudgetReport struct {
+	ReplyId    string                         `json:"replyId"`
+	Budget     int                            `json:"budget"`
+	NumTokens  int                            `json:"numTokens"`
+	StatusById map[string]ContextBudgetStatus `json:"statusById"`
+
+	// names of trimmed contexts, for display
+	Outlined []string `json:"outlined,omitempty"`
+	Dropped  []string `json:"dropped,omitempty"`
+}
+
+func (r *ContextBudgetReport) Trimmed() bool {
+	return len(r.Outlined) > 0 || len(r.Dropped) > 0
+}
+
+func (r *ContextBudgetReport) Summary() string {
+	var parts []string
+	if len(r.Outlined) > 0 {
+		parts = append(parts, "outlined "+strings.Join(r.Outlined, ", "))
+	}
+	if len(r.Dropped) > 0 {
+		parts = append(parts, "left out "+strings.Join(r.Dropped, ", "))
+	}
+	return fmt.Sprintf("Context is over the planner's budget (%d 🪙), so for this reply Plandex %s", r.Budget, strings.Join(parts, " and "))
+}
@@ -0,0 +1,134 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var contextSetNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+func IsValidContextSetName(name string) bool {
+	return len(name) <= 255 && contextSetNameRegex.MatchString(name)
+}
+
+// ContextSetRefFromContext converts a loaded context into a reference for a context set. Only notes and piped data keep their bodies since they have no other source. Commands keep the id of the user who loaded them, since only that user's machine should run them.
+func ContextSetRefFromContext(context *Context) *ContextSetRef {
+	ref := &ContextSetRef{
+		ContextType:     context.ContextType,
+		Name:            context.Name,
+		FilePath:        context.FilePath,
+		Url:             context.Url,
+		Symbol:          context.Symbol,
+		StartLine:       context.StartLine,
+		EndLine:         context.EndLine,
+		Outline:         context.Outline,
+		MapTokens:       context.MapTokens,
+		GitRef:          context.GitRef,
+		Command:         context.Command,
+		Priority:        context.Priority,
+		Pinned:          context.Pinned,
+		ForceSkipIgnore: context.ForceSkipIgnore,
+	}
+
+	if context.ContextType == ContextNoteType || context.ContextType == ContextPipedDataType {
+		ref.Body = context.Body
+	}
+
+	if context.ContextType == ContextExecType {
+		ref.OwnerId = context.OwnerId
+	}
+
+	return ref
+}
+
+// Key identifies what a ref points to, so versions of a set can be compared
+func (ref *ContextSetRef) Key() string {
+	var id string
+	switch ref.ContextType {
+	case ContextURLType:
+		id = ref.Url
+	case ContextSymbolType:
+		id = ref.FilePath + "#" + ref.Symbol
+	case ContextGitDiffType, ContextGitCommitType, ContextGitChangesType:
+		id = ref.GitRef
+	case ContextExecType:
+		id = ref.Command
+	case ContextNoteType, ContextPipedDataType:
+		sum := sha256.Sum256([]byte(ref.Body))
+		id = hex.EncodeToString(sum[:8])
+	default:
+		id = ref.FilePath
+		if ref.StartLine > 0 {
+			id = fmt.Sprintf("%s:%d-%d", ref.FilePath, ref.StartLine, ref.EndLine)
+		} else if ref.Outline {
+			id = ref.FilePath + "#outline"
+		}
+	}
+
+	return string(ref.ContextType) + "|" + id
+}
+
+func (ref *ContextSetRef) Label() string {
+	if ref.Name != "" {
+		return ref.Name
+	}
+	return string(ref.ContextType)
+}
+
+// SummaryForContextSetChanges describes how a new version of a context set differs from the previous one. changed is false if the refs are the same.
+func SummaryForContextSetChanges(prev, refs []*ContextSetRef) (summary string, changed bool) {
+	if prev == nil {
+		suffix := ""
+		if len(refs) != 1 {
+			suffix = "s"
+		}
+		return fmt.Sprintf("Created with %d piece%s of context", len(refs), suffix), true
+	}
+
+	prevByKey := map[string]*ContextSetRef{}
+	for _, ref := range prev {
+		prevByKey[ref.Key()] = ref
+	}
+
+	var added, removed, modified []string
+	seen := map[string]bool{}
+
+	for _, ref := range refs {
+		key := ref.Key()
+		seen[key] = true
+
+		prevRef, ok := prevByKey[key]
+		if !ok {
+			added = append(added, ref.Label())
+		} else if *prevRef != *ref {
+			modified = append(modified, ref.Label())
+		}
+	}
+
+	for _, ref := range prev {
+		if !seen[ref.Key()] {
+			removed = append(removed, ref.Label())
+		}
+	}
+
+	var parts []string
+	if len(added) > 0 {
+		parts = append(parts, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(removed, ", "))
+	}
+	if len(modified) > 0 {
+		parts = append(parts, "changed "+strings.Join(modified, ", "))
+	}
+
+	if len(parts) == 0 {
+		return "", false
+	}
+
+	summary = strings.Join(parts, "; ")
+	return strings.ToUpper(summary[:1]) + summary[1:], true
+}
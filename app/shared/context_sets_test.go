@@ -0,0 +1,82 @@
+package shared
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// testContextSetVersions stores versions the way SaveContextSet does: refs are stored as json, and a save only adds a version if they changed since the latest one
+type testContextSetVersions struct {
+	refs      []string
+	summaries []string
+}
+
+func (v *testContextSetVersions) save(t *testing.T, refs []*ContextSetRef) (string, bool) {
+	t.Helper()
+
+	var prev []*ContextSetRef
+	if len(v.refs) > 0 {
+		if err := json.Unmarshal([]byte(v.refs[len(v.refs)-1]), &prev); err != nil {
+			t.Fatal(err)
+		}
+		if prev == nil {
+			prev = []*ContextSetRef{}
+		}
+	}
+
+	summary, changed := SummaryForContextSetChanges(prev, refs)
+	if !changed {
+		return "", false
+	}
+
+	bytes, err := json.Marshal(refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.refs = append(v.refs, string(bytes))
+	v.summaries = append(v.summaries, summary)
+
+	return summary, true
+}
+
+func TestContextSetVersions(t *testing.T) {
+	file := &ContextSetRef{ContextType: ContextFileType, Name: "main.go", FilePath: "main.go"}
+	pinnedFile := &ContextSetRef{ContextType: ContextFileType, Name: "main.go", FilePath: "main.go", Pinned: true}
+	url := &ContextSetRef{ContextType: ContextURLType, Name: "docs", Url: "https://example.com/docs"}
+	note := &ContextSetRef{ContextType: ContextNoteType, Body: "use tabs"}
+
+	tests := []struct {
+		name        string
+		refs        []*ContextSetRef
+		wantChanged bool
+		wantSummary string
+	}{
+		{name: "create", refs: []*ContextSetRef{file, url}, wantChanged: true, wantSummary: "Created with 2 pieces of context"},
+		{name: "same refs", refs: []*ContextSetRef{file, url}, wantChanged: false},
+		{name: "same refs in another order", refs: []*ContextSetRef{url, file}, wantChanged: false},
+		{name: "pin a file", refs: []*ContextSetRef{pinnedFile, url}, wantChanged: true, wantSummary: "Changed main.go"},
+		{name: "swap the url for a note", refs: []*ContextSetRef{pinnedFile, note}, wantChanged: true, wantSummary: "Added note; removed docs"},
+		{name: "empty", refs: []*ContextSetRef{}, wantChanged: true, wantSummary: "Removed main.go, note"},
+		{name: "empty again", refs: []*ContextSetRef{}, wantChanged: false},
+	}
+
+	versions := &testContextSetVersions{}
+	wantVersions := 0
+
+	for _, tt := range tests {
+		summary, changed := versions.save(t, tt.refs)
+		if changed != tt.wantChanged {
+			t.Errorf("%s: changed = %v, want %v", tt.name, changed, tt.wantChanged)
+		}
+		if summary != tt.wantSummary {
+			t.Errorf("%s: summary = %q, want %q", tt.name, summary, tt.wantSummary)
+		}
+
+		if tt.wantChanged {
+			wantVersions++
+		}
+		if len(versions.refs) != wantVersions {
+			t.Errorf("%s: %d versions, want %d", tt.name, len(versions.refs), wantVersions)
+		}
+	}
+}
@@ -38,6 +38,57 @@ type Invite struct {
 	CreatedAt  time.Time  `json:"createdAt"`
 }
 
+type PromptTemplate struct {
+	Id          string    `json:"id,omitempty"`
+	OrgId       string    `json:"orgId,omitempty"`
+	OwnerId     string    `json:"ownerId,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Body        string    `json:"body"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type ContextSet struct {
+	Id        string           `json:"id"`
+	ProjectId string           `json:"projectId"`
+	OwnerId   string           `json:"ownerId"`
+	Name      string           `json:"name"`
+	Version   int              `json:"version"`
+	SavedBy   string           `json:"savedBy,omitempty"`
+	Refs      []*ContextSetRef `json:"refs,omitempty"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// ContextSetRef is a reference to a piece of context in a context set. Bodies are re-loaded from their source when the set is loaded, so only notes and piped data keep theirs.
+type ContextSetRef struct {
+	ContextType     ContextType     `json:"contextType"`
+	Name            string          `json:"name,omitempty"`
+	FilePath        string          `json:"filePath,omitempty"`
+	Url             string          `json:"url,omitempty"`
+	Symbol          string          `json:"symbol,omitempty"`
+	StartLine       int             `json:"startLine,omitempty"`
+	EndLine         int             `json:"endLine,omitempty"`
+	Outline         bool            `json:"outline,omitempty"`
+	MapTokens       int             `json:"mapTokens,omitempty"`
+	GitRef          string          `json:"gitRef,omitempty"`
+	Command         string          `json:"command,omitempty"`
+	OwnerId         string          `json:"ownerId,omitempty"`
+	Priority        ContextPriority `json:"priority,omitempty"`
+	Pinned          bool            `json:"pinned,omitempty"`
+	ForceSkipIgnore bool            `json:"forceSkipIgnore,omitempty"`
+	Body            string          `json:"body,omitempty"`
+}
+
+type ContextSetVersion struct {
+	Version   int       `json:"version"`
+	OwnerId   string    `json:"ownerId"`
+	NumRefs   int       `json:"numRefs"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 type Project struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
@@ -79,21 +130,39 @@ const (
 	ContextNoteType          ContextType = "note"
 	ContextDirectoryTreeType ContextType = "directory tree"
 	ContextPipedDataType     ContextType = "piped data"
+	ContextImageType         ContextType = "image"
+	ContextSymbolType        ContextType = "symbol"
+	ContextGitDiffType       ContextType = "git diff"
+	ContextGitCommitType     ContextType = "git commit"
+	ContextGitChangesType    ContextType = "git changes"
+	ContextExecType          ContextType = "command"
+	ContextRepoMapType       ContextType = "repo map"
 )
 
 type Context struct {
-	Id              string      `json:"id"`
-	OwnerId         string      `json:"ownerId"`
-	ContextType     ContextType `json:"contextType"`
-	Name            string      `json:"name"`
-	Url             string      `json:"url"`
-	FilePath        string      `json:"file_path"`
-	Sha             string      `json:"sha"`
-	NumTokens       int         `json:"numTokens"`
-	Body            string      `json:"body,omitempty"`
-	ForceSkipIgnore bool        `json:"forceSkipIgnore"`
-	CreatedAt       time.Time   `json:"createdAt"`
-	UpdatedAt       time.Time   `json:"updatedAt"`
+	Id              string              `json:"id"`
+	OwnerId         string              `json:"ownerId"`
+	ContextType     ContextType         `json:"contextType"`
+	Name            string              `json:"name"`
+	Url             string              `json:"url"`
+	FilePath        string              `json:"file_path"`
+	Symbol          string              `json:"symbol,omitempty"`
+	StartLine       int                 `json:"startLine,omitempty"`
+	EndLine         int                 `json:"endLine,omitempty"`
+	Outline         bool                `json:"outline,omitempty"`
+	MapTokens       int                 `json:"mapTokens,omitempty"`
+	GitRef          string              `json:"gitRef,omitempty"`
+	Command         string              `json:"command,omitempty"`
+	Priority        ContextPriority     `json:"priority,omitempty"`
+	Pinned          bool                `json:"pinned,omitempty"`
+	LastReplyStatus ContextBudgetStatus `json:"lastReplyStatus,omitempty"`
+	Sha             string              `json:"sha"`
+	NumTokens       int                 `json:"numTokens"`
+	Body            string              `json:"body,omitempty"`
+	ForceSkipIgnore bool                `json:"forceSkipIgnore"`
+	Redacted        bool                `json:"redacted,omitempty"` // secrets were masked when loaded, so they're masked again when it's updated
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
 }
 
 type ConvoMessage struct {
@@ -193,10 +262,11 @@ type OrgRole struct {
 }
 
 type BaseModelConfig struct {
-	Provider  ModelProvider `json:"provider"`
-	BaseUrl   string        `json:"baseUrl"`
-	ModelName string        `json:"modelName"`
-	MaxTokens int           `json:"maxTokens"`
+	Provider        ModelProvider `json:"provider"`
+	BaseUrl         string        `json:"baseUrl"`
+	ModelName       string        `json:"modelName"`
+	MaxTokens       int           `json:"maxTokens"`
+	HasImageSupport bool          `json:"hasImageSupport"`
 }
 
 type PlannerModelConfig struct {
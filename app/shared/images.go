@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+	"strings"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+var imageMimeTypesByExt = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+}
+
+func IsImagePath(path string) bool {
+	_, ok := imageMimeTypesByExt[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func ImageMimeType(path string) string {
+	return imageMimeTypesByExt[strings.ToLower(filepath.Ext(path))]
+}
+
+// ImageDataUrl builds a data url for a base64-encoded image body, as sent to the model in image parts.
+func ImageDataUrl(path, base64Body string) string {
+	return fmt.Sprintf("data:%s;base64,%s", ImageMimeType(path), base64Body)
+}
+
+// GetImageNumTokens counts tokens for an image using OpenAI's rules for high detail images: the image is scaled to fit within 2048x2048, then scaled so its shortest side is at most 768px, then each 512px tile costs 170 tokens on top of a base of 85.
+func GetImageNumTokens(data []byte) (int, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("error decoding image: %v", err)
+	}
+
+	width := float64(config.Width)
+	height := float64(config.Height)
+
+	if width > 2048 || height > 2048 {
+		scale := 2048 / math.Max(width, height)
+		width *= scale
+		height *= scale
+	}
+
+	if math.Min(width, height) > 768 {
+		scale := 768 / math.Min(width, height)
+		width *= scale
+		height *= scale
+	}
+
+	tiles := math.Ceil(width/512) * math.Ceil(height/512)
+
+	return 85 + 170*int(tiles), nil
+}
+
+func GetBase64ImageNumTokens(base64Body string) (int, error) {
+	data, err := base64.StdEncoding.DecodeString(base64Body)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding base64 image: %v", err)
+	}
+
+	return GetImageNumTokens(data)
+}
@@ -0,0 +1,129 @@
+package shared
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const outlineElided = "…"
+
+// GetOutline reduces a source file to its declarations, signatures and doc comments, leaving out function bodies. Go files are parsed; other files use an indentation heuristic. ok is false if the outline wouldn't be any smaller than the file.
+func GetOutline(path, body string) (string, bool) {
+	var outline string
+
+	if filepath.Ext(path) == ".go" {
+		var err error
+		outline, err = getGoOutline(body)
+		if err != nil {
+			// fall back to the heuristic for files that don't parse, like ones mid-edit
+			outline = getIndentOutline(body)
+		}
+	} else {
+		outline = getIndentOutline(body)
+	}
+
+	if len(outline) >= len(body) {
+		return "", false
+	}
+
+	return outline, true
+}
+
+// getGoOutline keeps everything except the bodies of funcs and methods
+func getGoOutline(body string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", body, parser.ParseComments)
+	if err != nil {
+		return "", err
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		spans = append(spans, span{
+			start: fset.Position(fn.Body.Lbrace).Offset + 1,
+			end:   fset.Position(fn.Body.Rbrace).Offset,
+		})
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var sb strings.Builder
+	last := 0
+	for _, s := range spans {
+		sb.WriteString(body[last:s.start])
+		sb.WriteString(" " + outlineElided + " ")
+		last = s.end
+	}
+	sb.WriteString(body[last:])
+
+	return sb.String(), nil
+}
+
+var outlineDeclPattern = regexp.MustCompile(`^\s*(?:(?:export|default|public|private|protected|internal|static|abstract|final|async|override|virtual|pub(?:\([\w:]+\))?|extern|unsafe|inline|const)\s+)*(?:def|class|func|fn|function|interface|type|struct|enum|trait|impl|module|namespace|object|record|protocol|extension|mod)\b`)
+
+var outlineCommentPattern = regexp.MustCompile(`^\s*(?://|#|/\*|\*|"""|'''|--)`)
+
+// getIndentOutline keeps top-level lines, nested lines that look like declarations, and comments directly above a kept line. Each run of left out lines becomes a single '…' at the run's indentation.
+func getIndentOutline(body string) string {
+	lines := strings.Split(body, "\n")
+	keep := make([]bool, len(lines))
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if len(line) == len(strings.TrimLeft(line, " \t")) || outlineDeclPattern.MatchString(line) {
+			keep[i] = true
+		}
+	}
+
+	// comments and decorators above a kept line document it
+	for i := len(lines) - 1; i > 0; i-- {
+		if !keep[i] {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			trimmed := strings.TrimSpace(lines[j])
+			if outlineCommentPattern.MatchString(lines[j]) || strings.HasPrefix(trimmed, "@") {
+				keep[j] = true
+			} else {
+				break
+			}
+		}
+	}
+
+	var res []string
+	elided := false
+	for i, line := range lines {
+		if keep[i] {
+			res = append(res, line)
+			elided = false
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			if !elided {
+				res = append(res, line)
+			}
+			continue
+		}
+		if !elided {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			res = append(res, indent+outlineElided)
+			elided = true
+		}
+	}
+
+	return strings.Join(res, "\n")
+}
@@ -0,0 +1,115 @@
+package shared
+
+import "testing"
+
+func TestGetOutline(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		want   string
+		wantOk bool
+	}{
+		{
+			name: "go",
+			path: "server.go",
+			body: `package server
+
+// Server handles requests
+type Server struct {
+	addr string
+}
+
+// Start listens on the server's address
+func (s *Server) Start() error {
+	if s.addr == "" {
+		return errNoAddr
+	}
+	return listen(s.addr)
+}
+
+func stop() {
+	close(done)
+}
+`,
+			want: `package server
+
+// Server handles requests
+type Server struct {
+	addr string
+}
+
+// Start listens on the server's address
+func (s *Server) Start() error { … }
+
+func stop() { … }
+`,
+			wantOk: true,
+		},
+		{
+			name: "go that doesn't parse falls back to indentation",
+			path: "broken.go",
+			body: `package broken
+
+// Run runs
+func Run() {
+	for {
+		work()
+	}
+`,
+			want: `package broken
+
+// Run runs
+func Run() {
+	…`,
+			wantOk: true,
+		},
+		{
+			// blank lines in a left out run go with it
+			name: "python",
+			path: "server.py",
+			body: `import os
+
+# Server handles requests
+class Server:
+    # start listens on the server's address
+    @property
+    def start(self):
+        if not self.addr:
+            raise ValueError()
+        return listen(self.addr)
+
+def stop():
+    done.close()
+`,
+			want: `import os
+
+# Server handles requests
+class Server:
+    # start listens on the server's address
+    @property
+    def start(self):
+        …
+def stop():
+    …`,
+			wantOk: true,
+		},
+		{
+			name:   "nothing to leave out",
+			path:   "types.go",
+			body:   "package types\n\ntype Id string\n",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		got, ok := GetOutline(tt.path, tt.body)
+		if ok != tt.wantOk {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.wantOk)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -8,16 +8,25 @@ import (
 )
 
 func (state *CurrentPlanState) PendingChangesSummaryForBuild() string {
-	return state.pendingChangesSummary(false)
+	return state.pendingChangesSummary(false, nil)
 }
 
-func (state *CurrentPlanState) PendingChangesSummaryForApply() string {
-	return state.pendingChangesSummary(true)
+// PendingChangesSummaryForApply summarizes the pending changes to paths for an apply commit message, so changes to files that aren't being applied aren't described. If paths is empty, every pending change is included.
+func (state *CurrentPlanState) PendingChangesSummaryForApply(paths []string) string {
+	return state.pendingChangesSummary(true, paths)
 }
 
-func (state *CurrentPlanState) pendingChangesSummary(forApply bool) string {
+func (state *CurrentPlanState) pendingChangesSummary(forApply bool, paths []string) string {
 	var msgs []string
 
+	var pathsSet map[string]bool
+	if len(paths) > 0 {
+		pathsSet = make(map[string]bool)
+		for _, path := range paths {
+			pathsSet[path] = true
+		}
+	}
+
 	descByConvoMessageId := make(map[string]*ConvoMessageDescription)
 
 	for _, desc := range state.ConvoMessageDescriptions {
@@ -40,6 +49,10 @@ func (state *CurrentPlanState) pendingChangesSummary(forApply bool) string {
 		// log.Println("result:")
 		// spew.Dump(result)
 
+		if pathsSet != nil && !pathsSet[result.Path] {
+			continue
+		}
+
 		convoIds := map[string]bool{}
 		if descByConvoMessageId[result.ConvoMessageId] != nil {
 			convoIds[result.ConvoMessageId] = true
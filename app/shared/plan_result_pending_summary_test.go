@@ -0,0 +1,34 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPendingChangesSummaryForApply(t *testing.T) {
+	state := &CurrentPlanState{
+		PlanResult: &PlanResult{
+			Results: []*PlanFileResult{
+				{Path: "a.go", ConvoMessageId: "msg-a", Content: "package a\n"},
+				{Path: "b.go", ConvoMessageId: "msg-b", Content: "package b\n"},
+			},
+		},
+		ConvoMessageDescriptions: []*ConvoMessageDescription{
+			{ConvoMessageId: "msg-a", CommitMsg: "Update a"},
+			{ConvoMessageId: "msg-b", CommitMsg: "Update b"},
+		},
+	}
+
+	all := state.PendingChangesSummaryForApply(nil)
+	if !strings.Contains(all, "Update a") || !strings.Contains(all, "Update b") {
+		t.Errorf("expected every change to be described:\n%s", all)
+	}
+
+	msg := state.PendingChangesSummaryForApply([]string{"a.go"})
+	if !strings.Contains(msg, "Update a") {
+		t.Errorf("the applied change isn't described:\n%s", msg)
+	}
+	if strings.Contains(msg, "Update b") {
+		t.Errorf("a change that's still pending is described:\n%s", msg)
+	}
+}
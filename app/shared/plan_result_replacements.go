@@ -89,6 +89,28 @@ func ApplyReplacements(content string, replacements []*Replacement, setFailed bo
 
 }
 
+// ApplyPendingToFile applies the pending changes for a path to the given content rather than to the context they were built from. It's used when only part of a file is in context, since the rest of the file may have changed since it was loaded.
+func (planState *CurrentPlanState) ApplyPendingToFile(path, content string) (string, bool) {
+	for _, planRes := range planState.PlanResult.FileResultsByPath[path] {
+		if !planRes.IsPending() {
+			continue
+		}
+
+		if len(planRes.Replacements) == 0 {
+			content = planRes.Content
+			continue
+		}
+
+		var allSucceeded bool
+		content, allSucceeded = ApplyReplacements(content, planRes.Replacements, false)
+		if !allSucceeded {
+			return "", false
+		}
+	}
+
+	return content, true
+}
+
 func (planState *CurrentPlanState) GetFiles() (*CurrentPlanFiles, error) {
 	return planState.GetFilesBeforeReplacement("")
 }
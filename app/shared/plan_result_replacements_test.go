@@ -0,0 +1,53 @@
+package shared
+
+import "testing"
+
+func TestGetLineRange(t *testing.T) {
+	body := "one\ntwo\nthree\nfour"
+
+	tests := []struct {
+		name       string
+		start, end int
+		want       string
+		wantOk     bool
+	}{
+		{name: "middle", start: 2, end: 3, want: "two\nthree", wantOk: true},
+		{name: "single line", start: 4, end: 4, want: "four", wantOk: true},
+		{name: "end past the last line", start: 3, end: 10, want: "three\nfour", wantOk: true},
+		{name: "start past the last line", start: 5, end: 6, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := GetLineRange(body, tt.start, tt.end)
+		if ok != tt.wantOk || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestApplyPendingToFile(t *testing.T) {
+	newState := func(results ...*PlanFileResult) *CurrentPlanState {
+		return &CurrentPlanState{PlanResult: &PlanResult{FileResultsByPath: PlanFileResultsByPath{"main.txt": results}}}
+	}
+
+	// the file was edited outside the loaded range after it was loaded
+	current := "one\ntwo\nthree\nFOUR\n"
+
+	state := newState(&PlanFileResult{
+		Path:         "main.txt",
+		Replacements: []*Replacement{{Old: "two\nthree", New: "TWO\nthree"}},
+	})
+	got, ok := state.ApplyPendingToFile("main.txt", current)
+	if !ok || got != "one\nTWO\nthree\nFOUR\n" {
+		t.Errorf("got %q, %v, want the change applied alongside the edit outside the range", got, ok)
+	}
+
+	// the range itself was edited so the change no longer applies
+	state = newState(&PlanFileResult{
+		Path:         "main.txt",
+		Replacements: []*Replacement{{Old: "two\n3", New: "TWO\n3"}},
+	})
+	if _, ok := state.ApplyPendingToFile("main.txt", current); ok {
+		t.Error("expected a change that no longer applies to fail")
+	}
+}
@@ -0,0 +1,50 @@
+package shared
+
+import (
+	"regexp"
+	"strings"
+)
+
+var promptTemplateNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// template variables are written as {{name}}, with optional whitespace inside the braces
+var promptTemplateVarRegex = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_-]*)\s*\}\}`)
+
+func IsValidPromptTemplateName(name string) bool {
+	return len(name) <= 255 && promptTemplateNameRegex.MatchString(name)
+}
+
+// PromptTemplateVars returns the distinct variable names used in a template body, in order of first use.
+func PromptTemplateVars(body string) []string {
+	var vars []string
+	seen := map[string]bool{}
+
+	for _, match := range promptTemplateVarRegex.FindAllStringSubmatch(body, -1) {
+		name := match[1]
+		if !seen[name] {
+			seen[name] = true
+			vars = append(vars, name)
+		}
+	}
+
+	return vars
+}
+
+// ExpandPromptTemplate fills in a template body with the given variables. Any variables that aren't set are left as-is and returned in missing.
+func ExpandPromptTemplate(body string, vars map[string]string) (expanded string, missing []string) {
+	missingSet := map[string]bool{}
+
+	expanded = promptTemplateVarRegex.ReplaceAllStringFunc(body, func(match string) string {
+		name := promptTemplateVarRegex.FindStringSubmatch(match)[1]
+		if val, ok := vars[name]; ok {
+			return val
+		}
+		if !missingSet[name] {
+			missingSet[name] = true
+			missing = append(missing, name)
+		}
+		return match
+	})
+
+	return strings.TrimSpace(expanded), missing
+}
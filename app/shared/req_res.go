@@ -62,6 +62,27 @@ type InviteRequest struct {
 	OrgRoleId string `json:"orgRoleId"`
 }
 
+type CreatePromptTemplateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Body        string `json:"body"`
+}
+
+type UpdatePromptTemplateRequest struct {
+	Description string `json:"description"`
+	Body        string `json:"body"`
+}
+
+type SaveContextSetRequest struct {
+	Name string `json:"name"`
+}
+
+type SaveContextSetResponse struct {
+	Set     *ContextSet `json:"set"`
+	Changed bool        `json:"changed"`
+	Msg     string      `json:"msg"`
+}
+
 type CreateProjectRequest struct {
 	Name string `json:"name"`
 }
@@ -143,12 +164,27 @@ type RespondMissingFileRequest struct {
 }
 
 type LoadContextParams struct {
-	ContextType     ContextType `json:"contextType"`
-	Name            string      `json:"name"`
-	Url             string      `json:"url"`
-	FilePath        string      `json:"file_path"`
-	Body            string      `json:"body"`
-	ForceSkipIgnore bool        `json:"forceSkipIgnore"`
+	ContextType     ContextType     `json:"contextType"`
+	Name            string          `json:"name"`
+	Url             string          `json:"url"`
+	FilePath        string          `json:"file_path"`
+	Symbol          string          `json:"symbol,omitempty"`
+	StartLine       int             `json:"startLine,omitempty"`
+	EndLine         int             `json:"endLine,omitempty"`
+	Outline         bool            `json:"outline,omitempty"`
+	MapTokens       int             `json:"mapTokens,omitempty"` // token budget for repo maps
+	GitRef          string          `json:"gitRef,omitempty"`
+	Command         string          `json:"command,omitempty"`
+	Priority        ContextPriority `json:"priority,omitempty"`
+	Pinned          bool            `json:"pinned,omitempty"`
+	Body            string          `json:"body"`
+	FileBody        string          `json:"fileBody,omitempty"` // full file for symbol, line range, and outline contexts
+	ForceSkipIgnore bool            `json:"forceSkipIgnore"`
+	Redacted        bool            `json:"redacted,omitempty"`
+
+	// content hashes of Body and FileBody. When set, a body can be left empty if the server already has it.
+	BodyHash     string `json:"bodyHash,omitempty"`
+	FileBodyHash string `json:"fileBodyHash,omitempty"`
 }
 
 type LoadContextRequest []*LoadContextParams
@@ -156,29 +192,68 @@ type LoadContextRequest []*LoadContextParams
 type LoadContextResponse struct {
 	TokensAdded       int    `json:"tokensAdded"`
 	TotalTokens       int    `json:"totalTokens"`
-	MaxTokensExceeded bool   `json:"maxTokensExceeded"`
+	MaxTokensExceeded bool   `json:"maxTokensExceeded"` // pinned context alone doesn't fit in MaxTokens
 	MaxTokens         int    `json:"maxTokens"`
+	PinnedTokens      int    `json:"pinnedTokens,omitempty"`
 	Msg               string `json:"msg"`
 }
 
 type UpdateContextParams struct {
-	Body string `json:"body"`
+	Body         string `json:"body"`
+	FileBody     string `json:"fileBody,omitempty"` // full file for symbol and line range contexts
+	BodyHash     string `json:"bodyHash,omitempty"`
+	FileBodyHash string `json:"fileBodyHash,omitempty"`
 }
 
 type UpdateContextRequest map[string]*UpdateContextParams
 
 type UpdateContextResponse = LoadContextResponse
 
+// MissingContextBlobsRequest asks which context bodies, by content hash, need to be uploaded: any that the branch's context doesn't already reference
+type MissingContextBlobsRequest struct {
+	Hashes []string `json:"hashes"`
+}
+
+type MissingContextBlobsResponse struct {
+	Missing []string `json:"missing"`
+}
+
 type DeleteContextRequest struct {
 	Ids map[string]bool `json:"ids"`
 }
 
+// SetContextPriorityRequest changes the priority and/or pin of the contexts in Ids. Nil fields are left as they are.
+type SetContextPriorityRequest struct {
+	Ids      map[string]bool  `json:"ids"`
+	Priority *ContextPriority `json:"priority,omitempty"`
+	Pinned   *bool            `json:"pinned,omitempty"`
+}
+
+type SetContextPriorityResponse struct {
+	Msg string `json:"msg"`
+}
+
 type DeleteContextResponse struct {
 	TokensRemoved int    `json:"tokensRemoved"`
 	TotalTokens   int    `json:"totalTokens"`
 	Msg           string `json:"msg"`
 }
 
+// ApplyPlanRequest limits an apply to the pending changes for FilePaths. With no paths, every pending change is applied.
+type ApplyPlanRequest struct {
+	FilePaths []string `json:"filePaths,omitempty"`
+	// what each applied file was written as, after merging local edits, with secrets masked if its context masks them
+	ContentsByPath map[string]string `json:"contentsByPath,omitempty"`
+}
+
+type UnapplyPlanRequest struct {
+	ResultIds []string `json:"resultIds"`
+	// the bodies file contexts had before the apply updated them, keyed by path
+	ContextBodiesByPath map[string]string `json:"contextBodiesByPath"`
+	// paths of new files that the apply loaded into context
+	RemoveContextPaths []string `json:"removeContextPaths"`
+}
+
 type RejectFileRequest struct {
 	FilePath string `json:"filePath"`
 }
@@ -192,6 +267,16 @@ type RewindPlanResponse struct {
 	LatestCommit string `json:"latestCommit"`
 }
 
+type RetryPlanRequest struct {
+	Name string `json:"name"`
+}
+
+type RetryPlanResponse struct {
+	Branch string `json:"branch"`
+	Prompt string `json:"prompt"`
+	Sha    string `json:"sha"`
+}
+
 type LogResponse struct {
 	Shas []string `json:"shas"`
 	Body string   `json:"body"`
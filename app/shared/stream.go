@@ -1,13 +1,37 @@
 package shared
 
+import "time"
+
+// legacy framing used by clients that don't send the stream protocol header
 const STREAM_MESSAGE_SEPARATOR = "@@PX@@"
 
+// version 2 of the stream protocol uses server-sent events with sequence numbers and heartbeats
+const STREAM_PROTOCOL_VERSION = 2
+const STREAM_PROTOCOL_HEADER = "X-Plandex-Stream-Protocol"
+const STREAM_HEARTBEAT_INTERVAL = 15 * time.Second
+
+// if no event or heartbeat is received within this interval, the client treats the connection as dead
+const STREAM_HEARTBEAT_TIMEOUT = 3 * STREAM_HEARTBEAT_INTERVAL
+
 type BuildInfo struct {
 	Path      string `json:"path"`
 	NumTokens int    `json:"numTokens"`
 	Finished  bool   `json:"finished"`
 }
 
+type AutoContinueDecision struct {
+	ShouldContinue bool   `json:"shouldContinue"`
+	Iteration      int    `json:"iteration"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// a user connected to a plan's stream
+type StreamWatcher struct {
+	UserId   string `json:"userId"`
+	UserName string `json:"userName"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
 type StreamMessageType string
 
 const (
@@ -21,20 +45,46 @@ const (
 	StreamMessageAborted           StreamMessageType = "aborted"
 	StreamMessageFinished          StreamMessageType = "finished"
 	StreamMessageError             StreamMessageType = "error"
+	StreamMessageHeartbeat         StreamMessageType = "heartbeat"
+	StreamMessageAutoContinue      StreamMessageType = "autoContinue"
+	StreamMessageWatchers          StreamMessageType = "watchers"
+	StreamMessageContextBudget     StreamMessageType = "contextBudget"
 )
 
 type StreamMessage struct {
 	Type StreamMessageType `json:"type"`
+	Seq  int               `json:"seq,omitempty"`
 
 	ReplyChunk string `json:"replyChunk,omitempty"`
 
 	BuildInfo       *BuildInfo               `json:"buildInfo,omitempty"`
+	AutoContinue    *AutoContinueDecision    `json:"autoContinue,omitempty"`
+	Watchers        []*StreamWatcher         `json:"watchers,omitempty"`
+	ContextBudget   *ContextBudgetReport     `json:"contextBudget,omitempty"`
 	Description     *ConvoMessageDescription `json:"description,omitempty"`
 	Error           *ApiError                `json:"error,omitempty"`
 	MissingFilePath string                   `json:"missingFilePath,omitempty"`
-	ModelStreamId   string                   `json:"modelStreamId,omitempty"`
+	// set when the missing file is loaded only as an outline
+	MissingFileOutline bool   `json:"missingFileOutline,omitempty"`
+	ModelStreamId      string `json:"modelStreamId,omitempty"`
 
 	InitPrompt    string   `json:"initPrompt,omitempty"`
 	InitReplies   []string `json:"initReplies,omitempty"`
 	InitBuildOnly bool     `json:"initBuildOnly,omitempty"`
+	// set on the connect message when the client can only watch the stream, not stop it or respond to prompts
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+type StreamLogEntry struct {
+	Ts      time.Time      `json:"ts"`
+	Message *StreamMessage `json:"message"`
+}
+
+type StreamLog struct {
+	ModelStreamId string            `json:"modelStreamId"`
+	PlanId        string            `json:"planId"`
+	Branch        string            `json:"branch"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	FinishedAt    *time.Time        `json:"finishedAt"`
+	Entries       []*StreamLogEntry `json:"entries"`
 }